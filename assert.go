@@ -0,0 +1,76 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// AssertionError is returned by Fail and the Assert functions when template data fails
+// validation.  Callers may use errors.As on the error returned by template execution to
+// distinguish data validation failures from template bugs.
+type AssertionError struct {
+	Message string
+}
+
+func (e *AssertionError) Error() string { return e.Message }
+
+/*
+ * Assertions
+ */
+
+// Fail unconditionally aborts template execution with an *AssertionError containing msg.
+func Fail(msg string) (string, error) { return "", &AssertionError{Message: msg} }
+
+// Assert aborts template execution with an *AssertionError containing msg if operand is false.
+// Otherwise it returns an empty string, making it suitable for use inline:
+// {{ gt .Replicas 0 | Assert "replicas must be positive" }}.
+func Assert(msg string, operand bool) (string, error) {
+	if !operand {
+		return Fail(msg)
+	}
+	return "", nil
+}
+
+// AssertMatches returns operand unchanged if it matches pattern.  Otherwise it returns an
+// *AssertionError.  Pattern is treated as a regexp.  An invalid pattern returns an
+// *ArgumentError rather than an *AssertionError.
+func AssertMatches(pattern string, operand string) (string, error) {
+	rex, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", wrapArgumentError("AssertMatches", 0, err)
+	}
+	if !rex.MatchString(operand) {
+		return "", &AssertionError{Message: fmt.Sprintf("%q does not match pattern %q", operand, pattern)}
+	}
+	return operand, nil
+}
+
+// AssertType returns operand unchanged if its type matches typeName.  Otherwise it returns an
+// *AssertionError.  TypeName may be either the full Go type (e.g. "map[string]interface {}") or
+// the type's kind (e.g. "map").  A nil operand matches a typeName of "nil".
+func AssertType(typeName string, operand interface{}) (interface{}, error) {
+	if typeName != TypeOf(operand) && typeName != KindOf(operand) {
+		return nil, &AssertionError{Message: fmt.Sprintf("expected type %s, received %s", typeName, TypeOf(operand))}
+	}
+	return operand, nil
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+	"text/template"
+)
+
+func TestFail(t *testing.T) {
+	msg := "bad input"
+	_, err := Fail(msg)
+	var assertErr *AssertionError
+	if !errors.As(err, &assertErr) {
+		t.Fatalf("Fail returned unexpected error type.  Msg: %s, Received: %#v", msg, err)
+	}
+	if assertErr.Message != msg {
+		t.Errorf("Fail message incorrect.  Expected: %s, Received: %s", msg, assertErr.Message)
+	}
+}
+
+func TestAssert(t *testing.T) {
+	var tests = []struct {
+		Operand bool
+		Valid   bool
+	}{
+		{Operand: true, Valid: true},
+		{Operand: false, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Assert("failed", test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Assert encountered unexpected error: %s.  Operand: %t", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Assert failed to return an error.  Operand: %t", test.Operand)
+		}
+		if result != "" {
+			t.Errorf("Assert result incorrect.  Operand: %t, Expected: \"\", Received: %#v", test.Operand, result)
+		}
+	}
+}
+
+func TestAssertMatches(t *testing.T) {
+	var tests = []struct {
+		Operand   string
+		Pattern   string
+		Valid     bool
+		Assertion bool
+	}{
+		{Operand: "web-01", Pattern: "^[a-z]+-[0-9]+$", Valid: true},
+		{Operand: "Web 01", Pattern: "^[a-z]+-[0-9]+$", Valid: false, Assertion: true},
+		{Operand: "web-01", Pattern: "[[:bogus:]]", Valid: false, Assertion: false},
+	}
+
+	for _, test := range tests {
+		result, err := AssertMatches(test.Pattern, test.Operand)
+		if test.Valid {
+			if err != nil {
+				t.Errorf("AssertMatches encountered unexpected error: %s.  Operand: %s, Pattern: %s", err, test.Operand, test.Pattern)
+			}
+			if result != test.Operand {
+				t.Errorf("AssertMatches result incorrect.  Operand: %s, Pattern: %s, Received: %s", test.Operand, test.Pattern, result)
+			}
+			continue
+		}
+		var assertErr *AssertionError
+		if errors.As(err, &assertErr) != test.Assertion {
+			t.Errorf("AssertMatches returned unexpected error.  Operand: %s, Pattern: %s, Received: %#v", test.Operand, test.Pattern, err)
+		}
+	}
+}
+
+func TestAssertType(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		TypeName string
+		Valid    bool
+	}{
+		{Operand: 42, TypeName: "int", Valid: true},
+		{Operand: "42", TypeName: "int", Valid: false},
+		{Operand: map[string]interface{}{}, TypeName: "map", Valid: true},
+		{Operand: map[string]interface{}{}, TypeName: "map[string]interface {}", Valid: true},
+		{Operand: []string{}, TypeName: "map", Valid: false},
+		{Operand: nil, TypeName: "nil", Valid: true},
+		{Operand: nil, TypeName: "string", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := AssertType(test.TypeName, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("AssertType encountered unexpected error: %s.  Operand: %#v, TypeName: %s", err, test.Operand, test.TypeName)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("AssertType failed to return an error.  Operand: %#v, TypeName: %s, Received: %#v", test.Operand, test.TypeName, result)
+		}
+	}
+}
+
+func TestAssertionTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("test").Funcs(FuncMap).Parse(`{{ gt .Replicas 0 | Assert "replicas must be positive" }}`))
+	err := tmpl.Execute(ioutil.Discard, map[string]int{"Replicas": 0})
+	var assertErr *AssertionError
+	if !errors.As(err, &assertErr) {
+		t.Fatalf("Template execution returned unexpected error type: %#v", err)
+	}
+	if assertErr.Message != "replicas must be positive" {
+		t.Errorf("Template assertion message incorrect.  Received: %s", assertErr.Message)
+	}
+}
@@ -0,0 +1,370 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var placeholderRegex = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Catalog holds translated messages for use with CatalogFuncMap.  Messages are keyed by
+// language and message ID.  A message is either a single text or a set of texts keyed by CLDR
+// plural category ("zero", "one", "two", "few", "many", or "other").  Message texts may contain
+// named placeholders such as "{name}", which are interpolated by T and Pluralize.
+//
+// Catalogs are loaded from in-memory data, never from the host filesystem.  A Catalog is not
+// safe for concurrent modification, but may be read concurrently once loaded.
+type Catalog struct {
+	messages map[string]map[string]map[string]string
+}
+
+// NewCatalog returns an empty Catalog.
+func NewCatalog() *Catalog {
+	return &Catalog{messages: make(map[string]map[string]map[string]string)}
+}
+
+// Add adds a message with a single text, replacing any existing message with the same id.
+func (c *Catalog) Add(lang, id, text string) {
+	c.add(lang, id, map[string]string{"other": text})
+}
+
+// AddPlural adds a message with texts keyed by CLDR plural category, replacing any existing
+// message with the same id.  An "other" text is required.
+func (c *Catalog) AddPlural(lang, id string, forms map[string]string) error {
+	if _, ok := forms["other"]; !ok {
+		return fmt.Errorf("plural message %q is missing the \"other\" form", id)
+	}
+	for category := range forms {
+		switch category {
+		case "zero", "one", "two", "few", "many", "other":
+		default:
+			return fmt.Errorf("plural message %q has invalid category %q", id, category)
+		}
+	}
+	copied := make(map[string]string, len(forms))
+	for category, text := range forms {
+		copied[category] = text
+	}
+	c.add(lang, id, copied)
+	return nil
+}
+
+// LoadJSON adds the messages in data, a JSON object mapping message ids to either a text or
+// an object mapping plural categories to texts.  Example:
+//
+//	{"greeting": "Hello, {name}!", "items": {"one": "{count} item", "other": "{count} items"}}
+func (c *Catalog) LoadJSON(lang string, data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(raw))
+	for id := range raw {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		var text string
+		if err := json.Unmarshal(raw[id], &text); err == nil {
+			c.Add(lang, id, text)
+			continue
+		}
+		var forms map[string]string
+		if err := json.Unmarshal(raw[id], &forms); err != nil {
+			return fmt.Errorf("message %q must be a string or an object of plural forms", id)
+		}
+		if err := c.AddPlural(lang, id, forms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadPO adds the messages in data, a gettext PO file.  The msgid is used as the message id.
+// Plural msgstr[N] entries are assigned to plural categories in the conventional gettext order
+// for lang.  The header entry, untranslated entries, fuzzy entries, and entries with a msgctxt
+// are skipped.
+func (c *Catalog) LoadPO(lang string, data []byte) error {
+	categories, _, err := lookupPluralForms(lang)
+	if err != nil {
+		return err
+	}
+
+	var entries []*poEntry
+	entry := &poEntry{}
+	var target *string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			if entry.started() {
+				entries, entry = append(entries, entry), &poEntry{}
+			}
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				entry.fuzzy = true
+			}
+			continue
+		case strings.HasPrefix(line, `"`):
+			if target == nil {
+				return fmt.Errorf("line %d: unexpected string continuation", lineno)
+			}
+			str, err := strconv.Unquote(line)
+			if err != nil {
+				return fmt.Errorf("line %d: invalid string: %s", lineno, err)
+			}
+			*target += str
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return fmt.Errorf("line %d: invalid syntax", lineno)
+		}
+		keyword := fields[0]
+		str, err := strconv.Unquote(strings.TrimSpace(fields[1]))
+		if err != nil {
+			return fmt.Errorf("line %d: invalid string: %s", lineno, err)
+		}
+		if (keyword == "msgctxt" || keyword == "msgid") && len(entry.strs) > 0 {
+			entries, entry = append(entries, entry), &poEntry{}
+		}
+		switch {
+		case keyword == "msgctxt":
+			entry.hasContext = true
+			target = &entry.context
+		case keyword == "msgid":
+			entry.hasID = true
+			target = &entry.id
+		case keyword == "msgid_plural":
+			entry.plural = true
+			target = &entry.idPlural
+		case keyword == "msgstr":
+			entry.strs = append(entry.strs, "")
+			target = &entry.strs[len(entry.strs)-1]
+		case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+			n, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+			if err != nil || n != len(entry.strs) {
+				return fmt.Errorf("line %d: invalid plural index in %s", lineno, keyword)
+			}
+			entry.strs = append(entry.strs, "")
+			target = &entry.strs[n]
+		default:
+			return fmt.Errorf("line %d: unknown keyword %q", lineno, keyword)
+		}
+		*target = str
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if entry.started() {
+		entries = append(entries, entry)
+	}
+
+	for _, entry := range entries {
+		if !entry.hasID {
+			return fmt.Errorf("PO entry is missing msgid")
+		}
+		if entry.id == "" || entry.fuzzy || entry.hasContext || !entry.translated() {
+			continue
+		}
+		if !entry.plural {
+			c.Add(lang, entry.id, entry.strs[0])
+			continue
+		}
+		if len(entry.strs) > len(categories) {
+			return fmt.Errorf("PO entry %q has %d plural forms, but %s has %d", entry.id, len(entry.strs), lang, len(categories))
+		}
+		plurals := make(map[string]string, len(entry.strs)+1)
+		for i, str := range entry.strs {
+			plurals[categories[i]] = str
+		}
+		plurals["other"] = entry.strs[len(entry.strs)-1]
+		if err := c.AddPlural(lang, entry.id, plurals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CatalogFuncMap returns a map of functions that translate messages from catalog into lang,
+// such as "de" or "pt-BR".  If the catalog has no messages for the full tag, the language
+// portion of the tag is used.  The returned map is meant for use with
+// text/template.Template.Funcs() alongside FuncMap:
+//
+//	T(id string, args ...interface{}) (string, error)
+//	    Returns the text of message id with its placeholders interpolated.
+//	Pluralize(id string, count interface{}, args ...interface{}) (string, error)
+//	    Returns the text of message id for the CLDR plural category of count in lang, with its
+//	    placeholders interpolated.  The "{count}" placeholder is set to count.
+//
+// Placeholder values in args are given either as a single map, or as alternating names and
+// values: {{ T "greeting" "name" .User.Name }}.  Referencing a placeholder without a value is
+// an error, as is a missing message.
+func CatalogFuncMap(catalog *Catalog, lang string) (map[string]interface{}, error) {
+	if _, _, err := lookupPluralForms(lang); err != nil {
+		return nil, err
+	}
+	messages := catalog.messages[normalizeLanguage(lang)]
+	if messages == nil {
+		messages = catalog.messages[baseLanguage(lang)]
+	}
+	if messages == nil {
+		return nil, fmt.Errorf("catalog has no messages for language %q", lang)
+	}
+
+	// translate reports errors as fn, whose placeholder values start at argument valuesArg.
+	translate := func(fn string, valuesArg int, id, category string, values map[string]interface{}) (string, error) {
+		texts, ok := messages[id]
+		if !ok {
+			return "", argumentError(fn, 0, ErrInvalidArgument, "no translation for message %q in %s", id, lang)
+		}
+		text, ok := texts[category]
+		if !ok {
+			text = texts["other"]
+		}
+		result, err := interpolate(text, values)
+		return result, wrapArgumentError(fn, valuesArg, err)
+	}
+	return map[string]interface{}{
+		"Pluralize": func(id string, count interface{}, args ...interface{}) (string, error) {
+			values, err := placeholderValues(args)
+			if err != nil {
+				return "", wrapArgumentError("Pluralize", 2, err)
+			}
+			category, err := pluralCategory(lang, count)
+			if err != nil {
+				return "", wrapArgumentError("Pluralize", 1, err)
+			}
+			if _, ok := values["count"]; !ok {
+				values["count"] = count
+			}
+			return translate("Pluralize", 2, id, category, values)
+		},
+		"T": func(id string, args ...interface{}) (string, error) {
+			values, err := placeholderValues(args)
+			if err != nil {
+				return "", wrapArgumentError("T", 1, err)
+			}
+			return translate("T", 1, id, "other", values)
+		},
+	}, nil
+}
+
+type poEntry struct {
+	context    string
+	id         string
+	idPlural   string
+	strs       []string
+	hasContext bool
+	hasID      bool
+	plural     bool
+	fuzzy      bool
+}
+
+func (e *poEntry) started() bool { return e.hasContext || e.hasID || len(e.strs) > 0 }
+
+func (e *poEntry) translated() bool {
+	for _, str := range e.strs {
+		if str != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Catalog) add(lang, id string, forms map[string]string) {
+	lang = normalizeLanguage(lang)
+	if c.messages[lang] == nil {
+		c.messages[lang] = make(map[string]map[string]string)
+	}
+	c.messages[lang][id] = forms
+}
+
+// placeholderValues converts the trailing arguments of T and Pluralize to a map of
+// placeholder names to values.
+func placeholderValues(args []interface{}) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if len(args) == 1 {
+		v := reflect.ValueOf(args[0])
+		if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("placeholder values must be a map with string keys or name/value pairs")
+		}
+		for _, key := range v.MapKeys() {
+			values[key.String()] = v.MapIndex(key).Interface()
+		}
+		return values, nil
+	}
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("placeholder values must be name/value pairs")
+	}
+	for i := 0; i < len(args); i += 2 {
+		name, ok := args[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("placeholder name must be a string, received %s", TypeOf(args[i]))
+		}
+		values[name] = args[i+1]
+	}
+	return values, nil
+}
+
+// interpolate replaces the named placeholders in text with values.
+func interpolate(text string, values map[string]interface{}) (string, error) {
+	var missing string
+	result := placeholderRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		value, ok := values[name]
+		if !ok {
+			if missing == "" {
+				missing = name
+			}
+			return placeholder
+		}
+		return fmt.Sprint(value)
+	})
+	if missing != "" {
+		return "", fmt.Errorf("no value for placeholder {%s} in %q", missing, text)
+	}
+	return result, nil
+}
+
+func normalizeLanguage(lang string) string {
+	return strings.ToLower(strings.Replace(lang, "_", "-", -1))
+}
+
+func baseLanguage(lang string) string {
+	lang = normalizeLanguage(lang)
+	if i := strings.Index(lang, "-"); i >= 0 {
+		return lang[:i]
+	}
+	return lang
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+)
+
+const testPO = `# German translations
+msgid ""
+msgstr ""
+"Language: de\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+#: templates/welcome.txt:1
+msgid "greeting"
+msgstr "Hallo, {name}!"
+
+msgid "files"
+msgid_plural "files"
+msgstr[0] "{count} Datei"
+msgstr[1] "{count} Dateien"
+
+msgid "multiline"
+msgstr ""
+"Erste Zeile\n"
+"Zweite Zeile"
+
+#, fuzzy
+msgid "fuzzy"
+msgstr "Unscharf"
+
+msgid "untranslated"
+msgstr ""
+
+msgctxt "menu"
+msgid "contextual"
+msgstr "Kontext"
+`
+
+func TestCatalogLoadPO(t *testing.T) {
+	catalog := NewCatalog()
+	if err := catalog.LoadPO("de", []byte(testPO)); err != nil {
+		t.Fatalf("LoadPO encountered unexpected error: %s", err)
+	}
+	var tests = []struct {
+		ID       string
+		Expected map[string]string
+	}{
+		{ID: "greeting", Expected: map[string]string{"other": "Hallo, {name}!"}},
+		{ID: "files", Expected: map[string]string{"one": "{count} Datei", "other": "{count} Dateien"}},
+		{ID: "multiline", Expected: map[string]string{"other": "Erste Zeile\nZweite Zeile"}},
+		{ID: "fuzzy", Expected: nil},
+		{ID: "untranslated", Expected: nil},
+		{ID: "contextual", Expected: nil},
+		{ID: "", Expected: nil},
+	}
+
+	for _, test := range tests {
+		result := catalog.messages["de"][test.ID]
+		if len(result) != len(test.Expected) {
+			t.Errorf("LoadPO result incorrect.  ID: %s, Expected: %#v, Received: %#v", test.ID, test.Expected, result)
+			continue
+		}
+		for category, text := range test.Expected {
+			if result[category] != text {
+				t.Errorf("LoadPO result incorrect.  ID: %s, Expected: %#v, Received: %#v", test.ID, test.Expected, result)
+			}
+		}
+	}
+}
+
+func TestCatalogLoadPOPluralOrder(t *testing.T) {
+	po := `msgid "files"
+msgid_plural "files"
+msgstr[0] "{count} plik"
+msgstr[1] "{count} pliki"
+msgstr[2] "{count} plików"
+`
+	catalog := NewCatalog()
+	if err := catalog.LoadPO("pl", []byte(po)); err != nil {
+		t.Fatalf("LoadPO encountered unexpected error: %s", err)
+	}
+	expected := map[string]string{"one": "{count} plik", "few": "{count} pliki", "many": "{count} plików", "other": "{count} plików"}
+	result := catalog.messages["pl"]["files"]
+	if len(result) != len(expected) {
+		t.Fatalf("LoadPO result incorrect.  Expected: %#v, Received: %#v", expected, result)
+	}
+	for category, text := range expected {
+		if result[category] != text {
+			t.Errorf("LoadPO result incorrect.  Expected: %#v, Received: %#v", expected, result)
+		}
+	}
+}
+
+func TestCatalogLoadPOInvalid(t *testing.T) {
+	var tests = []struct {
+		Lang string
+		PO   string
+	}{
+		{Lang: "xx", PO: `msgid "a"` + "\n" + `msgstr "b"`},
+		{Lang: "en", PO: `msgid "a` + "\n" + `msgstr "b"`},
+		{Lang: "en", PO: `"orphan"`},
+		{Lang: "en", PO: `msgid "a"` + "\n" + `msgbogus "b"`},
+		{Lang: "en", PO: `msgid "a"` + "\n" + `msgid_plural "a"` + "\n" + `msgstr[1] "b"`},
+		{Lang: "en", PO: `msgid "a"` + "\n" + `msgid_plural "a"` + "\n" + `msgstr[0] "b"` + "\n" + `msgstr[1] "c"` + "\n" + `msgstr[2] "d"`},
+		{Lang: "en", PO: `msgstr "b"`},
+	}
+
+	for _, test := range tests {
+		if err := NewCatalog().LoadPO(test.Lang, []byte(test.PO)); err == nil {
+			t.Errorf("LoadPO failed to return an error.  Lang: %s, PO: %q", test.Lang, test.PO)
+		}
+	}
+}
+
+func TestCatalogLoadJSON(t *testing.T) {
+	catalog := NewCatalog()
+	err := catalog.LoadJSON("en", []byte(`{"greeting": "Hello, {name}!", "files": {"one": "{count} file", "other": "{count} files"}}`))
+	if err != nil {
+		t.Fatalf("LoadJSON encountered unexpected error: %s", err)
+	}
+	if result := catalog.messages["en"]["greeting"]["other"]; result != "Hello, {name}!" {
+		t.Errorf("LoadJSON result incorrect.  Expected: %q, Received: %q", "Hello, {name}!", result)
+	}
+	if result := catalog.messages["en"]["files"]["one"]; result != "{count} file" {
+		t.Errorf("LoadJSON result incorrect.  Expected: %q, Received: %q", "{count} file", result)
+	}
+
+	var invalid = []string{
+		`[]`,
+		`{"files": {"one": "{count} file"}}`,
+		`{"files": {"single": "{count} file", "other": "{count} files"}}`,
+		`{"files": 42}`,
+	}
+	for _, data := range invalid {
+		if err := NewCatalog().LoadJSON("en", []byte(data)); err == nil {
+			t.Errorf("LoadJSON failed to return an error.  Data: %s", data)
+		}
+	}
+}
+
+func TestCatalogFuncMap(t *testing.T) {
+	catalog := NewCatalog()
+	catalog.Add("ru", "greeting", "Привет, {name}!")
+	catalog.AddPlural("ru", "files", map[string]string{
+		"one":   "{count} файл",
+		"few":   "{count} файла",
+		"many":  "{count} файлов",
+		"other": "{count} файла",
+	})
+	funcs, err := CatalogFuncMap(catalog, "ru-RU")
+	if err != nil {
+		t.Fatalf("CatalogFuncMap encountered unexpected error: %s", err)
+	}
+	T := funcs["T"].(func(string, ...interface{}) (string, error))
+	Pluralize := funcs["Pluralize"].(func(string, interface{}, ...interface{}) (string, error))
+
+	var tests = []struct {
+		Func     string
+		ID       string
+		Count    interface{}
+		Args     []interface{}
+		Expected string
+		Valid    bool
+	}{
+		{Func: "T", ID: "greeting", Args: []interface{}{"name", "Мир"}, Expected: "Привет, Мир!", Valid: true},
+		{Func: "T", ID: "greeting", Args: []interface{}{map[string]string{"name": "Мир"}}, Expected: "Привет, Мир!", Valid: true},
+		{Func: "T", ID: "greeting", Valid: false},
+		{Func: "T", ID: "greeting", Args: []interface{}{"name"}, Valid: false},
+		{Func: "T", ID: "greeting", Args: []interface{}{42, "Мир"}, Valid: false},
+		{Func: "T", ID: "missing", Valid: false},
+		{Func: "Pluralize", ID: "files", Count: 1, Expected: "1 файл", Valid: true},
+		{Func: "Pluralize", ID: "files", Count: 3, Expected: "3 файла", Valid: true},
+		{Func: "Pluralize", ID: "files", Count: 11, Expected: "11 файлов", Valid: true},
+		{Func: "Pluralize", ID: "files", Count: 1.5, Expected: "1.5 файла", Valid: true},
+		{Func: "Pluralize", ID: "greeting", Count: 1, Args: []interface{}{"name", "Мир"}, Expected: "Привет, Мир!", Valid: true},
+		{Func: "Pluralize", ID: "files", Count: "dog", Valid: false},
+	}
+
+	for _, test := range tests {
+		var result string
+		var err error
+		if test.Func == "T" {
+			result, err = T(test.ID, test.Args...)
+		} else {
+			result, err = Pluralize(test.ID, test.Count, test.Args...)
+		}
+		if test.Valid && err != nil {
+			t.Errorf("%s encountered unexpected error: %s.  ID: %s, Count: %#v, Args: %#v", test.Func, err, test.ID, test.Count, test.Args)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("%s failed to return an error.  ID: %s, Count: %#v, Args: %#v, Received: %s", test.Func, test.ID, test.Count, test.Args, result)
+		}
+		if result != test.Expected {
+			t.Errorf("%s result incorrect.  ID: %s, Count: %#v, Args: %#v, Expected: %s, Received: %s", test.Func, test.ID, test.Count, test.Args, test.Expected, result)
+		}
+	}
+
+	if _, err := CatalogFuncMap(catalog, "de"); err == nil {
+		t.Errorf("CatalogFuncMap failed to return an error for a language without messages")
+	}
+	if _, err := CatalogFuncMap(catalog, "xx"); err == nil {
+		t.Errorf("CatalogFuncMap failed to return an error for a language without plural rules")
+	}
+}
+
+func TestCatalogTemplate(t *testing.T) {
+	catalog := NewCatalog()
+	if err := catalog.LoadPO("de", []byte(testPO)); err != nil {
+		t.Fatalf("LoadPO encountered unexpected error: %s", err)
+	}
+	funcs, err := CatalogFuncMap(catalog, "de")
+	if err != nil {
+		t.Fatalf("CatalogFuncMap encountered unexpected error: %s", err)
+	}
+	tmpl := template.Must(template.New("test").Funcs(FuncMap).Funcs(funcs).Parse(`{{ T "greeting" "name" .Name }} {{ Pluralize "files" .Count }}`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]interface{}{"Name": "Welt", "Count": 1}); err != nil {
+		t.Fatalf("Template execution encountered unexpected error: %s", err)
+	}
+	expected := "Hallo, Welt! 1 Datei"
+	if buf.String() != expected {
+		t.Errorf("Template result incorrect.  Expected: %q, Received: %q", expected, buf.String())
+	}
+}
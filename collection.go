@@ -0,0 +1,347 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Indexed pairs a slice element with its index.  It is returned by Enumerate.
+type Indexed struct {
+	Index int
+	Value interface{}
+}
+
+/*
+ * Collection Transforms
+ *
+ * These functions accept slices or arrays of any element type.  Where possible, results keep
+ * the operand's element type, so Chunk on a []string returns a [][]string.
+ */
+
+// Chunk splits operand into consecutive slices of n elements.  The final chunk holds the
+// remaining elements and may be shorter: {{ range .Items | Chunk 3 }}<tr>...</tr>{{ end }}.
+func Chunk(n int, operand interface{}) (interface{}, error) {
+	if n <= 0 {
+		return nil, argumentError("Chunk", 0, ErrInvalidArgument, "chunk size %d is not positive", n)
+	}
+	v, err := sliceValue(operand)
+	if err != nil {
+		return nil, wrapArgumentError("Chunk", 1, err)
+	}
+	return windows("Chunk", 1, n, n, true, v)
+}
+
+// Partition splits operand into n consecutive slices whose lengths differ by at most one, with
+// longer slices first.  If operand has fewer than n elements, the trailing slices are empty.
+func Partition(n int, operand interface{}) (interface{}, error) {
+	if n <= 0 {
+		return nil, argumentError("Partition", 0, ErrInvalidArgument, "partition count %d is not positive", n)
+	}
+	v, err := sliceValue(operand)
+	if err != nil {
+		return nil, wrapArgumentError("Partition", 1, err)
+	}
+	if n > DefaultLimits.MaxElements {
+		return nil, limitError("Partition", 0, n)
+	}
+	parts := reflect.MakeSlice(reflect.SliceOf(v.Type()), n, n)
+	size, extra, start := v.Len()/n, v.Len()%n, 0
+	for i := 0; i < n; i++ {
+		end := start + size
+		if i < extra {
+			end++
+		}
+		parts.Index(i).Set(v.Slice3(start, end, end))
+		start = end
+	}
+	return parts.Interface(), nil
+}
+
+// Window returns the slices of size consecutive elements of operand starting at every step-th
+// element.  Only complete windows are returned: Window 2 1 on [a b c] returns [[a b] [b c]].
+func Window(size, step int, operand interface{}) (interface{}, error) {
+	if size <= 0 {
+		return nil, argumentError("Window", 0, ErrInvalidArgument, "window size %d is not positive", size)
+	}
+	if step <= 0 {
+		return nil, argumentError("Window", 1, ErrInvalidArgument, "step %d is not positive", step)
+	}
+	v, err := sliceValue(operand)
+	if err != nil {
+		return nil, wrapArgumentError("Window", 2, err)
+	}
+	return windows("Window", 2, size, step, false, v)
+}
+
+// Zip pairs the elements of a and operand, returning a slice of two-element slices
+// [a[i] operand[i]].  The result is as long as the shorter input.
+func Zip(a, operand interface{}) (interface{}, error) {
+	left, err := sliceValue(a)
+	if err != nil {
+		return nil, wrapArgumentError("Zip", 0, err)
+	}
+	right, err := sliceValue(operand)
+	if err != nil {
+		return nil, wrapArgumentError("Zip", 1, err)
+	}
+	pairType := commonSliceType(left, right)
+	n := left.Len()
+	if right.Len() < n {
+		n = right.Len()
+	}
+	zipped := reflect.MakeSlice(reflect.SliceOf(pairType), n, n)
+	for i := 0; i < n; i++ {
+		pair := reflect.MakeSlice(pairType, 2, 2)
+		pair.Index(0).Set(left.Index(i))
+		pair.Index(1).Set(right.Index(i))
+		zipped.Index(i).Set(pair)
+	}
+	return zipped.Interface(), nil
+}
+
+// Unzip reverses Zip.  Operand is a slice of equal-length slices, and the result holds one slice
+// per position: Unzip on [[a 1] [b 2]] returns [[a b] [1 2]].
+func Unzip(operand interface{}) (interface{}, error) {
+	v, err := sliceValue(operand)
+	if err != nil {
+		return nil, wrapArgumentError("Unzip", 0, err)
+	}
+	if v.Len() == 0 {
+		return []interface{}{}, nil
+	}
+	tuples := make([]reflect.Value, v.Len())
+	for i := range tuples {
+		if tuples[i], err = sliceValue(v.Index(i).Interface()); err != nil {
+			return nil, argumentError("Unzip", 0, ErrInvalidArgument, "element %d: %s", i, err)
+		}
+		if tuples[i].Len() != tuples[0].Len() {
+			return nil, argumentError("Unzip", 0, ErrInvalidArgument, "cannot unzip elements of differing lengths %d and %d", tuples[0].Len(), tuples[i].Len())
+		}
+	}
+	columnType := commonSliceType(tuples...)
+	unzipped := reflect.MakeSlice(reflect.SliceOf(columnType), tuples[0].Len(), tuples[0].Len())
+	for j := 0; j < tuples[0].Len(); j++ {
+		column := reflect.MakeSlice(columnType, len(tuples), len(tuples))
+		for i, tuple := range tuples {
+			column.Index(i).Set(tuple.Index(j))
+		}
+		unzipped.Index(j).Set(column)
+	}
+	return unzipped.Interface(), nil
+}
+
+// Flatten replaces nested slices in operand with their elements, descending depth levels.  A
+// negative depth flattens completely.  The result keeps the innermost element type when it is
+// known from operand's type, as with [][]string, and is a []interface{} otherwise.  Operand may
+// be nested at most DefaultLimits.MaxDepth levels deep, and the result may hold at most
+// DefaultLimits.MaxElements elements.
+func Flatten(depth int, operand interface{}) (interface{}, error) {
+	v, err := sliceValue(operand)
+	if err != nil {
+		return nil, wrapArgumentError("Flatten", 1, err)
+	}
+	// A slice type may contain itself, as with type T []T, so the descent through element types
+	// is bounded like the descent through values.
+	elemType := v.Type().Elem()
+	for d, level := depth, 0; d != 0 && isSliceKind(elemType.Kind()); d, level = d-1, level+1 {
+		if level >= DefaultLimits.MaxDepth {
+			break
+		}
+		elemType = elemType.Elem()
+	}
+	flattened, err := flattenInto(reflect.MakeSlice(reflect.SliceOf(elemType), 0, v.Len()), v, depth, 0)
+	if err != nil {
+		return nil, err
+	}
+	return flattened.Interface(), nil
+}
+
+// Compact returns a copy of operand without its empty elements: nil, false, zero numbers, and
+// empty strings, slices, and maps.
+func Compact(operand interface{}) (interface{}, error) {
+	v, err := sliceValue(operand)
+	if err != nil {
+		return nil, wrapArgumentError("Compact", 0, err)
+	}
+	compacted := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if !isEmptyValue(v.Index(i)) {
+			compacted = reflect.Append(compacted, v.Index(i))
+		}
+	}
+	return compacted.Interface(), nil
+}
+
+// Rotate returns a copy of operand with its elements rotated n places to the left, so that
+// operand[n] comes first.  A negative n rotates to the right.
+func Rotate(n int, operand interface{}) (interface{}, error) {
+	v, err := sliceValue(operand)
+	if err != nil {
+		return nil, wrapArgumentError("Rotate", 1, err)
+	}
+	rotated := reflect.MakeSlice(v.Type(), 0, v.Len())
+	if v.Len() == 0 {
+		return rotated.Interface(), nil
+	}
+	n %= v.Len()
+	if n < 0 {
+		n += v.Len()
+	}
+	rotated = reflect.AppendSlice(rotated, v.Slice(n, v.Len()))
+	return reflect.AppendSlice(rotated, v.Slice(0, n)).Interface(), nil
+}
+
+// Interleave alternates the elements of a and operand, starting with a.  Once the shorter input
+// is exhausted, the remaining elements of the longer one are appended.
+func Interleave(a, operand interface{}) (interface{}, error) {
+	left, err := sliceValue(a)
+	if err != nil {
+		return nil, wrapArgumentError("Interleave", 0, err)
+	}
+	right, err := sliceValue(operand)
+	if err != nil {
+		return nil, wrapArgumentError("Interleave", 1, err)
+	}
+	interleaved := reflect.MakeSlice(commonSliceType(left, right), 0, left.Len()+right.Len())
+	for i := 0; i < left.Len() || i < right.Len(); i++ {
+		if i < left.Len() {
+			interleaved = reflect.Append(interleaved, left.Index(i))
+		}
+		if i < right.Len() {
+			interleaved = reflect.Append(interleaved, right.Index(i))
+		}
+	}
+	return interleaved.Interface(), nil
+}
+
+// Enumerate pairs each element of operand with its index:
+// {{ range Enumerate .Items }}{{ .Index }}: {{ .Value }}{{ end }}.
+func Enumerate(operand interface{}) ([]Indexed, error) {
+	v, err := sliceValue(operand)
+	if err != nil {
+		return nil, wrapArgumentError("Enumerate", 0, err)
+	}
+	enumerated := make([]Indexed, v.Len())
+	for i := range enumerated {
+		enumerated[i] = Indexed{Index: i, Value: v.Index(i).Interface()}
+	}
+	return enumerated, nil
+}
+
+var interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// sliceValue returns operand as a reflect.Value of kind slice.  Arrays are copied into a slice
+// of the same element type.
+func sliceValue(operand interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(operand)
+	switch v.Kind() {
+	case reflect.Slice:
+		return v, nil
+	case reflect.Array:
+		s := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), v.Len(), v.Len())
+		reflect.Copy(s, v)
+		return s, nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %s as a slice", TypeOf(operand))
+}
+
+// commonSliceType returns the slice type of the values if they share an element type, or
+// []interface{} otherwise.
+func commonSliceType(values ...reflect.Value) reflect.Type {
+	t := values[0].Type()
+	for _, v := range values[1:] {
+		if v.Type() != t {
+			return reflect.SliceOf(interfaceType)
+		}
+	}
+	return t
+}
+
+// windows implements Chunk and Window, which are reported as fn with operand at index arg in
+// errors.  If partial is true, a final window shorter than size is included.
+func windows(fn string, arg, size, step int, partial bool, v reflect.Value) (interface{}, error) {
+	result := reflect.MakeSlice(reflect.SliceOf(v.Type()), 0, 0)
+	total := 0
+	for start := 0; start < v.Len(); start += step {
+		end := start + size
+		if end > v.Len() {
+			if !partial {
+				break
+			}
+			end = v.Len()
+		}
+		if total += end - start; total > DefaultLimits.MaxElements {
+			return nil, limitError(fn, arg, total)
+		}
+		result = reflect.Append(result, v.Slice3(start, end, end))
+	}
+	return result.Interface(), nil
+}
+
+// flattenInto appends the elements of src, found level levels below Flatten's operand, to dst,
+// descending depth further levels.
+func flattenInto(dst, src reflect.Value, depth, level int) (reflect.Value, error) {
+	if level > DefaultLimits.MaxDepth {
+		return dst, argumentError("Flatten", 1, ErrLimitExceeded, "operand is nested more than %d levels deep", DefaultLimits.MaxDepth)
+	}
+	for i := 0; i < src.Len(); i++ {
+		elem := src.Index(i)
+		if elem.Kind() == reflect.Interface && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+		if depth != 0 && isSliceKind(elem.Kind()) {
+			var err error
+			if dst, err = flattenInto(dst, elem, depth-1, level+1); err != nil {
+				return dst, err
+			}
+			continue
+		}
+		if dst.Len() >= DefaultLimits.MaxElements {
+			return dst, limitError("Flatten", 1, dst.Len()+1)
+		}
+		dst = reflect.Append(dst, src.Index(i))
+	}
+	return dst, nil
+}
+
+func isSliceKind(kind reflect.Kind) bool { return kind == reflect.Slice || kind == reflect.Array }
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface:
+		return v.IsNil() || isEmptyValue(v.Elem())
+	case reflect.Ptr:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return v.Len() == 0
+	case reflect.Invalid:
+		return true
+	}
+	return v.IsZero()
+}
+
+// limitError reports that argument arg of fn would produce a result of n elements, more than
+// DefaultLimits.MaxElements.
+func limitError(fn string, arg, n int) error {
+	return argumentError(fn, arg, ErrLimitExceeded, "result of %d elements exceeds the limit of %d", n, DefaultLimits.MaxElements)
+}
@@ -0,0 +1,321 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func TestChunk(t *testing.T) {
+	var tests = []struct {
+		N        int
+		Operand  interface{}
+		Expected interface{}
+		Valid    bool
+	}{
+		{N: 2, Operand: []string{"a", "b", "c", "d", "e"}, Expected: [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, Valid: true},
+		{N: 3, Operand: []int{1, 2, 3}, Expected: [][]int{{1, 2, 3}}, Valid: true},
+		{N: 3, Operand: [4]int{1, 2, 3, 4}, Expected: [][]int{{1, 2, 3}, {4}}, Valid: true},
+		{N: 2, Operand: []string{}, Expected: [][]string{}, Valid: true},
+		{N: 0, Operand: []string{"a"}, Valid: false},
+		{N: 2, Operand: "abc", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Chunk(test.N, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Chunk encountered unexpected error: %s.  N: %d, Operand: %#v", err, test.N, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Chunk failed to return an error.  N: %d, Operand: %#v", test.N, test.Operand)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Chunk result incorrect.  N: %d, Operand: %#v, Expected: %#v, Received: %#v", test.N, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestChunkTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("test").Funcs(FuncMap).Parse(`{{ range Chunk 3 . }}{{ Join "|" . }};{{ end }}`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, []string{"a", "b", "c", "d"}); err != nil {
+		t.Fatalf("Template execution encountered unexpected error: %s", err)
+	}
+	if buf.String() != "a|b|c;d;" {
+		t.Errorf("Chunk template output incorrect.  Received: %s", buf.String())
+	}
+}
+
+func TestPartition(t *testing.T) {
+	var tests = []struct {
+		N        int
+		Operand  interface{}
+		Expected interface{}
+		Valid    bool
+	}{
+		{N: 3, Operand: []string{"a", "b", "c", "d", "e"}, Expected: [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, Valid: true},
+		{N: 2, Operand: []int{1, 2, 3, 4}, Expected: [][]int{{1, 2}, {3, 4}}, Valid: true},
+		{N: 3, Operand: []int{1}, Expected: [][]int{{1}, {}, {}}, Valid: true},
+		{N: 0, Operand: []int{1}, Valid: false},
+		{N: 1 << 30, Operand: []int{1}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Partition(test.N, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Partition encountered unexpected error: %s.  N: %d, Operand: %#v", err, test.N, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Partition failed to return an error.  N: %d, Operand: %#v", test.N, test.Operand)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Partition result incorrect.  N: %d, Operand: %#v, Expected: %#v, Received: %#v", test.N, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	var tests = []struct {
+		Size     int
+		Step     int
+		Operand  interface{}
+		Expected interface{}
+		Valid    bool
+	}{
+		{Size: 2, Step: 1, Operand: []string{"a", "b", "c"}, Expected: [][]string{{"a", "b"}, {"b", "c"}}, Valid: true},
+		{Size: 2, Step: 2, Operand: []int{1, 2, 3, 4, 5}, Expected: [][]int{{1, 2}, {3, 4}}, Valid: true},
+		{Size: 1, Step: 3, Operand: []int{1, 2, 3, 4}, Expected: [][]int{{1}, {4}}, Valid: true},
+		{Size: 4, Step: 1, Operand: []int{1, 2, 3}, Expected: [][]int{}, Valid: true},
+		{Size: 2, Step: 0, Operand: []int{1, 2, 3}, Valid: false},
+		{Size: 60000, Step: 1, Operand: make([]int, 70000), Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Window(test.Size, test.Step, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Window encountered unexpected error: %s.  Size: %d, Step: %d", err, test.Size, test.Step)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Window failed to return an error.  Size: %d, Step: %d", test.Size, test.Step)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Window result incorrect.  Size: %d, Step: %d, Operand: %#v, Expected: %#v, Received: %#v", test.Size, test.Step, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestZip(t *testing.T) {
+	var tests = []struct {
+		A        interface{}
+		Operand  interface{}
+		Expected interface{}
+		Valid    bool
+	}{
+		{A: []string{"a", "b", "c"}, Operand: []string{"x", "y"}, Expected: [][]string{{"a", "x"}, {"b", "y"}}, Valid: true},
+		{A: []string{"a", "b"}, Operand: []int{1, 2}, Expected: [][]interface{}{{"a", 1}, {"b", 2}}, Valid: true},
+		{A: []string{}, Operand: []string{"x"}, Expected: [][]string{}, Valid: true},
+		{A: 1, Operand: []string{"x"}, Valid: false},
+		{A: []string{"a"}, Operand: nil, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Zip(test.A, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Zip encountered unexpected error: %s.  A: %#v, Operand: %#v", err, test.A, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Zip failed to return an error.  A: %#v, Operand: %#v", test.A, test.Operand)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Zip result incorrect.  A: %#v, Operand: %#v, Expected: %#v, Received: %#v", test.A, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestUnzip(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		Expected interface{}
+		Valid    bool
+	}{
+		{Operand: [][]string{{"a", "x"}, {"b", "y"}}, Expected: [][]string{{"a", "b"}, {"x", "y"}}, Valid: true},
+		{Operand: [][]interface{}{{"a", 1}, {"b", 2}}, Expected: [][]interface{}{{"a", "b"}, {1, 2}}, Valid: true},
+		{Operand: []interface{}{[]string{"a", "x"}, []int{1, 2}}, Expected: [][]interface{}{{"a", 1}, {"x", 2}}, Valid: true},
+		{Operand: [][]string{}, Expected: []interface{}{}, Valid: true},
+		{Operand: [][]string{{"a", "x"}, {"b"}}, Valid: false},
+		{Operand: []string{"a"}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Unzip(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Unzip encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Unzip failed to return an error.  Operand: %#v", test.Operand)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Unzip result incorrect.  Operand: %#v, Expected: %#v, Received: %#v", test.Operand, test.Expected, result)
+		}
+	}
+
+	zipped, _ := Zip([]string{"a", "b"}, []string{"x", "y"})
+	unzipped, _ := Unzip(zipped)
+	if !reflect.DeepEqual(unzipped, [][]string{{"a", "b"}, {"x", "y"}}) {
+		t.Errorf("Unzip did not reverse Zip.  Received: %#v", unzipped)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	nested := []interface{}{"a", []interface{}{"b", []string{"c", "d"}}, []int{1}}
+	var tests = []struct {
+		Depth    int
+		Operand  interface{}
+		Expected interface{}
+		Valid    bool
+	}{
+		{Depth: 1, Operand: [][]string{{"a", "b"}, {"c"}}, Expected: []string{"a", "b", "c"}, Valid: true},
+		{Depth: -1, Operand: [][][]int{{{1}, {2, 3}}, {{4}}}, Expected: []int{1, 2, 3, 4}, Valid: true},
+		{Depth: 1, Operand: [][][]int{{{1}, {2, 3}}, {{4}}}, Expected: [][]int{{1}, {2, 3}, {4}}, Valid: true},
+		{Depth: 0, Operand: [][]string{{"a"}}, Expected: [][]string{{"a"}}, Valid: true},
+		{Depth: 1, Operand: nested, Expected: []interface{}{"a", "b", []string{"c", "d"}, 1}, Valid: true},
+		{Depth: -1, Operand: nested, Expected: []interface{}{"a", "b", "c", "d", 1}, Valid: true},
+		{Depth: 1, Operand: map[string]int{}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Flatten(test.Depth, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Flatten encountered unexpected error: %s.  Depth: %d, Operand: %#v", err, test.Depth, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Flatten failed to return an error.  Depth: %d, Operand: %#v", test.Depth, test.Operand)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Flatten result incorrect.  Depth: %d, Operand: %#v, Expected: %#v, Received: %#v", test.Depth, test.Operand, test.Expected, result)
+		}
+	}
+
+	cyclic := []interface{}{"a", nil}
+	cyclic[1] = cyclic
+	if _, err := Flatten(-1, cyclic); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Flatten failed to reject a self-containing operand.  Received: %v", err)
+	}
+	if result, err := Flatten(2, cyclic); err != nil || len(result.([]interface{})) != 4 {
+		t.Errorf("Flatten result incorrect for a self-containing operand.  Error: %v", err)
+	}
+
+	type recursive []recursive
+	if result, err := Flatten(-1, recursive{recursive{}, recursive{recursive{}}}); err != nil || reflect.ValueOf(result).Len() != 0 {
+		t.Errorf("Flatten result incorrect for a self-containing type.  Received: %#v, Error: %v", result, err)
+	}
+	selfContaining := recursive{nil}
+	selfContaining[0] = selfContaining
+	if _, err := Flatten(-1, selfContaining); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Flatten failed to reject a self-containing operand of a self-containing type.  Received: %v", err)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	var zero *int
+	var tests = []struct {
+		Operand  interface{}
+		Expected interface{}
+	}{
+		{Operand: []string{"a", "", "b", ""}, Expected: []string{"a", "b"}},
+		{Operand: []int{0, 1, 0, 2}, Expected: []int{1, 2}},
+		{Operand: []interface{}{nil, false, 0.0, "", []string{}, map[string]int{}, zero, "x", true, []int{0}}, Expected: []interface{}{"x", true, []int{0}}},
+	}
+
+	for _, test := range tests {
+		result, err := Compact(test.Operand)
+		if err != nil {
+			t.Errorf("Compact encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Compact result incorrect.  Operand: %#v, Expected: %#v, Received: %#v", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestRotate(t *testing.T) {
+	var tests = []struct {
+		N        int
+		Operand  interface{}
+		Expected interface{}
+	}{
+		{N: 1, Operand: []string{"a", "b", "c"}, Expected: []string{"b", "c", "a"}},
+		{N: -1, Operand: []string{"a", "b", "c"}, Expected: []string{"c", "a", "b"}},
+		{N: 7, Operand: []int{1, 2, 3}, Expected: []int{2, 3, 1}},
+		{N: 0, Operand: []int{1, 2, 3}, Expected: []int{1, 2, 3}},
+		{N: 2, Operand: []int{}, Expected: []int{}},
+	}
+
+	for _, test := range tests {
+		result, err := Rotate(test.N, test.Operand)
+		if err != nil {
+			t.Errorf("Rotate encountered unexpected error: %s.  N: %d, Operand: %#v", err, test.N, test.Operand)
+		}
+		if !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Rotate result incorrect.  N: %d, Operand: %#v, Expected: %#v, Received: %#v", test.N, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestInterleave(t *testing.T) {
+	var tests = []struct {
+		A        interface{}
+		Operand  interface{}
+		Expected interface{}
+	}{
+		{A: []string{"a", "b", "c"}, Operand: []string{"x"}, Expected: []string{"a", "x", "b", "c"}},
+		{A: []string{"a"}, Operand: []string{"x", "y"}, Expected: []string{"a", "x", "y"}},
+		{A: []string{"a"}, Operand: []int{1}, Expected: []interface{}{"a", 1}},
+	}
+
+	for _, test := range tests {
+		result, err := Interleave(test.A, test.Operand)
+		if err != nil {
+			t.Errorf("Interleave encountered unexpected error: %s.  A: %#v, Operand: %#v", err, test.A, test.Operand)
+		}
+		if !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Interleave result incorrect.  A: %#v, Operand: %#v, Expected: %#v, Received: %#v", test.A, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestEnumerate(t *testing.T) {
+	result, err := Enumerate([]string{"a", "b"})
+	expected := []Indexed{{Index: 0, Value: "a"}, {Index: 1, Value: "b"}}
+	if err != nil {
+		t.Errorf("Enumerate encountered unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Enumerate result incorrect.  Expected: %#v, Received: %#v", expected, result)
+	}
+	if _, err := Enumerate(42); err == nil {
+		t.Errorf("Enumerate failed to return an error.  Operand: 42")
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/*
+ * Type Conversion
+ */
+
+// ToString converts the scalar operand to a string.  Numbers are formatted in base 10 without
+// exponents and bools are formatted as "true" or "false".
+func ToString(operand interface{}) (string, error) {
+	s, err := toString(operand)
+	return s, wrapArgumentError("ToString", 0, err)
+}
+
+func toString(operand interface{}) (string, error) {
+	if b, ok := operand.([]byte); ok {
+		return string(b), nil
+	}
+	v := reflect.ValueOf(operand)
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	}
+	return "", conversionError(operand, "string")
+}
+
+// ToInt converts the scalar operand to an int.  See ToInt64 for conversion rules.
+func ToInt(operand interface{}) (int, error) {
+	i64, err := toInt64(operand)
+	if err == nil && int64(int(i64)) != i64 {
+		err = rangeErrorf("value %d overflows int", i64)
+	}
+	return int(i64), wrapArgumentError("ToInt", 0, err)
+}
+
+// ToInt64 converts the scalar operand to an int64.  Floats are truncated toward zero.  Strings
+// are parsed with strconv.ParseInt using base == 0 (auto), falling back to strconv.ParseFloat.
+// Bools convert to 1 or 0.  Values that don't fit in an int64 return an error.
+func ToInt64(operand interface{}) (int64, error) {
+	i64, err := toInt64(operand)
+	return i64, wrapArgumentError("ToInt64", 0, err)
+}
+
+func toInt64(operand interface{}) (int64, error) {
+	v := reflect.ValueOf(operand)
+	switch v.Kind() {
+	case reflect.String:
+		i64, err := strconv.ParseInt(v.String(), 0, 64)
+		if err == nil {
+			return i64, nil
+		}
+		f, ferr := strconv.ParseFloat(v.String(), 64)
+		if ferr != nil {
+			return 0, err
+		}
+		return floatToInt64(f)
+	case reflect.Bool:
+		if v.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return 0, rangeErrorf("value %d overflows int64", v.Uint())
+		}
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return floatToInt64(v.Float())
+	}
+	return 0, conversionError(operand, "int64")
+}
+
+// ToFloat converts the scalar operand to a float64.  Strings are parsed with strconv.ParseFloat.
+// Bools convert to 1 or 0.
+func ToFloat(operand interface{}) (float64, error) {
+	f, err := toFloat(operand)
+	return f, wrapArgumentError("ToFloat", 0, err)
+}
+
+func toFloat(operand interface{}) (float64, error) {
+	v := reflect.ValueOf(operand)
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.ParseFloat(v.String(), 64)
+	case reflect.Bool:
+		if v.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	}
+	return 0, conversionError(operand, "float64")
+}
+
+// ToBool converts the scalar operand to a bool.  Numbers are true if non-zero.  Strings are
+// parsed with strconv.ParseBool.
+func ToBool(operand interface{}) (bool, error) {
+	b, err := toBool(operand)
+	return b, wrapArgumentError("ToBool", 0, err)
+}
+
+func toBool(operand interface{}) (bool, error) {
+	v := reflect.ValueOf(operand)
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.ParseBool(v.String())
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() != 0, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() != 0, nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float() != 0, nil
+	}
+	return false, conversionError(operand, "bool")
+}
+
+// FormatInt uses strconv.FormatInt to format operand in the given base.  Operand is converted
+// with ToInt64.
+func FormatInt(base int, operand interface{}) (string, error) {
+	if base < 2 || base > 36 {
+		return "", argumentError("FormatInt", 0, ErrOutOfRange, "base %d is not between 2 and 36", base)
+	}
+	i64, err := toInt64(operand)
+	if err != nil {
+		return "", wrapArgumentError("FormatInt", 1, err)
+	}
+	return strconv.FormatInt(i64, base), nil
+}
+
+// FormatFloat uses strconv.FormatFloat to format operand according to format and precision.
+// Format is one of the strconv.FormatFloat format characters: "b", "e", "E", "f", "g", "G",
+// "x", or "X".  A precision of -1 uses the smallest number of digits necessary to represent the
+// value exactly, and precision may not exceed 100.  Operand is converted with ToFloat.
+func FormatFloat(format string, precision int, operand interface{}) (string, error) {
+	if len(format) != 1 || !strings.ContainsAny(format, "beEfgGxX") {
+		return "", argumentError("FormatFloat", 0, ErrInvalidArgument, "float format %q is not one of b, e, E, f, g, G, x, or X", format)
+	}
+	if precision < -1 || precision > maxDecimals {
+		return "", argumentError("FormatFloat", 1, ErrOutOfRange, "precision %d is not between -1 and %d", precision, maxDecimals)
+	}
+	f, err := toFloat(operand)
+	if err != nil {
+		return "", wrapArgumentError("FormatFloat", 2, err)
+	}
+	return strconv.FormatFloat(f, format[0], precision, 64), nil
+}
+
+func floatToInt64(f float64) (int64, error) {
+	if math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+		return 0, rangeErrorf("value %v overflows int64", f)
+	}
+	return int64(f), nil
+}
+
+func conversionError(operand interface{}, typeName string) error {
+	return fmt.Errorf("cannot convert %s to %s", TypeOf(operand), typeName)
+}
+
+/*
+ * Type Inspection
+ */
+
+// TypeOf returns the Go type of operand, such as "int" or "map[string]interface {}".  A nil
+// operand returns "nil".
+func TypeOf(operand interface{}) string {
+	if operand == nil {
+		return "nil"
+	}
+	return reflect.TypeOf(operand).String()
+}
+
+// KindOf returns the reflect.Kind of operand, such as "int", "map", or "slice".  A nil operand
+// returns "nil".
+func KindOf(operand interface{}) string {
+	if operand == nil {
+		return "nil"
+	}
+	return reflect.TypeOf(operand).Kind().String()
+}
+
+// IsNumber checks if operand is an int, uint, or float of any size.
+func IsNumber(operand interface{}) bool {
+	switch reflect.ValueOf(operand).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// IsString checks if operand is a string.
+func IsString(operand interface{}) bool { return reflect.ValueOf(operand).Kind() == reflect.String }
+
+// IsMap checks if operand is a map.
+func IsMap(operand interface{}) bool { return reflect.ValueOf(operand).Kind() == reflect.Map }
+
+// IsSlice checks if operand is a slice or array.
+func IsSlice(operand interface{}) bool {
+	kind := reflect.ValueOf(operand).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestToString(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		Expected string
+		Valid    bool
+	}{
+		{Operand: "dog", Expected: "dog", Valid: true},
+		{Operand: []byte("dog"), Expected: "dog", Valid: true},
+		{Operand: 42, Expected: "42", Valid: true},
+		{Operand: int64(-42), Expected: "-42", Valid: true},
+		{Operand: uint8(255), Expected: "255", Valid: true},
+		{Operand: 2.5, Expected: "2.5", Valid: true},
+		{Operand: float32(0.1), Expected: "0.1", Valid: true},
+		{Operand: 1e21, Expected: "1000000000000000000000", Valid: true},
+		{Operand: true, Expected: "true", Valid: true},
+		{Operand: time.Second, Expected: "1000000000", Valid: true},
+		{Operand: nil, Valid: false},
+		{Operand: []string{"dog"}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := ToString(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("ToString encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("ToString failed to return an error.  Operand: %#v, Received: %#v", test.Operand, result)
+		}
+		if result != test.Expected {
+			t.Errorf("ToString result incorrect.  Operand: %#v, Expected: %#v, Received: %#v", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestToInt64(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		Expected int64
+		Valid    bool
+	}{
+		{Operand: 42, Expected: 42, Valid: true},
+		{Operand: int8(-42), Expected: -42, Valid: true},
+		{Operand: uint64(42), Expected: 42, Valid: true},
+		{Operand: uint64(math.MaxUint64), Valid: false},
+		{Operand: 2.9, Expected: 2, Valid: true},
+		{Operand: -2.9, Expected: -2, Valid: true},
+		{Operand: 1e19, Valid: false},
+		{Operand: math.NaN(), Valid: false},
+		{Operand: "42", Expected: 42, Valid: true},
+		{Operand: "0x2a", Expected: 42, Valid: true},
+		{Operand: "4294967296", Expected: 4294967296, Valid: true},
+		{Operand: "3.0", Expected: 3, Valid: true},
+		{Operand: "dog", Valid: false},
+		{Operand: true, Expected: 1, Valid: true},
+		{Operand: false, Expected: 0, Valid: true},
+		{Operand: nil, Valid: false},
+		{Operand: map[string]int{}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := ToInt64(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("ToInt64 encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("ToInt64 failed to return an error.  Operand: %#v, Received: %d", test.Operand, result)
+		}
+		if result != test.Expected {
+			t.Errorf("ToInt64 result incorrect.  Operand: %#v, Expected: %d, Received: %d", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestToInt(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		Expected int
+		Valid    bool
+	}{
+		{Operand: "42", Expected: 42, Valid: true},
+		{Operand: 42.5, Expected: 42, Valid: true},
+		{Operand: "dog", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := ToInt(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("ToInt encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("ToInt failed to return an error.  Operand: %#v, Received: %d", test.Operand, result)
+		}
+		if result != test.Expected {
+			t.Errorf("ToInt result incorrect.  Operand: %#v, Expected: %d, Received: %d", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestToFloat(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		Expected float64
+		Valid    bool
+	}{
+		{Operand: 42, Expected: 42, Valid: true},
+		{Operand: uint(42), Expected: 42, Valid: true},
+		{Operand: float32(0.5), Expected: 0.5, Valid: true},
+		{Operand: "2.25", Expected: 2.25, Valid: true},
+		{Operand: true, Expected: 1, Valid: true},
+		{Operand: "dog", Valid: false},
+		{Operand: []int{1}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := ToFloat(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("ToFloat encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("ToFloat failed to return an error.  Operand: %#v, Received: %f", test.Operand, result)
+		}
+		if result != test.Expected {
+			t.Errorf("ToFloat result incorrect.  Operand: %#v, Expected: %f, Received: %f", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestToBool(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		Expected bool
+		Valid    bool
+	}{
+		{Operand: true, Expected: true, Valid: true},
+		{Operand: "true", Expected: true, Valid: true},
+		{Operand: "0", Expected: false, Valid: true},
+		{Operand: 3, Expected: true, Valid: true},
+		{Operand: uint(0), Expected: false, Valid: true},
+		{Operand: 0.0, Expected: false, Valid: true},
+		{Operand: "dog", Valid: false},
+		{Operand: nil, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := ToBool(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("ToBool encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("ToBool failed to return an error.  Operand: %#v, Received: %t", test.Operand, result)
+		}
+		if result != test.Expected {
+			t.Errorf("ToBool result incorrect.  Operand: %#v, Expected: %t, Received: %t", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestFormatInt(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		Base     int
+		Expected string
+		Valid    bool
+	}{
+		{Operand: 255, Base: 16, Expected: "ff", Valid: true},
+		{Operand: "10", Base: 2, Expected: "1010", Valid: true},
+		{Operand: -8, Base: 8, Expected: "-10", Valid: true},
+		{Operand: 8, Base: 1, Valid: false},
+		{Operand: 8, Base: 37, Valid: false},
+		{Operand: "dog", Base: 10, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := FormatInt(test.Base, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("FormatInt encountered unexpected error: %s.  Operand: %#v, Base: %d", err, test.Operand, test.Base)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("FormatInt failed to return an error.  Operand: %#v, Base: %d, Received: %s", test.Operand, test.Base, result)
+		}
+		if result != test.Expected {
+			t.Errorf("FormatInt result incorrect.  Operand: %#v, Base: %d, Expected: %s, Received: %s", test.Operand, test.Base, test.Expected, result)
+		}
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	var tests = []struct {
+		Operand   interface{}
+		Format    string
+		Precision int
+		Expected  string
+		Valid     bool
+	}{
+		{Operand: 3.14159, Format: "f", Precision: 2, Expected: "3.14", Valid: true},
+		{Operand: 1500, Format: "e", Precision: 1, Expected: "1.5e+03", Valid: true},
+		{Operand: "0.5", Format: "g", Precision: -1, Expected: "0.5", Valid: true},
+		{Operand: 1.0, Format: "q", Precision: 2, Valid: false},
+		{Operand: 1.0, Format: "ff", Precision: 2, Valid: false},
+		{Operand: "dog", Format: "f", Precision: 2, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := FormatFloat(test.Format, test.Precision, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("FormatFloat encountered unexpected error: %s.  Operand: %#v, Format: %s, Precision: %d", err, test.Operand, test.Format, test.Precision)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("FormatFloat failed to return an error.  Operand: %#v, Format: %s, Precision: %d, Received: %s", test.Operand, test.Format, test.Precision, result)
+		}
+		if result != test.Expected {
+			t.Errorf("FormatFloat result incorrect.  Operand: %#v, Format: %s, Precision: %d, Expected: %s, Received: %s", test.Operand, test.Format, test.Precision, test.Expected, result)
+		}
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	var tests = []struct {
+		Operand interface{}
+		Type    string
+		Kind    string
+	}{
+		{Operand: 42, Type: "int", Kind: "int"},
+		{Operand: "dog", Type: "string", Kind: "string"},
+		{Operand: map[string]interface{}{}, Type: "map[string]interface {}", Kind: "map"},
+		{Operand: []string{}, Type: "[]string", Kind: "slice"},
+		{Operand: time.Second, Type: "time.Duration", Kind: "int64"},
+		{Operand: nil, Type: "nil", Kind: "nil"},
+	}
+
+	for _, test := range tests {
+		result := TypeOf(test.Operand)
+		if result != test.Type {
+			t.Errorf("TypeOf result incorrect.  Operand: %#v, Expected: %s, Received: %s", test.Operand, test.Type, result)
+		}
+		result = KindOf(test.Operand)
+		if result != test.Kind {
+			t.Errorf("KindOf result incorrect.  Operand: %#v, Expected: %s, Received: %s", test.Operand, test.Kind, result)
+		}
+	}
+}
+
+func TestTypePredicates(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		IsNumber bool
+		IsString bool
+		IsMap    bool
+		IsSlice  bool
+	}{
+		{Operand: 42, IsNumber: true},
+		{Operand: uint16(42), IsNumber: true},
+		{Operand: 4.2, IsNumber: true},
+		{Operand: "42", IsString: true},
+		{Operand: map[string]int{}, IsMap: true},
+		{Operand: []interface{}{}, IsSlice: true},
+		{Operand: [2]int{}, IsSlice: true},
+		{Operand: true},
+		{Operand: nil},
+	}
+
+	for _, test := range tests {
+		if result := IsNumber(test.Operand); result != test.IsNumber {
+			t.Errorf("IsNumber result incorrect.  Operand: %#v, Expected: %t, Received: %t", test.Operand, test.IsNumber, result)
+		}
+		if result := IsString(test.Operand); result != test.IsString {
+			t.Errorf("IsString result incorrect.  Operand: %#v, Expected: %t, Received: %t", test.Operand, test.IsString, result)
+		}
+		if result := IsMap(test.Operand); result != test.IsMap {
+			t.Errorf("IsMap result incorrect.  Operand: %#v, Expected: %t, Received: %t", test.Operand, test.IsMap, result)
+		}
+		if result := IsSlice(test.Operand); result != test.IsSlice {
+			t.Errorf("IsSlice result incorrect.  Operand: %#v, Expected: %t, Received: %t", test.Operand, test.IsSlice, result)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"reflect"
+	"sort"
+)
+
+// KV is a single map entry.  It is returned by Entries and accepted by Dict.
+type KV struct {
+	Key   string
+	Value interface{}
+}
+
+/*
+ * Data Construction
+ *
+ * text/template has no literal syntax for maps or slices.  These functions build them inside a
+ * template, for example to pass several values to another template:
+ * {{ template "row" (Dict "name" .Name "tags" (List "a" "b")) }}.  The results are plain
+ * map[string]interface{} and slice values, so they may be passed to the map and collection
+ * functions.
+ */
+
+// Dict returns a map built from its arguments.  Each argument is either a KV, or a string key
+// followed by its value: {{ Dict "name" "web" "replicas" 3 }}.  Later entries replace earlier
+// entries with the same key.
+func Dict(pairs ...interface{}) (map[string]interface{}, error) {
+	dict := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i++ {
+		if kv, ok := pairs[i].(KV); ok {
+			dict[kv.Key] = kv.Value
+			continue
+		}
+		key := reflect.ValueOf(pairs[i])
+		if key.Kind() != reflect.String {
+			return nil, argumentError("Dict", i, ErrInvalidArgument, "%s is not a string key or a KV", TypeOf(pairs[i]))
+		}
+		if i+1 == len(pairs) {
+			return nil, argumentError("Dict", i, ErrInvalidArgument, "key %q is missing a value", key.String())
+		}
+		dict[key.String()] = pairs[i+1]
+		i++
+	}
+	return dict, nil
+}
+
+// Entries returns the entries of the operand map, sorted by key.  The keys of operand must be
+// strings.  It is the inverse of Dict: {{ range Entries .Labels }}{{ .Key }}={{ .Value }}{{ end }}.
+func Entries(operand interface{}) ([]KV, error) {
+	v := reflect.ValueOf(operand)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, argumentError("Entries", 0, ErrInvalidArgument, "cannot list the entries of %s: expected a map with string keys", TypeOf(operand))
+	}
+	entries := make([]KV, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		entries = append(entries, KV{Key: iter.Key().String(), Value: iter.Value().Interface()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// List returns its arguments as a slice: {{ List "a" "b" "c" | Join "," }}.
+func List(items ...interface{}) []interface{} {
+	list := make([]interface{}, len(items))
+	copy(list, items)
+	return list
+}
+
+// Append returns a copy of operand with value added at the end.  The result has the type of
+// operand if value can be stored in it, and is a []interface{} otherwise.  A nil operand is
+// treated as an empty slice.
+func Append(value, operand interface{}) (interface{}, error) {
+	return insertValue("Append", value, operand, false)
+}
+
+// Prepend returns a copy of operand with value added at the beginning.  The result has the
+// type of operand if value can be stored in it, and is a []interface{} otherwise.  A nil
+// operand is treated as an empty slice.
+func Prepend(value, operand interface{}) (interface{}, error) {
+	return insertValue("Prepend", value, operand, true)
+}
+
+// Concat returns the elements of each slice in order as a single slice: {{ Concat .A .B .C }}.
+// The result has the type of the slices if they share one, and is a []interface{} otherwise.
+// Nil arguments are ignored.
+func Concat(slices ...interface{}) (interface{}, error) {
+	var values []reflect.Value
+	total := 0
+	for i, s := range slices {
+		if s == nil {
+			continue
+		}
+		v, err := sliceValue(s)
+		if err != nil {
+			return nil, wrapArgumentError("Concat", i, err)
+		}
+		values = append(values, v)
+		if total += v.Len(); total > DefaultLimits.MaxElements {
+			return nil, limitError("Concat", i, total)
+		}
+	}
+	if len(values) == 0 {
+		return []interface{}{}, nil
+	}
+	result := reflect.MakeSlice(commonSliceType(values...), 0, total)
+	for _, v := range values {
+		for i := 0; i < v.Len(); i++ {
+			result = reflect.Append(result, v.Index(i))
+		}
+	}
+	return result.Interface(), nil
+}
+
+func insertValue(fn string, value, operand interface{}, front bool) (interface{}, error) {
+	v := reflect.ValueOf([]interface{}{})
+	if operand != nil {
+		var err error
+		if v, err = sliceValue(operand); err != nil {
+			return nil, wrapArgumentError(fn, 1, err)
+		}
+	}
+	if v.Len()+1 > DefaultLimits.MaxElements {
+		return nil, limitError(fn, 1, v.Len()+1)
+	}
+	t := v.Type()
+	elem := reflect.ValueOf(value)
+	if !elem.IsValid() || !elem.Type().AssignableTo(t.Elem()) {
+		t = reflect.SliceOf(interfaceType)
+		elem = reflect.ValueOf(&value).Elem()
+	}
+	result := reflect.MakeSlice(t, 0, v.Len()+1)
+	if front {
+		result = reflect.Append(result, elem)
+	}
+	for i := 0; i < v.Len(); i++ {
+		result = reflect.Append(result, v.Index(i))
+	}
+	if !front {
+		result = reflect.Append(result, elem)
+	}
+	return result.Interface(), nil
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func TestDict(t *testing.T) {
+	var tests = []struct {
+		Pairs    []interface{}
+		Expected map[string]interface{}
+		Valid    bool
+	}{
+		{Pairs: []interface{}{}, Expected: map[string]interface{}{}, Valid: true},
+		{Pairs: []interface{}{"a", 1, "b", "two"}, Expected: map[string]interface{}{"a": 1, "b": "two"}, Valid: true},
+		{Pairs: []interface{}{"a", 1, "a", nil}, Expected: map[string]interface{}{"a": nil}, Valid: true},
+		{Pairs: []interface{}{KV{Key: "a", Value: 1}, "b", 2, KV{Key: "c"}}, Expected: map[string]interface{}{"a": 1, "b": 2, "c": nil}, Valid: true},
+		{Pairs: []interface{}{"a"}, Valid: false},
+		{Pairs: []interface{}{1, "a"}, Valid: false},
+		{Pairs: []interface{}{"a", 1, nil, 2}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Dict(test.Pairs...)
+		if test.Valid && err != nil {
+			t.Errorf("Dict encountered unexpected error: %s.  Pairs: %#v", err, test.Pairs)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Dict failed to return an error.  Pairs: %#v", test.Pairs)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Dict result incorrect.  Pairs: %#v, Expected: %#v, Received: %#v", test.Pairs, test.Expected, result)
+		}
+	}
+}
+
+func TestEntries(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		Expected []KV
+		Valid    bool
+	}{
+		{Operand: map[string]int{"b": 2, "a": 1}, Expected: []KV{{Key: "a", Value: 1}, {Key: "b", Value: 2}}, Valid: true},
+		{Operand: map[string]interface{}{}, Expected: []KV{}, Valid: true},
+		{Operand: map[int]int{1: 1}, Valid: false},
+		{Operand: []string{"a"}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Entries(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Entries encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Entries failed to return an error.  Operand: %#v", test.Operand)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Entries result incorrect.  Operand: %#v, Expected: %#v, Received: %#v", test.Operand, test.Expected, result)
+		}
+	}
+
+	entries, _ := Entries(map[string]string{"x": "1", "y": "2"})
+	args := make([]interface{}, len(entries))
+	for i, kv := range entries {
+		args[i] = kv
+	}
+	if result, err := Dict(args...); err != nil || !reflect.DeepEqual(result, map[string]interface{}{"x": "1", "y": "2"}) {
+		t.Errorf("Dict of Entries result incorrect.  Received: %#v, Error: %v", result, err)
+	}
+}
+
+func TestList(t *testing.T) {
+	items := []interface{}{"a", 1, nil}
+	result := List(items...)
+	if !reflect.DeepEqual(result, items) {
+		t.Errorf("List result incorrect.  Expected: %#v, Received: %#v", items, result)
+	}
+	result[0] = "b"
+	if items[0] != "a" {
+		t.Errorf("List result shares storage with its arguments")
+	}
+	if result := List(); result == nil || len(result) != 0 {
+		t.Errorf("List result incorrect for no arguments.  Received: %#v", result)
+	}
+}
+
+func TestAppendPrepend(t *testing.T) {
+	var tests = []struct {
+		Value    interface{}
+		Operand  interface{}
+		Appended interface{}
+		Prepend  interface{}
+		Valid    bool
+	}{
+		{Value: "c", Operand: []string{"a", "b"}, Appended: []string{"a", "b", "c"}, Prepend: []string{"c", "a", "b"}, Valid: true},
+		{Value: 1, Operand: []string{"a"}, Appended: []interface{}{"a", 1}, Prepend: []interface{}{1, "a"}, Valid: true},
+		{Value: nil, Operand: []interface{}{"a"}, Appended: []interface{}{"a", nil}, Prepend: []interface{}{nil, "a"}, Valid: true},
+		{Value: 3, Operand: [2]int{1, 2}, Appended: []int{1, 2, 3}, Prepend: []int{3, 1, 2}, Valid: true},
+		{Value: "a", Operand: nil, Appended: []interface{}{"a"}, Prepend: []interface{}{"a"}, Valid: true},
+		{Value: "a", Operand: "b", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Append(test.Value, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Append encountered unexpected error: %s.  Value: %#v, Operand: %#v", err, test.Value, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Append failed to return an error.  Value: %#v, Operand: %#v", test.Value, test.Operand)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Appended) {
+			t.Errorf("Append result incorrect.  Value: %#v, Operand: %#v, Expected: %#v, Received: %#v", test.Value, test.Operand, test.Appended, result)
+		}
+
+		result, err = Prepend(test.Value, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Prepend encountered unexpected error: %s.  Value: %#v, Operand: %#v", err, test.Value, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Prepend failed to return an error.  Value: %#v, Operand: %#v", test.Value, test.Operand)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Prepend) {
+			t.Errorf("Prepend result incorrect.  Value: %#v, Operand: %#v, Expected: %#v, Received: %#v", test.Value, test.Operand, test.Prepend, result)
+		}
+	}
+
+	operand := make([]string, 1, 4)
+	appended, _ := Append("x", operand)
+	operand = append(operand, "y")
+	if appended.([]string)[1] != "x" {
+		t.Errorf("Append result shares storage with operand.  Received: %#v", appended)
+	}
+}
+
+func TestConcat(t *testing.T) {
+	var tests = []struct {
+		Slices   []interface{}
+		Expected interface{}
+		Valid    bool
+	}{
+		{Slices: []interface{}{}, Expected: []interface{}{}, Valid: true},
+		{Slices: []interface{}{[]string{"a"}, []string{"b", "c"}}, Expected: []string{"a", "b", "c"}, Valid: true},
+		{Slices: []interface{}{[]string{"a"}, nil, []int{1}}, Expected: []interface{}{"a", 1}, Valid: true},
+		{Slices: []interface{}{[]int{1}, [1]int{2}}, Expected: []int{1, 2}, Valid: true},
+		{Slices: []interface{}{[]string{"a"}, "b"}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Concat(test.Slices...)
+		if test.Valid && err != nil {
+			t.Errorf("Concat encountered unexpected error: %s.  Slices: %#v", err, test.Slices)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Concat failed to return an error.  Slices: %#v", test.Slices)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Concat result incorrect.  Slices: %#v, Expected: %#v, Received: %#v", test.Slices, test.Expected, result)
+		}
+	}
+
+	limits := DefaultLimits
+	defer func() { DefaultLimits = limits }()
+	DefaultLimits.MaxElements = 2
+	if _, err := Concat([]int{1, 2}, []int{3}); err == nil {
+		t.Errorf("Concat failed to return an error for a result exceeding MaxElements")
+	}
+}
+
+func TestDictTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("test").Funcs(FuncMap).Parse(
+		`{{ define "row" }}{{ .name }}:{{ Join "," .tags }}{{ end }}` +
+			`{{ template "row" (Dict "name" "web" "tags" (Split "," "a,b" | Append "c")) }};` +
+			`{{ range Entries (DeepMerge "replace" (Dict "x" 1) (Dict "y" 2)) }}{{ .Key }}={{ .Value }} {{ end }}`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		t.Fatalf("Template execution encountered unexpected error: %s", err)
+	}
+	if buf.String() != "web:a,b,c;x=1 y=2 " {
+		t.Errorf("Dict template output incorrect.  Received: %s", buf.String())
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// These errors classify the failures of haven functions caused by their arguments.  The
+// *ArgumentError values returned for such failures wrap them, so callers may test for them with
+// errors.Is on the error returned by template execution.
+var (
+	// ErrInvalidArgument indicates an argument that is not valid for the function, such as a
+	// zero divisor or a negative count.
+	ErrInvalidArgument = errors.New("invalid argument")
+
+	// ErrOutOfRange indicates an index or bound outside of the operand.
+	ErrOutOfRange = errors.New("out of range")
+
+	// ErrLimitExceeded indicates that a function would exceed one of the DefaultLimits, or
+	// that rendering would exceed the output limit of a Renderer.
+	ErrLimitExceeded = errors.New("limit exceeded")
+)
+
+// ArgumentError is returned by haven functions when an argument is invalid.  It wraps one of
+// ErrInvalidArgument, ErrOutOfRange, or ErrLimitExceeded.  Failures that no argument caused are
+// not ArgumentErrors: the *AssertionError returned by Fail and the Assert functions, the context
+// error returned once a bound context is done, and the error from a failed read of the random
+// source are returned as they are.
+type ArgumentError struct {
+	// Func is the name of the function, such as "Seq".
+	Func string
+
+	// Arg is the zero-based index of the offending argument in the function's parameter list,
+	// which is also its position in a template call.
+	Arg int
+
+	// Err is the class of the error.
+	Err error
+
+	// Reason describes the problem with the argument.
+	Reason string
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("%s: argument %d: %s", e.Func, e.Arg, e.Reason)
+}
+
+func (e *ArgumentError) Unwrap() error { return e.Err }
+
+func argumentError(fn string, arg int, class error, format string, args ...interface{}) error {
+	return &ArgumentError{Func: fn, Arg: arg, Err: class, Reason: fmt.Sprintf(format, args...)}
+}
+
+// wrapArgumentError reports err, a failure caused by argument arg of fn, as an *ArgumentError.
+// An *ArgumentError from a function that fn calls keeps its class and reason but names fn
+// instead.  Other errors are ErrOutOfRange or ErrLimitExceeded if they wrap one, ErrOutOfRange
+// if they are strconv range errors, and ErrInvalidArgument otherwise.  Errors from a done
+// context are not caused by an argument and are returned unchanged, as is a nil err.
+func wrapArgumentError(fn string, arg int, err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if argErr, ok := err.(*ArgumentError); ok {
+		return &ArgumentError{Func: fn, Arg: arg, Err: argErr.Err, Reason: argErr.Reason}
+	}
+	class := ErrInvalidArgument
+	for _, c := range []error{ErrOutOfRange, ErrLimitExceeded} {
+		if errors.Is(err, c) {
+			class = c
+		}
+	}
+	if isRangeError(err) {
+		class = ErrOutOfRange
+	}
+	return &ArgumentError{Func: fn, Arg: arg, Err: class, Reason: err.Error()}
+}
+
+// classError is an error of one of the classes above that is not tied to an argument on its
+// own, such as an expression running out of steps or a value overflowing a conversion.
+// Exported functions report them through wrapArgumentError, except for a Renderer exceeding
+// its output limit.
+type classError struct {
+	class error
+	msg   string
+}
+
+func (e *classError) Error() string { return e.msg }
+
+func (e *classError) Unwrap() error { return e.class }
+
+func limitErrorf(format string, args ...interface{}) error {
+	return &classError{class: ErrLimitExceeded, msg: fmt.Sprintf(format, args...)}
+}
+
+func rangeErrorf(format string, args ...interface{}) error {
+	return &classError{class: ErrOutOfRange, msg: fmt.Sprintf(format, args...)}
+}
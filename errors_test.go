@@ -0,0 +1,134 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func TestArgumentError(t *testing.T) {
+	err := argumentError("Slice", 1, ErrOutOfRange, "index %d is out of range", 5)
+	if err.Error() != "Slice: argument 1: index 5 is out of range" {
+		t.Errorf("ArgumentError message incorrect.  Received: %s", err)
+	}
+	if !errors.Is(err, ErrOutOfRange) || errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("ArgumentError failed to unwrap to its class.  Received: %#v", err)
+	}
+}
+
+func TestErrorClasses(t *testing.T) {
+	limits := DefaultLimits
+	defer func() { DefaultLimits = limits }()
+	DefaultLimits.MaxElements = 4
+
+	var tests = []struct {
+		Template string
+		Func     string
+		Arg      int
+		Class    error
+	}{
+		{Template: `{{ Divide 0 10 }}`, Func: "Divide", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ Modulo 0 10 }}`, Func: "Modulo", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ Seq 1 10 0 }}`, Func: "Seq", Arg: 2, Class: ErrInvalidArgument},
+		{Template: `{{ Split "," "a,b" | Head -1 }}`, Func: "Head", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ Split "," "a,b" | Tail -1 }}`, Func: "Tail", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ Repeat -1 "a" }}`, Func: "Repeat", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ Split "," "a,b" | Chunk 0 }}`, Func: "Chunk", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ Split "," "a,b" | Partition 0 }}`, Func: "Partition", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ Split "," "a,b" | Window 2 0 }}`, Func: "Window", Arg: 1, Class: ErrInvalidArgument},
+		{Template: `{{ HashBucket 0 "a" }}`, Func: "HashBucket", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ RandomInt 5 5 }}`, Func: "RandomInt", Arg: 1, Class: ErrInvalidArgument},
+		{Template: `{{ RandomHex -1 }}`, Func: "RandomHex", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ RandomPassword "lower,bogus" 2 }}`, Func: "RandomPassword", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ FormatFloat "q" 2 1.5 }}`, Func: "FormatFloat", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ SemverBump "build" "1.2.3" }}`, Func: "SemverBump", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ Split "," "a,b" | Slice 1 3 }}`, Func: "Slice", Arg: 1, Class: ErrOutOfRange},
+		{Template: `{{ Split "," "a,b" | Sample 3 }}`, Func: "Sample", Arg: 0, Class: ErrOutOfRange},
+		{Template: `{{ FormatInt 37 10 }}`, Func: "FormatInt", Arg: 0, Class: ErrOutOfRange},
+		{Template: `{{ FormatNumber -1 10 }}`, Func: "FormatNumber", Arg: 0, Class: ErrOutOfRange},
+		{Template: `{{ Percent -1 0.5 }}`, Func: "Percent", Arg: 0, Class: ErrOutOfRange},
+		{Template: `{{ Percent 101 0.5 }}`, Func: "Percent", Arg: 0, Class: ErrOutOfRange},
+		{Template: `{{ FormatNumber 1000000000 10 }}`, Func: "FormatNumber", Arg: 0, Class: ErrOutOfRange},
+		{Template: `{{ FormatFloat "f" 101 1.5 }}`, Func: "FormatFloat", Arg: 1, Class: ErrOutOfRange},
+		{Template: `{{ FormatFloat "f" -2 1.5 }}`, Func: "FormatFloat", Arg: 1, Class: ErrOutOfRange},
+		{Template: `{{ CIDRSubnet 40 0 "10.0.0.0/8" }}`, Func: "CIDRSubnet", Arg: 0, Class: ErrOutOfRange},
+		{Template: `{{ CIDRSubnet 8 256 "10.0.0.0/8" }}`, Func: "CIDRSubnet", Arg: 1, Class: ErrOutOfRange},
+		{Template: `{{ ParseIntBase 1 64 "10" }}`, Func: "ParseIntBase", Arg: 0, Class: ErrOutOfRange},
+		{Template: `{{ ParseUint 10 65 "10" }}`, Func: "ParseUint", Arg: 1, Class: ErrOutOfRange},
+		{Template: `{{ ParseIntBase 10 8 "128" }}`, Func: "ParseIntBase", Arg: 2, Class: ErrOutOfRange},
+		{Template: `{{ Seq 1 10 }}`, Func: "Seq", Arg: 1, Class: ErrLimitExceeded},
+		{Template: `{{ Repeat 5 "a" }}`, Func: "Repeat", Arg: 0, Class: ErrLimitExceeded},
+		{Template: `{{ Split "," "a,b,c" | Concat (Split "," "d,e") }}`, Func: "Concat", Arg: 1, Class: ErrLimitExceeded},
+		{Template: `{{ Split "," "a,b,c,d" | Append "e" }}`, Func: "Append", Arg: 1, Class: ErrLimitExceeded},
+		{Template: `{{ Split "," "a,b,c,d" | Window 3 1 }}`, Func: "Window", Arg: 2, Class: ErrLimitExceeded},
+		{Template: `{{ List (Split "," "a,b,c") (Split "," "d,e") | Flatten -1 }}`, Func: "Flatten", Arg: 1, Class: ErrLimitExceeded},
+		{Template: `{{ CIDRHosts "10.0.0.0/24" }}`, Func: "CIDRHosts", Arg: 0, Class: ErrLimitExceeded},
+		{Template: `{{ RandomHex 5 }}`, Func: "RandomHex", Arg: 0, Class: ErrLimitExceeded},
+		{Template: `{{ Dict "a" }}`, Func: "Dict", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ Dict "a" 1 2 3 }}`, Func: "Dict", Arg: 2, Class: ErrInvalidArgument},
+		{Template: `{{ ToInt "x" }}`, Func: "ToInt", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ ToInt64 1e30 }}`, Func: "ToInt64", Arg: 0, Class: ErrOutOfRange},
+		{Template: `{{ ParseInt64 "99999999999999999999" }}`, Func: "ParseInt64", Arg: 0, Class: ErrOutOfRange},
+		{Template: `{{ ParseInt64 "x" }}`, Func: "ParseInt64", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ ParseBytes "1 XB" }}`, Func: "ParseBytes", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ ParseIP "x" }}`, Func: "ParseIP", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ SemverParse "x" }}`, Func: "SemverParse", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ SQLQuoteLiteral "oracle" "x" }}`, Func: "SQLQuoteLiteral", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ WeightedChoice (Dict "a" -1) }}`, Func: "WeightedChoice", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ DeepMerge "bogus" }}`, Func: "DeepMerge", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ List (List 1 2) (List 1) | Unzip }}`, Func: "Unzip", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ List 1 | Where "a" "~" 1 }}`, Func: "Where", Arg: 1, Class: ErrInvalidArgument},
+		{Template: `{{ AssertMatches "(" "a" }}`, Func: "AssertMatches", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ List 1 | MapExpr "x +" }}`, Func: "MapExpr", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ List 1 | MapExpr "x / 0" }}`, Func: "MapExpr", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ List 1 | FilterExpr "x" }}`, Func: "FilterExpr", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ QueryOne "$[*]" (List 1 2) }}`, Func: "QueryOne", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ Query "$[" (List 1) }}`, Func: "Query", Arg: 0, Class: ErrInvalidArgument},
+		{Template: `{{ Query "$..*" (List 1 2 3 4 5) }}`, Func: "Query", Arg: 0, Class: ErrLimitExceeded},
+		{Template: `{{ Split "," "1,x" | SortNumeric }}`, Func: "SortNumeric", Arg: 0, Class: ErrInvalidArgument},
+	}
+
+	for _, test := range tests {
+		tmpl := template.Must(template.New("test").Funcs(FuncMap).Parse(test.Template))
+		err := tmpl.Execute(&bytes.Buffer{}, nil)
+		if !errors.Is(err, test.Class) {
+			t.Errorf("Template error class incorrect.  Template: %s, Expected: %s, Received: %v", test.Template, test.Class, err)
+		}
+		var argErr *ArgumentError
+		if !errors.As(err, &argErr) || argErr.Func != test.Func || argErr.Arg != test.Arg {
+			t.Errorf("Template argument error incorrect.  Template: %s, Expected: %s argument %d, Received: %v", test.Template, test.Func, test.Arg, err)
+		}
+	}
+}
+
+func TestExprRecoversPanics(t *testing.T) {
+	panicky := reflect.ValueOf(func(n int) int { return 10 / n })
+	if _, err := callFunc("Panicky", panicky, []interface{}{0}); err == nil {
+		t.Errorf("callFunc failed to return an error for a panicking function")
+	}
+	if result, err := callFunc("Panicky", panicky, []interface{}{2}); err != nil || result != 5 {
+		t.Errorf("callFunc result incorrect.  Received: %#v, Error: %v", result, err)
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+ * Escaping
+ *
+ * text/template performs no escaping of its own.  These functions make a string safe to embed
+ * in a particular kind of output, such as a shell script, SQL file, or XML document.  See also
+ * Quote, which produces a Go string literal, and QuoteRegex.
+ */
+
+// ShellQuote returns operand quoted as a single word for a POSIX shell:
+// {{ .Path | ShellQuote }}.  Strings made up only of letters, digits, and the characters
+// @%+=:,./_- are returned unchanged.  Anything else is wrapped in single quotes, so the shell
+// performs no expansion within it.
+func ShellQuote(operand string) string {
+	if operand == "" {
+		return "''"
+	}
+	if strings.IndexFunc(operand, isShellUnsafe) < 0 {
+		return operand
+	}
+	return "'" + strings.Replace(operand, "'", `'\''`, -1) + "'"
+}
+
+// SQLQuoteLiteral returns operand quoted as a string literal for the SQL dialect, which is
+// one of "ansi", "mysql", "postgres", "sqlite", or "sqlserver":
+// {{ .Name | SQLQuoteLiteral "postgres" }}.  The mysql dialect escapes with backslashes and
+// assumes the NO_BACKSLASH_ESCAPES SQL mode is disabled, as it is by default.  Strings holding
+// NUL characters or invalid UTF-8 are an error.
+func SQLQuoteLiteral(dialect, operand string) (string, error) {
+	if err := checkSQLString("SQLQuoteLiteral", dialect, operand); err != nil {
+		return "", err
+	}
+	switch dialect {
+	case "mysql":
+		return "'" + mysqlEscaper.Replace(operand) + "'", nil
+	case "postgres":
+		// E'' strings interpret backslashes regardless of standard_conforming_strings.
+		if strings.Contains(operand, `\`) {
+			return "E'" + strings.Replace(strings.Replace(operand, `\`, `\\`, -1), "'", "''", -1) + "'", nil
+		}
+	case "sqlserver":
+		// N'' strings hold Unicode regardless of the database collation.
+		return "N'" + strings.Replace(operand, "'", "''", -1) + "'", nil
+	}
+	return "'" + strings.Replace(operand, "'", "''", -1) + "'", nil
+}
+
+// SQLQuoteIdent returns operand quoted as an identifier, such as a table or column name, for
+// the SQL dialect.  See SQLQuoteLiteral for the supported dialects.  Empty strings, and strings
+// holding NUL characters or invalid UTF-8, are an error.
+func SQLQuoteIdent(dialect, operand string) (string, error) {
+	if err := checkSQLString("SQLQuoteIdent", dialect, operand); err != nil {
+		return "", err
+	}
+	if operand == "" {
+		return "", argumentError("SQLQuoteIdent", 1, ErrInvalidArgument, "SQL identifiers cannot be empty")
+	}
+	switch dialect {
+	case "mysql":
+		return "`" + strings.Replace(operand, "`", "``", -1) + "`", nil
+	case "sqlserver":
+		return "[" + strings.Replace(operand, "]", "]]", -1) + "]", nil
+	}
+	return `"` + strings.Replace(operand, `"`, `""`, -1) + `"`, nil
+}
+
+// XMLEscape uses xml.EscapeText to return operand escaped for use as XML character data or
+// attribute values.  Characters that XML does not permit, such as most control characters, are
+// replaced with U+FFFD.
+func XMLEscape(operand string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(operand))
+	return buf.String()
+}
+
+// XMLUnescape replaces the XML entities in operand, such as "&lt;" and "&#x41;", with the
+// characters they represent.  Unknown entities, and references to characters that XML does
+// not permit, are an error.
+func XMLUnescape(operand string) (string, error) {
+	var buf strings.Builder
+	for {
+		amp := strings.IndexByte(operand, '&')
+		if amp < 0 {
+			buf.WriteString(operand)
+			return buf.String(), nil
+		}
+		buf.WriteString(operand[:amp])
+		semi := strings.IndexByte(operand[amp:], ';')
+		if semi < 0 {
+			return "", argumentError("XMLUnescape", 0, ErrInvalidArgument, "unterminated XML entity at %q", operand[amp:])
+		}
+		entity := operand[amp+1 : amp+semi]
+		r, err := xmlEntity(entity)
+		if err != nil {
+			return "", wrapArgumentError("XMLUnescape", 0, err)
+		}
+		buf.WriteRune(r)
+		operand = operand[amp+semi+1:]
+	}
+}
+
+// HTMLEscape uses html.EscapeString to return operand with the characters <, >, &, ', and "
+// escaped.  The result is safe within HTML text and quoted attribute values.
+func HTMLEscape(operand string) string { return html.EscapeString(operand) }
+
+// HTMLUnescape uses html.UnescapeString to replace HTML entities in operand, such as "&lt;"
+// and "&eacute;", with the characters they represent.
+func HTMLUnescape(operand string) string { return html.UnescapeString(operand) }
+
+// JSStringEscape returns operand escaped for use within a JavaScript or JSON string literal
+// delimited by single quotes, double quotes, or backticks.  Backslashes and double quotes are
+// escaped with a backslash.  Control characters, quotes, the characters <, >, &, =, $, {, and },
+// and the line separators U+2028 and U+2029 are escaped as \uXXXX, so the result cannot start a
+// substitution within a template literal and is also safe within an HTML script element.
+// Invalid UTF-8 is replaced with U+FFFD.
+func JSStringEscape(operand string) string {
+	var buf strings.Builder
+	for i, width := 0, 0; i < len(operand); i += width {
+		var r rune
+		r, width = utf8.DecodeRuneInString(operand[i:])
+		switch {
+		case r == '\\' || r == '"':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r < 0x20 || r == 0x7f || r == 0x2028 || r == 0x2029 || strings.ContainsRune("'`<>&=${}", r):
+			fmt.Fprintf(&buf, `\u%04x`, r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+// CSVEscape returns operand as a single CSV field: {{ List .Name .Email | Join "," }}.  Fields
+// containing commas, double quotes, line breaks, or leading whitespace are wrapped in double
+// quotes, with inner double quotes doubled.  Note that CSV readers, including encoding/csv,
+// read a quoted "\r\n" as "\n".
+func CSVEscape(operand string) string {
+	if !strings.ContainsAny(operand, ",\"\r\n") && !strings.HasPrefix(operand, " ") && !strings.HasPrefix(operand, "\t") {
+		return operand
+	}
+	return `"` + strings.Replace(operand, `"`, `""`, -1) + `"`
+}
+
+var mysqlEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `\'`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x1a", `\Z`,
+)
+
+func isShellUnsafe(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	}
+	return !strings.ContainsRune("@%+=:,./_-", r)
+}
+
+// checkSQLString checks the dialect and operand arguments of fn.
+func checkSQLString(fn, dialect, operand string) error {
+	switch dialect {
+	case "ansi", "mysql", "postgres", "sqlite", "sqlserver":
+	default:
+		return argumentError(fn, 0, ErrInvalidArgument, "unknown SQL dialect %q: expected ansi, mysql, postgres, sqlite, or sqlserver", dialect)
+	}
+	if strings.IndexByte(operand, 0) >= 0 {
+		return argumentError(fn, 1, ErrInvalidArgument, "SQL strings cannot contain NUL characters")
+	}
+	if !utf8.ValidString(operand) {
+		return argumentError(fn, 1, ErrInvalidArgument, "SQL strings must be valid UTF-8")
+	}
+	return nil
+}
+
+func xmlEntity(entity string) (rune, error) {
+	switch entity {
+	case "lt":
+		return '<', nil
+	case "gt":
+		return '>', nil
+	case "amp":
+		return '&', nil
+	case "apos":
+		return '\'', nil
+	case "quot":
+		return '"', nil
+	}
+	var (
+		n   uint64
+		err error
+	)
+	switch {
+	case strings.HasPrefix(entity, "#x"):
+		n, err = strconv.ParseUint(entity[2:], 16, 32)
+	case strings.HasPrefix(entity, "#"):
+		n, err = strconv.ParseUint(entity[1:], 10, 32)
+	default:
+		return 0, fmt.Errorf("unknown XML entity %q", "&"+entity+";")
+	}
+	if err != nil || !isXMLChar(rune(n)) {
+		return 0, fmt.Errorf("invalid XML character reference %q", "&"+entity+";")
+	}
+	return rune(n), nil
+}
+
+// isXMLChar reports whether r is permitted in an XML document, per the Char production of the
+// XML 1.0 specification.
+func isXMLChar(r rune) bool {
+	return r == 0x09 || r == 0x0a || r == 0x0d ||
+		r >= 0x20 && r <= 0xd7ff ||
+		r >= 0xe000 && r <= 0xfffd ||
+		r >= 0x10000 && r <= 0x10ffff
+}
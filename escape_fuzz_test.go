@@ -0,0 +1,298 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build go1.18
+// +build go1.18
+
+package haven
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var escapeSeeds = []string{
+	"",
+	"plain",
+	"it's",
+	`say "hi"`,
+	`C:\path\to\file`,
+	"a,b\nc\r\nd",
+	"<script>alert('x')</script>",
+	"$(rm -rf /) `id` ${HOME}",
+	"Robert'); DROP TABLE students;--",
+	"\x00\x01\x1a\x7f",
+	"line\u2028sep\u2029",
+	"caf\u00e9 \U0001f600",
+	"\xff\xfe invalid",
+	"&amp; &#38; &lt;",
+	" leading space",
+	"${alert(1)}`+`${x}",
+}
+
+func FuzzShellQuote(f *testing.F) {
+	for _, seed := range escapeSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, operand string) {
+		quoted := ShellQuote(operand)
+		unquoted, err := shellUnquote(quoted)
+		if err != nil || unquoted != operand {
+			t.Errorf("ShellQuote failed to round-trip.  Operand: %q, Quoted: %q, Unquoted: %q, Error: %v", operand, quoted, unquoted, err)
+		}
+	})
+}
+
+func FuzzSQLQuoteLiteral(f *testing.F) {
+	for _, seed := range escapeSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, operand string) {
+		for _, dialect := range []string{"ansi", "mysql", "postgres", "sqlite", "sqlserver"} {
+			quoted, err := SQLQuoteLiteral(dialect, operand)
+			if err != nil {
+				if utf8.ValidString(operand) && !strings.Contains(operand, "\x00") {
+					t.Errorf("SQLQuoteLiteral encountered unexpected error: %s.  Dialect: %s, Operand: %q", err, dialect, operand)
+				}
+				continue
+			}
+			unquoted, err := sqlUnquoteLiteral(dialect, quoted)
+			if err != nil || unquoted != operand {
+				t.Errorf("SQLQuoteLiteral failed to round-trip.  Dialect: %s, Operand: %q, Quoted: %q, Unquoted: %q, Error: %v", dialect, operand, quoted, unquoted, err)
+			}
+		}
+	})
+}
+
+func FuzzSQLQuoteIdent(f *testing.F) {
+	for _, seed := range escapeSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, operand string) {
+		for _, dialect := range []string{"ansi", "mysql", "postgres", "sqlite", "sqlserver"} {
+			quoted, err := SQLQuoteIdent(dialect, operand)
+			if err != nil {
+				continue
+			}
+			open, close := `"`, `"`
+			switch dialect {
+			case "mysql":
+				open, close = "`", "`"
+			case "sqlserver":
+				open, close = "[", "]"
+			}
+			unquoted, err := unquoteDoubled(quoted, open, close)
+			if err != nil || unquoted != operand {
+				t.Errorf("SQLQuoteIdent failed to round-trip.  Dialect: %s, Operand: %q, Quoted: %q, Unquoted: %q, Error: %v", dialect, operand, quoted, unquoted, err)
+			}
+		}
+	})
+}
+
+func FuzzXMLEscape(f *testing.F) {
+	for _, seed := range escapeSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, operand string) {
+		var expected strings.Builder
+		for i, width := 0, 0; i < len(operand); i += width {
+			var r rune
+			r, width = utf8.DecodeRuneInString(operand[i:])
+			if !isXMLChar(r) {
+				r = utf8.RuneError
+			}
+			expected.WriteRune(r)
+		}
+		escaped := XMLEscape(operand)
+		if strings.ContainsAny(escaped, `<>"'`) {
+			t.Errorf("XMLEscape result contains markup.  Operand: %q, Received: %q", operand, escaped)
+		}
+		unescaped, err := XMLUnescape(escaped)
+		if err != nil || unescaped != expected.String() {
+			t.Errorf("XMLEscape failed to round-trip.  Operand: %q, Escaped: %q, Unescaped: %q, Error: %v", operand, escaped, unescaped, err)
+		}
+	})
+}
+
+func FuzzHTMLEscape(f *testing.F) {
+	for _, seed := range escapeSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, operand string) {
+		escaped := HTMLEscape(operand)
+		if strings.ContainsAny(escaped, `<>"'`) {
+			t.Errorf("HTMLEscape result contains markup.  Operand: %q, Received: %q", operand, escaped)
+		}
+		if unescaped := HTMLUnescape(escaped); unescaped != operand {
+			t.Errorf("HTMLEscape failed to round-trip.  Operand: %q, Escaped: %q, Unescaped: %q", operand, escaped, unescaped)
+		}
+	})
+}
+
+func FuzzJSStringEscape(f *testing.F) {
+	for _, seed := range escapeSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, operand string) {
+		var expected strings.Builder
+		for i, width := 0, 0; i < len(operand); i += width {
+			var r rune
+			r, width = utf8.DecodeRuneInString(operand[i:])
+			expected.WriteRune(r)
+		}
+		escaped := JSStringEscape(operand)
+		if strings.ContainsAny(escaped, "'`<>&=${}\n\r\u2028\u2029") {
+			t.Errorf("JSStringEscape result contains unsafe characters.  Operand: %q, Received: %q", operand, escaped)
+		}
+		var decoded string
+		if err := json.Unmarshal([]byte(`"`+escaped+`"`), &decoded); err != nil || decoded != expected.String() {
+			t.Errorf("JSStringEscape failed to round-trip.  Operand: %q, Escaped: %q, Decoded: %q, Error: %v", operand, escaped, decoded, err)
+		}
+		if value, err := jsTemplateLiteralValue(escaped); err != nil || value != expected.String() {
+			t.Errorf("JSStringEscape failed to round-trip as a template literal.  Operand: %q, Escaped: %q, Value: %q, Error: %v", operand, escaped, value, err)
+		}
+	})
+}
+
+func FuzzCSVEscape(f *testing.F) {
+	for _, seed := range escapeSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, operand string) {
+		line := CSVEscape(operand) + "," + CSVEscape(operand) + "\n"
+		record, err := csv.NewReader(strings.NewReader(line)).Read()
+		expected := strings.Replace(operand, "\r\n", "\n", -1)
+		if err != nil || len(record) != 2 || record[0] != expected || record[1] != expected {
+			t.Errorf("CSVEscape failed to round-trip.  Operand: %q, Line: %q, Record: %q, Error: %v", operand, line, record, err)
+		}
+	})
+}
+
+// jsTemplateLiteralValue evaluates s as the body of a JavaScript template literal, `s`.  An
+// unescaped backtick or a ${ substitution, which would run code, is an error.
+func jsTemplateLiteralValue(s string) (string, error) {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '`':
+			return "", fmt.Errorf("unescaped backtick at %d", i)
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			return "", fmt.Errorf("substitution at %d", i)
+		case s[i] == '\\' && strings.HasPrefix(s[i+1:], "u") && len(s) >= i+6:
+			r, err := strconv.ParseUint(s[i+2:i+6], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape %q", s[i:i+6])
+			}
+			buf.WriteRune(rune(r))
+			i += 5
+		case s[i] == '\\' && i+1 < len(s):
+			buf.WriteByte(s[i+1])
+			i++
+		case s[i] == '\\':
+			return "", fmt.Errorf("trailing backslash")
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String(), nil
+}
+
+// shellUnquote parses s as a single word made of unquoted safe characters, single-quoted
+// strings, and backslash-escaped characters, as a POSIX shell would.
+func shellUnquote(s string) (string, error) {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated single quote")
+			}
+			buf.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+		case s[i] == '\\' && i+1 < len(s):
+			buf.WriteByte(s[i+1])
+			i++
+		case isShellUnsafe(rune(s[i])):
+			return "", fmt.Errorf("unquoted special character %q", s[i])
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String(), nil
+}
+
+func sqlUnquoteLiteral(dialect, s string) (string, error) {
+	switch {
+	case dialect == "sqlserver":
+		s = strings.TrimPrefix(s, "N")
+	case dialect == "postgres" && strings.HasPrefix(s, "E'"):
+		unquoted, err := unquoteDoubled(s[1:], "'", "'")
+		return strings.Replace(unquoted, `\\`, `\`, -1), err
+	case dialect == "mysql":
+		if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+			return "", fmt.Errorf("missing quotes")
+		}
+		var buf strings.Builder
+		body := s[1 : len(s)-1]
+		for i := 0; i < len(body); i++ {
+			switch body[i] {
+			case '\'':
+				return "", fmt.Errorf("unescaped quote at %d", i)
+			case '\\':
+				if i++; i == len(body) {
+					return "", fmt.Errorf("trailing backslash")
+				}
+				switch body[i] {
+				case 'n':
+					buf.WriteByte('\n')
+				case 'r':
+					buf.WriteByte('\r')
+				case 'Z':
+					buf.WriteByte('\x1a')
+				default:
+					buf.WriteByte(body[i])
+				}
+			default:
+				buf.WriteByte(body[i])
+			}
+		}
+		return buf.String(), nil
+	}
+	return unquoteDoubled(s, "'", "'")
+}
+
+// unquoteDoubled removes the open and close delimiters from s and replaces doubled close
+// delimiters within it.  A lone close delimiter is an error.
+func unquoteDoubled(s, open, close string) (string, error) {
+	if !strings.HasPrefix(s, open) || !strings.HasSuffix(s, close) || len(s) < len(open)+len(close) {
+		return "", fmt.Errorf("missing delimiters")
+	}
+	body := s[len(open) : len(s)-len(close)]
+	if strings.Contains(strings.Replace(body, close+close, "", -1), close) {
+		return "", fmt.Errorf("unescaped delimiter")
+	}
+	return strings.Replace(body, close+close, close, -1), nil
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import "testing"
+
+func TestShellQuote(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Expected string
+	}{
+		{Operand: "", Expected: "''"},
+		{Operand: "file.txt", Expected: "file.txt"},
+		{Operand: "user@host:/srv/a-b_c,d=e+f%", Expected: "user@host:/srv/a-b_c,d=e+f%"},
+		{Operand: "two words", Expected: "'two words'"},
+		{Operand: "it's", Expected: `'it'\''s'`},
+		{Operand: "$HOME", Expected: "'$HOME'"},
+		{Operand: "*", Expected: "'*'"},
+	}
+
+	for _, test := range tests {
+		result := ShellQuote(test.Operand)
+		if result != test.Expected {
+			t.Errorf("ShellQuote result incorrect.  Operand: %q, Expected: %s, Received: %s", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestSQLQuoteLiteral(t *testing.T) {
+	var tests = []struct {
+		Dialect  string
+		Operand  string
+		Expected string
+		Valid    bool
+	}{
+		{Dialect: "ansi", Operand: "it's", Expected: "'it''s'", Valid: true},
+		{Dialect: "sqlite", Operand: `a\b`, Expected: `'a\b'`, Valid: true},
+		{Dialect: "postgres", Operand: "it's", Expected: "'it''s'", Valid: true},
+		{Dialect: "postgres", Operand: `it's a\b`, Expected: `E'it''s a\\b'`, Valid: true},
+		{Dialect: "mysql", Operand: "it's \"a\\b\"\n", Expected: `'it\'s \"a\\b\"\n'`, Valid: true},
+		{Dialect: "sqlserver", Operand: "caf\u00e9's", Expected: "N'caf\u00e9''s'", Valid: true},
+		{Dialect: "ansi", Operand: "", Expected: "''", Valid: true},
+		{Dialect: "oracle", Operand: "a", Valid: false},
+		{Dialect: "ansi", Operand: "a\x00b", Valid: false},
+		{Dialect: "mysql", Operand: "\xbf\x27", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := SQLQuoteLiteral(test.Dialect, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("SQLQuoteLiteral encountered unexpected error: %s.  Dialect: %s, Operand: %q", err, test.Dialect, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("SQLQuoteLiteral failed to return an error.  Dialect: %s, Operand: %q", test.Dialect, test.Operand)
+		}
+		if result != test.Expected {
+			t.Errorf("SQLQuoteLiteral result incorrect.  Dialect: %s, Operand: %q, Expected: %s, Received: %s", test.Dialect, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestSQLQuoteIdent(t *testing.T) {
+	var tests = []struct {
+		Dialect  string
+		Operand  string
+		Expected string
+		Valid    bool
+	}{
+		{Dialect: "ansi", Operand: `my "table"`, Expected: `"my ""table"""`, Valid: true},
+		{Dialect: "postgres", Operand: "users", Expected: `"users"`, Valid: true},
+		{Dialect: "mysql", Operand: "a`b", Expected: "`a``b`", Valid: true},
+		{Dialect: "sqlserver", Operand: "a]b[c", Expected: "[a]]b[c]", Valid: true},
+		{Dialect: "ansi", Operand: "", Valid: false},
+		{Dialect: "mysql", Operand: "a\x00", Valid: false},
+		{Dialect: "db2", Operand: "a", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := SQLQuoteIdent(test.Dialect, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("SQLQuoteIdent encountered unexpected error: %s.  Dialect: %s, Operand: %q", err, test.Dialect, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("SQLQuoteIdent failed to return an error.  Dialect: %s, Operand: %q", test.Dialect, test.Operand)
+		}
+		if result != test.Expected {
+			t.Errorf("SQLQuoteIdent result incorrect.  Dialect: %s, Operand: %q, Expected: %s, Received: %s", test.Dialect, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestXMLEscape(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Expected string
+	}{
+		{Operand: `<a href="x">Tom & Jerry's</a>`, Expected: "&lt;a href=&#34;x&#34;&gt;Tom &amp; Jerry&#39;s&lt;/a&gt;"},
+		{Operand: "tab\tnew\nline", Expected: "tab&#x9;new&#xA;line"},
+		{Operand: "nul\x00", Expected: "nul\uFFFD"},
+		{Operand: "caf\u00e9", Expected: "caf\u00e9"},
+	}
+
+	for _, test := range tests {
+		result := XMLEscape(test.Operand)
+		if result != test.Expected {
+			t.Errorf("XMLEscape result incorrect.  Operand: %q, Expected: %q, Received: %q", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestXMLUnescape(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Expected string
+		Valid    bool
+	}{
+		{Operand: "&lt;a&gt; &amp; &apos;b&apos; &quot;c&quot;", Expected: `<a> & 'b' "c"`, Valid: true},
+		{Operand: "&#65;&#x42;&#x1F600;", Expected: "AB\U0001f600", Valid: true},
+		{Operand: "no entities", Expected: "no entities", Valid: true},
+		{Operand: "&nbsp;", Valid: false},
+		{Operand: "a & b", Valid: false},
+		{Operand: "&#0;", Valid: false},
+		{Operand: "&#xD800;", Valid: false},
+		{Operand: "&#x;", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := XMLUnescape(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("XMLUnescape encountered unexpected error: %s.  Operand: %q", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("XMLUnescape failed to return an error.  Operand: %q", test.Operand)
+		}
+		if result != test.Expected {
+			t.Errorf("XMLUnescape result incorrect.  Operand: %q, Expected: %q, Received: %q", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestHTMLEscape(t *testing.T) {
+	escaped := HTMLEscape(`<img src="x" onerror='y'> & more`)
+	if escaped != "&lt;img src=&#34;x&#34; onerror=&#39;y&#39;&gt; &amp; more" {
+		t.Errorf("HTMLEscape result incorrect.  Received: %s", escaped)
+	}
+	if unescaped := HTMLUnescape("&eacute;&lt;&#x41;&amp;amp;"); unescaped != "\u00e9<A&amp;" {
+		t.Errorf("HTMLUnescape result incorrect.  Received: %s", unescaped)
+	}
+}
+
+func TestJSStringEscape(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Expected string
+	}{
+		{Operand: `say "hi" \o/`, Expected: `say \"hi\" \\o/`},
+		{Operand: "it's `x`", Expected: `it\u0027s \u0060x\u0060`},
+		{Operand: "</script><!--", Expected: `\u003c/script\u003e\u003c!--`},
+		{Operand: "a=b&c", Expected: `a\u003db\u0026c`},
+		{Operand: "${alert(1)}", Expected: `\u0024\u007balert(1)\u007d`},
+		{Operand: "tab\tnew\n\u2028", Expected: `tab\u0009new\u000a\u2028`},
+		{Operand: "caf\u00e9\xff", Expected: "caf\u00e9\uFFFD"},
+	}
+
+	for _, test := range tests {
+		result := JSStringEscape(test.Operand)
+		if result != test.Expected {
+			t.Errorf("JSStringEscape result incorrect.  Operand: %q, Expected: %s, Received: %s", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestCSVEscape(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Expected string
+	}{
+		{Operand: "plain", Expected: "plain"},
+		{Operand: "", Expected: ""},
+		{Operand: "a,b", Expected: `"a,b"`},
+		{Operand: `say "hi"`, Expected: `"say ""hi"""`},
+		{Operand: "two\nlines", Expected: "\"two\nlines\""},
+		{Operand: " padded", Expected: `" padded"`},
+	}
+
+	for _, test := range tests {
+		result := CSVEscape(test.Operand)
+		if result != test.Expected {
+			t.Errorf("CSVEscape result incorrect.  Operand: %q, Expected: %s, Received: %s", test.Operand, test.Expected, result)
+		}
+	}
+}
@@ -0,0 +1,889 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// exprFuncNames lists the functions callable from expressions.  Every function listed is pure,
+// returning the same result for the same arguments, so an expression gives the same result for
+// the same operand.  It omits the functions that depend on randomness or the current time, such
+// as Now, Shuffle, and the Random and UUID generators, as well as MapExpr, FilterExpr, Query,
+// and QueryOne, since they evaluate expressions themselves and would escape the step budget of
+// the calling expression.  TestExprFuncNames fails unless every FuncMap function is either listed
+// here or excluded there, so a function added to or removed from FuncMap must be classified.
+var exprFuncNames = []string{
+	"Abs", "Add", "All", "Any", "Append", "Assert", "AssertMatches", "AssertType",
+	"Base64Decode", "Base64Encode", "CIDRContains", "CIDRHosts", "CIDRSubnet", "CSVEscape",
+	"Chunk", "Compact", "CompileERE", "CompileRegex", "Concat", "Contains", "ContainsAny",
+	"Count", "CountBy", "DeepCopy", "DeepEqual", "DeepMerge", "Dict", "Diff", "Difference",
+	"Divide", "Entries", "Enumerate", "Equal", "Fail", "Fields", "Find", "Flatten",
+	"FormatFloat", "FormatInt", "FormatNumber", "Grep", "GroupBy", "HTMLEscape",
+	"HTMLUnescape", "HasPrefix", "HasSuffix", "HashBucket", "Head", "HumanizeBytes",
+	"HumanizeBytesSI", "HumanizeSI", "IPAdd", "IPCompare", "Index", "IndexAny", "IndexBy",
+	"Interleave", "Intersect", "IsIPv4", "IsIPv6", "IsMap", "IsNumber", "IsSlice", "IsString",
+	"IsSubset", "IsUUID", "JSONPatch", "JSStringEscape", "Join", "KindOf", "LastIndex",
+	"LastIndexAny", "Lines", "List", "Matches", "Max", "MaxBy", "MergePatch", "Min", "MinBy",
+	"Modulo", "Multiply", "None", "Ordinal", "ParseBool", "ParseBytes", "ParseCIDR",
+	"ParseFloat", "ParseIP", "ParseInt", "ParseInt64", "ParseIntBase", "ParseTime",
+	"ParseURL", "ParseUUID", "ParseUint", "Partition", "Percent", "Prepend", "Quote",
+	"QuoteRegex", "Reject", "Repeat", "Replace", "Reverse", "Rotate", "SQLQuoteIdent",
+	"SQLQuoteLiteral", "SeededShuffle", "SemverBump", "SemverCompare", "SemverParse",
+	"SemverSatisfies", "Seq", "ShellQuote", "Slice", "Sort", "SortBy", "SortByDesc",
+	"SortFold", "SortFoldDesc", "SortNatural", "SortNaturalDesc", "SortNumeric",
+	"SortNumericDesc", "SortSemver", "SortStable", "Split", "SplitAfter", "SplitAfterN",
+	"SplitN", "Subtract", "Sum", "SumBy", "SymmetricDifference", "Tail", "Title", "ToBool",
+	"ToFloat", "ToInt", "ToInt64", "ToLower", "ToString", "ToUpper", "Trim", "TrimLeft",
+	"TrimPrefix", "TrimRight", "TrimSpace", "TrimSuffix", "TypeOf", "UUIDv5", "Union", "Uniq",
+	"UniqBy", "Unquote", "Unzip", "Where", "Window", "XMLEscape", "XMLUnescape", "Zip",
+}
+
+// defaultEnv.funcs is assigned in init, since FuncMap itself refers to MapExpr and FilterExpr.
+func init() { defaultEnv.funcs = exprFuncs(FuncMap) }
+
+// exprFuncs returns a copy of the functions in funcs that expressions may call.  Functions
+// added to funcs afterwards, such as a caller's own additions to FuncMap, are not callable.
+func exprFuncs(funcs map[string]interface{}) map[string]interface{} {
+	allowed := make(map[string]interface{}, len(exprFuncNames))
+	for _, name := range exprFuncNames {
+		if fn, ok := funcs[name]; ok {
+			allowed[name] = fn
+		}
+	}
+	return allowed
+}
+
+/*
+ * Expressions
+ *
+ * MapExpr and FilterExpr evaluate a small expression language for each element of a slice.
+ * The element is available as x and its index as i.  Expressions support:
+ *
+ *	literals        42, 1.5, "text", 'text', true, false, nil
+ *	fields          x.price, x.owner.name, x["content-type"], x.tags[0]
+ *	arithmetic      + - * / % and unary -, with + also joining strings.  Integer results
+ *	                that overflow int64 are an error.
+ *	comparison      == != < <= > >=, following the rules of Where
+ *	logic           && || !, which require bools
+ *	calls           haven functions by name, such as ToUpper(x.name) or Join(",", x.tags)
+ *
+ * Expressions cannot assign variables, loop, or call anything other than the haven functions
+ * in exprFuncNames.  Functions added to FuncMap are not callable, and expressions evaluated by
+ * the functions from NewFuncMap call its bound functions.  Evaluation is bounded by
+ * DefaultLimits.MaxSteps across all elements, with each function call costing a step plus the
+ * size of its arguments and result.
+ */
+
+// MapExpr evaluates expr for each element of operand and returns the results:
+// {{ .Items | MapExpr "x.price * x.qty" | Sum }}.
+func MapExpr(expr string, operand interface{}) ([]interface{}, error) {
+	return defaultEnv.mapExpr(expr, operand)
+}
+
+// FilterExpr returns a copy of operand containing the elements for which expr evaluates to
+// true: {{ range FilterExpr "x.age >= 18" .People }}...{{ end }}.  Expr must evaluate to a bool.
+func FilterExpr(expr string, operand interface{}) (interface{}, error) {
+	return defaultEnv.filterExpr(expr, operand)
+}
+
+// Sum returns the sum of the numbers in operand.  The sum is an int64 if every element is an
+// integer and a float64 otherwise.
+func Sum(operand interface{}) (interface{}, error) {
+	sum, err := SumBy("", operand)
+	return sum, wrapArgumentError("Sum", 0, err)
+}
+
+func (e env) mapExpr(expr string, operand interface{}) ([]interface{}, error) {
+	v, compiled, err := e.prepareExpr("MapExpr", expr, operand)
+	if err != nil {
+		return nil, err
+	}
+	state := &exprState{ctx: e.ctx}
+	results := make([]interface{}, v.Len())
+	for i := range results {
+		if results[i], err = compiled.eval(state, v.Index(i).Interface(), i); err != nil {
+			return nil, wrapArgumentError("MapExpr", 0, err)
+		}
+	}
+	return results, nil
+}
+
+func (e env) filterExpr(expr string, operand interface{}) (interface{}, error) {
+	v, compiled, err := e.prepareExpr("FilterExpr", expr, operand)
+	if err != nil {
+		return nil, err
+	}
+	state := &exprState{ctx: e.ctx}
+	filtered := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		result, err := compiled.eval(state, v.Index(i).Interface(), i)
+		if err != nil {
+			return nil, wrapArgumentError("FilterExpr", 0, err)
+		}
+		keep, ok := result.(bool)
+		if !ok {
+			return nil, argumentError("FilterExpr", 0, ErrInvalidArgument, "expression %q returned %s, expected bool", expr, TypeOf(result))
+		}
+		if keep {
+			filtered = reflect.Append(filtered, v.Index(i))
+		}
+	}
+	return filtered.Interface(), nil
+}
+
+func (e env) prepareExpr(fn, expr string, operand interface{}) (reflect.Value, *expression, error) {
+	v, err := sliceValue(operand)
+	if err != nil {
+		return v, nil, wrapArgumentError(fn, 1, err)
+	}
+	compiled, err := compileExpr(fn, expr, "x", "i", e.funcs)
+	return v, compiled, wrapArgumentError(fn, 0, err)
+}
+
+// expression is a compiled expression along with the names of the variables bound to the
+// element and index during evaluation.  A strict expression, as used by query filters, fails
+// with errMissing when a field or index it accesses does not exist.
+type expression struct {
+	source   string
+	root     exprNode
+	elemVar  string
+	indexVar string
+	strict   bool
+}
+
+// errMissing is returned by strict expressions for a field or index that does not exist.
+var errMissing = errors.New("path does not exist")
+
+// exprState tracks the steps taken across evaluations sharing a budget, along with the
+// context, if any, that stops them.
+type exprState struct {
+	ctx   context.Context
+	steps int
+}
+
+// done returns the error of the context once it is done.
+func (s *exprState) done() error {
+	if s.ctx == nil {
+		return nil
+	}
+	return s.ctx.Err()
+}
+
+type exprNode interface {
+	eval(env *exprEnv) (interface{}, error)
+}
+
+type exprEnv struct {
+	state *exprState
+	expr  *expression
+	elem  interface{}
+	index int
+}
+
+func (e *exprEnv) step() error { return e.charge(1) }
+
+// charge counts n steps against the budget.
+func (e *exprEnv) charge(n int) error {
+	e.state.steps += n
+	if e.state.steps > DefaultLimits.MaxSteps {
+		return limitErrorf("expression %q exceeded the limit of %d steps", e.expr.source, DefaultLimits.MaxSteps)
+	}
+	return e.state.done()
+}
+
+func (x *expression) eval(state *exprState, elem interface{}, index int) (interface{}, error) {
+	result, err := x.root.eval(&exprEnv{state: state, expr: x, elem: elem, index: index})
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", x.source, err)
+	}
+	return result, nil
+}
+
+// compileExpr parses src, binding elemVar and indexVar as the only variables and resolving
+// calls against funcs.  Expressions nested more than DefaultLimits.MaxDepth levels deep are
+// rejected with an ArgumentError for argument 0 of fn.
+func compileExpr(fn, src, elemVar, indexVar string, funcs map[string]interface{}) (*expression, error) {
+	tokens, err := tokenizeExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %s", src, err)
+	}
+	p := &exprParser{tokens: tokens, elemVar: elemVar, indexVar: indexVar, funcs: funcs}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %s", p.peek())
+	}
+	if err == errExprTooDeep {
+		return nil, argumentError(fn, 0, ErrLimitExceeded, "expression %q is nested more than %d levels deep", src, DefaultLimits.MaxDepth)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %s", src, err)
+	}
+	return &expression{source: src, root: root, elemVar: elemVar, indexVar: indexVar}, nil
+}
+
+/*
+ * Tokenizer
+ */
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOp
+)
+
+type exprToken struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+func (t exprToken) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q at offset %d", t.text, t.pos)
+}
+
+var exprOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "!", "(", ")", "[", "]", ".", ","}
+
+func tokenizeExpr(src string) ([]exprToken, error) {
+	var tokens []exprToken
+	for pos := 0; pos < len(src); {
+		r, size := utf8.DecodeRuneInString(src[pos:])
+		switch {
+		case unicode.IsSpace(r):
+			pos += size
+		case r >= '0' && r <= '9':
+			end := pos
+			for end < len(src) && (isASCIIDigit(src[end]) || src[end] == '.' || src[end] == '_' ||
+				src[end] == 'e' || src[end] == 'E' || (src[end-1] == 'e' || src[end-1] == 'E') && (src[end] == '+' || src[end] == '-')) {
+				end++
+			}
+			text := src[pos:end]
+			var value interface{}
+			if i, err := strconv.ParseInt(text, 0, 64); err == nil {
+				value = int(i)
+				if int64(int(i)) != i {
+					value = i
+				}
+			} else if f, err := strconv.ParseFloat(text, 64); err == nil {
+				value = f
+			} else {
+				return nil, fmt.Errorf("invalid number %q at offset %d", text, pos)
+			}
+			tokens = append(tokens, exprToken{kind: tokenNumber, text: text, value: value, pos: pos})
+			pos = end
+		case r == '"' || r == '\'':
+			text, value, err := scanExprString(src[pos:])
+			if err != nil {
+				return nil, fmt.Errorf("%s at offset %d", err, pos)
+			}
+			tokens = append(tokens, exprToken{kind: tokenString, text: text, value: value, pos: pos})
+			pos += len(text)
+		case r == '_' || r == '@' || r == '$' || unicode.IsLetter(r):
+			end := pos + size
+			for end < len(src) {
+				next, nextSize := utf8.DecodeRuneInString(src[end:])
+				if next != '_' && !unicode.IsLetter(next) && !unicode.IsDigit(next) {
+					break
+				}
+				end += nextSize
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, text: src[pos:end], pos: pos})
+			pos = end
+		default:
+			op := ""
+			for _, candidate := range exprOperators {
+				if strings.HasPrefix(src[pos:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", r, pos)
+			}
+			tokens = append(tokens, exprToken{kind: tokenOp, text: op, pos: pos})
+			pos += len(op)
+		}
+	}
+	return append(tokens, exprToken{kind: tokenEOF, pos: len(src)}), nil
+}
+
+// scanExprString scans the quoted string at the start of src, returning its source text and
+// value.  Double-quoted strings follow Go syntax.  Single-quoted strings may escape only \' and
+// \\.
+func scanExprString(src string) (string, string, error) {
+	quote := src[0]
+	var value strings.Builder
+	for i := 1; i < len(src); i++ {
+		switch src[i] {
+		case quote:
+			if quote == '"' {
+				unquoted, err := strconv.Unquote(src[:i+1])
+				return src[:i+1], unquoted, err
+			}
+			return src[:i+1], value.String(), nil
+		case '\\':
+			i++
+			if i < len(src) && quote == '\'' && src[i] != '\'' && src[i] != '\\' {
+				return "", "", fmt.Errorf("invalid escape \\%c in string", src[i])
+			}
+		}
+		if i < len(src) {
+			value.WriteByte(src[i])
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string")
+}
+
+/*
+ * Parser
+ */
+
+type exprParser struct {
+	tokens   []exprToken
+	pos      int
+	depth    int
+	elemVar  string
+	indexVar string
+	funcs    map[string]interface{}
+}
+
+// errExprTooDeep is returned by exprParser for expressions nested more than
+// DefaultLimits.MaxDepth levels deep.
+var errExprTooDeep = errors.New("expression is nested too deeply")
+
+func (p *exprParser) peek() exprToken { return p.tokens[p.pos] }
+
+func (p *exprParser) next() exprToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		return fmt.Errorf("expected %q, found %s", op, p.peek())
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	return p.parseBinary(p.parseComparison, "&&")
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := p.accept("==", "!=", "<=", ">=", "<", ">"); ok {
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAdditive() (exprNode, error) {
+	return p.parseBinary(p.parseMultiplicative, "+", "-")
+}
+
+func (p *exprParser) parseMultiplicative() (exprNode, error) {
+	return p.parseBinary(p.parseUnary, "*", "/", "%")
+}
+
+func (p *exprParser) parseBinary(operand func() (exprNode, error), ops ...string) (exprNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+// parseUnary is reached once per level of nesting, whether through parentheses, calls,
+// indexes, or unary operators, so it bounds the depth of the recursion.
+func (p *exprParser) parseUnary() (exprNode, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > DefaultLimits.MaxDepth {
+		return nil, errExprTooDeep
+	}
+	if op, ok := p.accept("-", "!"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *exprParser) parsePostfix() (exprNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("."); ok {
+			t := p.next()
+			if t.kind != tokenIdent {
+				return nil, fmt.Errorf("expected field name, found %s", t)
+			}
+			node = &indexNode{container: node, key: &literalNode{value: t.text}}
+			continue
+		}
+		if _, ok := p.accept("["); ok {
+			key, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			node = &indexNode{container: node, key: key}
+			continue
+		}
+		return node, nil
+	}
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber, tokenString:
+		return &literalNode{value: t.value}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "nil", "null":
+			return &literalNode{value: nil}, nil
+		case p.elemVar:
+			return &varNode{index: false}, nil
+		case p.indexVar:
+			return &varNode{index: true}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(t)
+		}
+		return nil, fmt.Errorf("unknown variable %s", t)
+	case tokenOp:
+		if t.text == "(" {
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		}
+	}
+	return nil, fmt.Errorf("unexpected %s", t)
+}
+
+func (p *exprParser) parseCall(name exprToken) (exprNode, error) {
+	fn, ok := p.funcs[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+	call := &callNode{name: name.text, fn: reflect.ValueOf(fn)}
+	if _, ok := p.accept(")"); ok {
+		return call, nil
+	}
+	for {
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		if _, ok := p.accept(","); !ok {
+			return call, p.expect(")")
+		}
+	}
+}
+
+/*
+ * Evaluation
+ */
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(env *exprEnv) (interface{}, error) { return n.value, env.step() }
+
+type varNode struct {
+	index bool
+}
+
+func (n *varNode) eval(env *exprEnv) (interface{}, error) {
+	if n.index {
+		return env.index, env.step()
+	}
+	return env.elem, env.step()
+}
+
+type indexNode struct {
+	container exprNode
+	key       exprNode
+}
+
+func (n *indexNode) eval(env *exprEnv) (interface{}, error) {
+	container, err := n.container.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	key, err := n.key.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if err := env.step(); err != nil {
+		return nil, err
+	}
+	if i, err := exactInt64(key); err == nil && !IsString(key) {
+		key = strconv.FormatInt(i, 10)
+	}
+	segment, ok := key.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid index %v", key)
+	}
+	value, found, err := lookupSegment(segment, container)
+	if env.expr.strict && (err != nil || !found) {
+		return nil, errMissing
+	}
+	return value, err
+}
+
+type unaryNode struct {
+	op      string
+	operand exprNode
+}
+
+func (n *unaryNode) eval(env *exprEnv) (interface{}, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if err := env.step(); err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator ! requires a bool, received %s", TypeOf(value))
+		}
+		return !b, nil
+	}
+	return arithmetic("-", 0, value)
+}
+
+type binaryNode struct {
+	op    string
+	left  exprNode
+	right exprNode
+}
+
+func (n *binaryNode) eval(env *exprEnv) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s requires bools, received %s", n.op, TypeOf(left))
+		}
+		if l == (n.op == "||") {
+			return l, env.step()
+		}
+		right, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s requires bools, received %s", n.op, TypeOf(right))
+		}
+		return r, env.step()
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if err := env.step(); err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equalValues(left, right), nil
+	case "!=":
+		return !equalValues(left, right), nil
+	case "<", "<=", ">", ">=":
+		// Ordering operators cannot fail, so no function name is needed for errors.
+		test, _ := newComparison("", n.op, right)
+		return test(left)
+	}
+	return arithmetic(n.op, left, right)
+}
+
+// arithmetic applies op to a and b.  Integers use int64 arithmetic, other numbers use float64,
+// and + also concatenates strings.
+func arithmetic(op string, a, b interface{}) (interface{}, error) {
+	if op == "+" && IsString(a) && IsString(b) {
+		return reflect.ValueOf(a).String() + reflect.ValueOf(b).String(), nil
+	}
+	if !IsNumber(a) || !IsNumber(b) {
+		return nil, fmt.Errorf("operator %s requires numbers, received %s and %s", op, TypeOf(a), TypeOf(b))
+	}
+	ai, aerr := exactInt64(a)
+	bi, berr := exactInt64(b)
+	if aerr == nil && berr == nil {
+		return intArithmetic(op, ai, bi)
+	}
+	af, _ := toFloat(a)
+	bf, _ := toFloat(b)
+	switch op {
+	case "+":
+		return af + bf, nil
+	case "-":
+		return af - bf, nil
+	case "*":
+		return af * bf, nil
+	case "/":
+		return af / bf, nil
+	}
+	return math.Mod(af, bf), nil
+}
+
+// intArithmetic applies op to the int64 operands a and b.  Results that overflow int64 are an
+// error rather than wrapping around.
+func intArithmetic(op string, a, b int64) (interface{}, error) {
+	var result int64
+	overflow := false
+	switch op {
+	case "+":
+		result = a + b
+		overflow = b > 0 && result < a || b < 0 && result > a
+	case "-":
+		result = a - b
+		overflow = b < 0 && result < a || b > 0 && result > a
+	case "*":
+		result = a * b
+		overflow = a != 0 && (result/a != b || a == -1 && b == math.MinInt64)
+	default:
+		if b == 0 {
+			return nil, fmt.Errorf("integer division by zero")
+		}
+		if op == "%" {
+			return a % b, nil
+		}
+		result = a / b
+		overflow = a == math.MinInt64 && b == -1
+	}
+	if overflow {
+		return nil, rangeErrorf("integer overflow in %d %s %d", a, op, b)
+	}
+	return result, nil
+}
+
+type callNode struct {
+	name string
+	fn   reflect.Value
+	args []exprNode
+}
+
+func (n *callNode) eval(env *exprEnv) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		var err error
+		if args[i], err = arg.eval(env); err != nil {
+			return nil, err
+		}
+	}
+	// A call costs a step plus the size of its arguments and result, so that functions such
+	// as Repeat and Sort consume the budget in proportion to the work they do.
+	cost := 1
+	for _, arg := range args {
+		cost += valueSize(arg)
+	}
+	if err := env.charge(cost); err != nil {
+		return nil, err
+	}
+	result, err := callFunc(n.name, n.fn, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := env.charge(valueSize(result)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// valueSize returns the length of a string, slice, array, or map, and 0 for other values.
+func valueSize(value interface{}) int {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len()
+	}
+	return 0
+}
+
+// callFunc calls fn with args, converting each argument to the parameter type where the
+// conversion is lossless, such as a float64 holding an integer to an int, or a []interface{} of
+// strings to a []string.
+func callFunc(name string, fn reflect.Value, args []interface{}) (result interface{}, err error) {
+	// Like text/template, report a panicking function as an error rather than crashing the
+	// caller.
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("error calling %s: %v", name, r)
+		}
+	}()
+	t := fn.Type()
+	if t.IsVariadic() && len(args) < t.NumIn()-1 || !t.IsVariadic() && len(args) != t.NumIn() {
+		return nil, fmt.Errorf("wrong number of arguments for %s: received %d, expected %d", name, len(args), t.NumIn())
+	}
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var paramType reflect.Type
+		if t.IsVariadic() && i >= t.NumIn()-1 {
+			paramType = t.In(t.NumIn() - 1).Elem()
+		} else {
+			paramType = t.In(i)
+		}
+		v, err := convertArg(arg, paramType)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d of %s: %s", i+1, name, err)
+		}
+		in[i] = v
+	}
+	out := fn.Call(in)
+	if len(out) == 2 && !out[1].IsNil() {
+		return nil, out[1].Interface().(error)
+	}
+	return out[0].Interface(), nil
+}
+
+func convertArg(arg interface{}, t reflect.Type) (reflect.Value, error) {
+	if arg == nil {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Slice, reflect.Map:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot use nil as %s", t)
+	}
+	v := reflect.ValueOf(arg)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if IsNumber(arg) {
+			i, err := exactInt64(arg)
+			if err != nil {
+				f, _ := toFloat(arg)
+				if f != math.Trunc(f) {
+					return reflect.Value{}, fmt.Errorf("cannot use %v as %s", arg, t)
+				}
+				if i, err = floatToInt64(f); err != nil {
+					return reflect.Value{}, err
+				}
+			}
+			converted := reflect.New(t).Elem()
+			if converted.OverflowInt(i) {
+				return reflect.Value{}, fmt.Errorf("value %d overflows %s", i, t)
+			}
+			converted.SetInt(i)
+			return converted, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if IsNumber(arg) {
+			f, _ := toFloat(arg)
+			return reflect.ValueOf(f).Convert(t), nil
+		}
+	case reflect.String:
+		if v.Kind() == reflect.String {
+			return v.Convert(t), nil
+		}
+	case reflect.Slice:
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			converted := reflect.MakeSlice(t, v.Len(), v.Len())
+			for i := 0; i < v.Len(); i++ {
+				elem, err := convertArg(v.Index(i).Interface(), t.Elem())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				converted.Index(i).Set(elem)
+			}
+			return converted, nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %s as %s", TypeOf(arg), t)
+}
@@ -0,0 +1,274 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestMapExpr(t *testing.T) {
+	items := decodeRecords(t, `[
+		{"name": "pen", "price": 1.5, "qty": 4, "tags": ["office", "ink"]},
+		{"name": "pad", "price": 3, "qty": 2, "tags": [], "content-type": "paper"}
+	]`)
+	var tests = []struct {
+		Expr     string
+		Operand  interface{}
+		Expected []interface{}
+		Valid    bool
+	}{
+		{Expr: "x.price * x.qty", Operand: items, Expected: []interface{}{6.0, 6.0}, Valid: true},
+		{Expr: "x * 2 + 1", Operand: []int{1, 2}, Expected: []interface{}{int64(3), int64(5)}, Valid: true},
+		{Expr: "-(x - 10) % 4", Operand: []int{3}, Expected: []interface{}{int64(3)}, Valid: true},
+		{Expr: "7 / x", Operand: []int{2, 4}, Expected: []interface{}{int64(3), int64(1)}, Valid: true},
+		{Expr: "7.0 / x", Operand: []int{2}, Expected: []interface{}{3.5}, Valid: true},
+		{Expr: "i", Operand: []string{"a", "b"}, Expected: []interface{}{0, 1}, Valid: true},
+		{Expr: `x.name + "-" + 'x'`, Operand: items, Expected: []interface{}{"pen-x", "pad-x"}, Valid: true},
+		{Expr: `ToUpper(x.name)`, Operand: items, Expected: []interface{}{"PEN", "PAD"}, Valid: true},
+		{Expr: `Join(",", x.tags)`, Operand: items, Expected: []interface{}{"office,ink", ""}, Valid: true},
+		{Expr: `Repeat(x.qty, "*")`, Operand: items, Expected: []interface{}{"****", "**"}, Valid: true},
+		{Expr: `x.tags[0]`, Operand: []interface{}{items[0]}, Expected: []interface{}{"office"}, Valid: true},
+		{Expr: `x["content-type"]`, Operand: items, Expected: []interface{}{nil, "paper"}, Valid: true},
+		{Expr: `x.Owner.Email`, Operand: testRecords, Expected: []interface{}{"a@example.com", nil, "c@example.com"}, Valid: true},
+		{Expr: `x.qty > 3 && x.name != "pad" || false`, Operand: items, Expected: []interface{}{true, false}, Valid: true},
+		{Expr: `!(x.price == 3)`, Operand: items, Expected: []interface{}{true, false}, Valid: true},
+		{Expr: "x - 1 + 1", Operand: []int64{math.MaxInt64}, Expected: []interface{}{int64(math.MaxInt64)}, Valid: true},
+		{Expr: "x * -1", Operand: []int64{math.MaxInt64}, Expected: []interface{}{int64(-math.MaxInt64)}, Valid: true},
+		{Expr: "x % -1", Operand: []int64{math.MinInt64}, Expected: []interface{}{int64(0)}, Valid: true},
+		{Expr: "x + 1", Operand: []int64{math.MaxInt64}, Valid: false},
+		{Expr: "x - 1", Operand: []int64{math.MinInt64}, Valid: false},
+		{Expr: "-x", Operand: []int64{math.MinInt64}, Valid: false},
+		{Expr: "x * 2", Operand: []int64{math.MaxInt64/2 + 1}, Valid: false},
+		{Expr: "x * -1", Operand: []int64{math.MinInt64}, Valid: false},
+		{Expr: "-1 * x", Operand: []int64{math.MinInt64}, Valid: false},
+		{Expr: "x / -1", Operand: []int64{math.MinInt64}, Valid: false},
+		{Expr: "y.price", Operand: items, Valid: false},
+		{Expr: "Exec(x)", Operand: items, Valid: false},
+		{Expr: `MapExpr("x", x.tags)`, Operand: items, Valid: false},
+		{Expr: "x.price *", Operand: items, Valid: false},
+		{Expr: "x.price )", Operand: items, Valid: false},
+		{Expr: `"unterminated`, Operand: items, Valid: false},
+		{Expr: "x.price # 2", Operand: items, Valid: false},
+		{Expr: "x.name * 2", Operand: items, Valid: false},
+		{Expr: "x.qty && true", Operand: items, Valid: false},
+		{Expr: "10 / (x - 1)", Operand: []int{1}, Valid: false},
+		{Expr: "ToUpper(x.name, x.name)", Operand: items, Valid: false},
+		{Expr: "Repeat(x.price, x.name)", Operand: items, Valid: false},
+		{Expr: "x.Missing", Operand: testRecords, Valid: false},
+		{Expr: "x", Operand: "items", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := MapExpr(test.Expr, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("MapExpr encountered unexpected error: %s.  Expr: %s", err, test.Expr)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("MapExpr failed to return an error.  Expr: %s, Received: %#v", test.Expr, result)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("MapExpr result incorrect.  Expr: %s, Expected: %#v, Received: %#v", test.Expr, test.Expected, result)
+		}
+	}
+
+	if _, err := MapExpr("x * x", []int64{math.MaxInt64}); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("MapExpr failed to classify integer overflow as out of range.  Received: %v", err)
+	}
+}
+
+func TestFilterExpr(t *testing.T) {
+	people := decodeRecords(t, `[{"name": "ann", "age": 17}, {"name": "bob", "age": 18}, {"name": "cid", "age": 40}]`)
+	var tests = []struct {
+		Expr     string
+		Operand  interface{}
+		Expected []string
+		Valid    bool
+	}{
+		{Expr: "x.age >= 18", Operand: people, Expected: []string{"bob", "cid"}, Valid: true},
+		{Expr: `HasPrefix("c", x.name) || x.age < 18`, Operand: people, Expected: []string{"ann", "cid"}, Valid: true},
+		{Expr: "x.Score > 8", Operand: testRecords, Expected: []string{"web"}, Valid: true},
+		{Expr: "x.age", Operand: people, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := FilterExpr(test.Expr, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("FilterExpr encountered unexpected error: %s.  Expr: %s", err, test.Expr)
+		}
+		if !test.Valid {
+			if err == nil {
+				t.Errorf("FilterExpr failed to return an error.  Expr: %s", test.Expr)
+			}
+			continue
+		}
+		if names := recordNames(t, result); !reflect.DeepEqual(names, test.Expected) {
+			t.Errorf("FilterExpr result incorrect.  Expr: %s, Expected: %#v, Received: %#v", test.Expr, test.Expected, names)
+		}
+	}
+}
+
+func TestExprStepLimit(t *testing.T) {
+	saved := DefaultLimits
+	defer func() { DefaultLimits = saved }()
+	DefaultLimits.MaxSteps = 50
+
+	if _, err := MapExpr("x + 1", make([]int, 10)); err != nil {
+		t.Errorf("MapExpr encountered unexpected error within step limit: %s", err)
+	}
+	_, err := MapExpr("x + 1", make([]int, 20))
+	if !errors.Is(err, ErrLimitExceeded) || !strings.Contains(err.Error(), "limit of 50 steps") {
+		t.Errorf("MapExpr failed to enforce step limit.  Received: %v", err)
+	}
+	_, err = MapExpr(`Repeat(100, "a")`, make([]int, 1))
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("MapExpr failed to count function results against step limit.  Received: %v", err)
+	}
+}
+
+func TestExprDepthLimit(t *testing.T) {
+	nested := strings.Repeat("(", 10) + "x" + strings.Repeat(")", 10)
+	if _, err := MapExpr(nested, []int{1}); err != nil {
+		t.Errorf("MapExpr encountered unexpected error within depth limit: %s", err)
+	}
+
+	deep := []string{
+		strings.Repeat("(", 100000) + "x" + strings.Repeat(")", 100000),
+		strings.Repeat("-", 100000) + "x",
+		strings.Repeat("Abs(", 100000) + "x" + strings.Repeat(")", 100000),
+		"x" + strings.Repeat("[x", 100000) + strings.Repeat("]", 100000),
+	}
+	for _, expr := range deep {
+		_, err := MapExpr(expr, []int{1})
+		var argErr *ArgumentError
+		if !errors.Is(err, ErrLimitExceeded) || !errors.As(err, &argErr) || argErr.Func != "MapExpr" || argErr.Arg != 0 {
+			t.Errorf("MapExpr failed to enforce depth limit.  Received: %.100v", err)
+		}
+		if _, err := FilterExpr(expr, []int{1}); !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("FilterExpr failed to enforce depth limit.  Received: %.100v", err)
+		}
+	}
+}
+
+func TestExprFuncs(t *testing.T) {
+	FuncMap["Custom"] = strings.ToUpper
+	defer delete(FuncMap, "Custom")
+	if _, err := MapExpr("Custom(x)", []string{"a"}); err == nil {
+		t.Errorf("MapExpr failed to return an error for a function added to FuncMap")
+	}
+	for _, expr := range []string{`Query("$", x)`, `QueryOne("$", x)`, `FilterExpr("true", x)`} {
+		if _, err := MapExpr(expr, [][]int{{1}}); err == nil {
+			t.Errorf("MapExpr failed to return an error.  Expr: %s", expr)
+		}
+	}
+
+	// Nondeterministic functions are not callable, even when a NewFuncMap binds them to a source.
+	seeded := NewFuncMap(Options{Rand: rand.New(rand.NewSource(7))})["MapExpr"].(func(string, interface{}) ([]interface{}, error))
+	for _, expr := range []string{"Now()", "RandomHex(8)", "UUIDv4()", `Shuffle(List("a", "b"))`, `WeightedChoice(Dict("a", 1))`} {
+		if _, err := MapExpr(expr, []int{0}); err == nil {
+			t.Errorf("MapExpr failed to return an error.  Expr: %s", expr)
+		}
+		if _, err := seeded(expr, []int{0}); err == nil {
+			t.Errorf("NewFuncMap MapExpr failed to return an error.  Expr: %s", expr)
+		}
+	}
+}
+
+func TestExprFuncNames(t *testing.T) {
+	allowed := make(map[string]bool, len(exprFuncNames))
+	for _, name := range exprFuncNames {
+		if _, ok := FuncMap[name]; !ok {
+			t.Errorf("exprFuncNames lists a function missing from FuncMap.  Name: %s", name)
+		}
+		allowed[name] = true
+	}
+	excluded := make(map[string]bool)
+	for _, name := range []string{
+		"FilterExpr", "MapExpr", "Now", "Query", "QueryOne", "RandomAlphaNum", "RandomBytesBase64",
+		"RandomChoice", "RandomHex", "RandomInt", "RandomPassword", "Sample", "Shuffle", "UUIDv4",
+		"UUIDv7", "WeightedChoice",
+	} {
+		if allowed[name] {
+			t.Errorf("exprFuncNames lists a function that is not pure.  Name: %s", name)
+		}
+		if _, ok := FuncMap[name]; !ok {
+			t.Errorf("Excluded expression function is missing from FuncMap.  Name: %s", name)
+		}
+		excluded[name] = true
+	}
+	for name := range FuncMap {
+		if !allowed[name] && !excluded[name] {
+			t.Errorf("FuncMap function is neither allowed nor excluded in expressions.  Name: %s", name)
+		}
+	}
+	bound := exprFuncs(NewFuncMap(Options{}))
+	for name := range allowed {
+		if _, ok := bound[name]; !ok {
+			t.Errorf("exprFuncNames lists a function missing from NewFuncMap.  Name: %s", name)
+		}
+	}
+}
+
+func TestSum(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		Expected interface{}
+		Valid    bool
+	}{
+		{Operand: []int{1, 2, 3}, Expected: int64(6), Valid: true},
+		{Operand: []interface{}{1, 2.5}, Expected: 3.5, Valid: true},
+		{Operand: []float64{}, Expected: int64(0), Valid: true},
+		{Operand: []string{"a"}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Sum(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Sum encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Sum failed to return an error.  Operand: %#v", test.Operand)
+		}
+		if test.Valid && result != test.Expected {
+			t.Errorf("Sum result incorrect.  Operand: %#v, Expected: %#v, Received: %#v", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestExprTemplate(t *testing.T) {
+	data := map[string]interface{}{
+		"Items": decodeRecords(t, `[{"price": 2.5, "qty": 2}, {"price": 1, "qty": 3}]`),
+	}
+	tmpl := template.Must(template.New("test").Funcs(FuncMap).Parse(`{{ .Items | MapExpr "x.price * x.qty" | Sum }}`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("Template execution encountered unexpected error: %s", err)
+	}
+	if buf.String() != "8" {
+		t.Errorf("MapExpr template output incorrect.  Received: %s", buf.String())
+	}
+}
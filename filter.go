@@ -0,0 +1,264 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// predicate reports whether a single element matches a filter.
+type predicate func(elem interface{}) (bool, error)
+
+/*
+ * Filtering
+ *
+ * These functions select elements of a slice by comparing the value at a dotted path, as
+ * described under Records, against a value using one of the following operators:
+ *
+ *	==, !=          Equality.  Numbers compare by value regardless of type.
+ *	<, <=, >, >=    Ordering of numbers, strings, bools, or times.  Nil never matches.
+ *	in              The path's value equals an element of the slice value.
+ *	contains        The path's value is a string containing the string value, a slice with
+ *	                an element equal to value, or a map with value as a key.
+ *	matches         The path's value is a string matching the regexp value.
+ *	exists          The path's value is non-nil if value is true, or nil if value is false.
+ */
+
+// Where returns a copy of operand containing the elements whose value at path satisfies op and
+// value: {{ range Where "status" "==" "active" .Users }}...{{ end }}.
+func Where(path, op string, value interface{}, operand interface{}) (interface{}, error) {
+	return filterBy("Where", path, op, value, true, operand)
+}
+
+// Reject returns a copy of operand without the elements whose value at path satisfies op and
+// value.  It is the complement of Where.
+func Reject(path, op string, value interface{}, operand interface{}) (interface{}, error) {
+	return filterBy("Reject", path, op, value, false, operand)
+}
+
+// Find returns the first element of operand whose value at path satisfies op and value, or nil
+// if no element does.
+func Find(path, op string, value interface{}, operand interface{}) (interface{}, error) {
+	v, match, err := preparePredicate("Find", path, op, value, operand)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < v.Len(); i++ {
+		ok, err := match(v.Index(i).Interface())
+		if err != nil {
+			return nil, elementError("Find", i, err)
+		}
+		if ok {
+			return v.Index(i).Interface(), nil
+		}
+	}
+	return nil, nil
+}
+
+// Any checks if any element of operand has a value at path satisfying op and value.
+func Any(path, op string, value interface{}, operand interface{}) (bool, error) {
+	found, err := countMatches("Any", path, op, value, operand, true)
+	return found > 0, err
+}
+
+// All checks if every element of operand has a value at path satisfying op and value.  All is
+// true for an empty operand.
+func All(path, op string, value interface{}, operand interface{}) (bool, error) {
+	misses, err := countMatches("All", path, op, value, operand, false)
+	return misses == 0, err
+}
+
+// None checks if no element of operand has a value at path satisfying op and value.
+func None(path, op string, value interface{}, operand interface{}) (bool, error) {
+	found, err := countMatches("None", path, op, value, operand, true)
+	return found == 0, err
+}
+
+func filterBy(fn, path, op string, value interface{}, keep bool, operand interface{}) (interface{}, error) {
+	v, match, err := preparePredicate(fn, path, op, value, operand)
+	if err != nil {
+		return nil, err
+	}
+	filtered := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		ok, err := match(v.Index(i).Interface())
+		if err != nil {
+			return nil, elementError(fn, i, err)
+		}
+		if ok == keep {
+			filtered = reflect.Append(filtered, v.Index(i))
+		}
+	}
+	return filtered.Interface(), nil
+}
+
+// countMatches counts the elements whose match result equals want, stopping at the first.
+func countMatches(fn, path, op string, value interface{}, operand interface{}, want bool) (int, error) {
+	v, match, err := preparePredicate(fn, path, op, value, operand)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < v.Len(); i++ {
+		ok, err := match(v.Index(i).Interface())
+		if err != nil {
+			return 0, elementError(fn, i, err)
+		}
+		if ok == want {
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// preparePredicate checks the arguments of fn, one of the filtering functions, and returns its
+// operand along with a predicate matching its elements.
+func preparePredicate(fn, path, op string, value interface{}, operand interface{}) (reflect.Value, predicate, error) {
+	v, err := sliceValue(operand)
+	if err != nil {
+		return v, nil, wrapArgumentError(fn, 3, err)
+	}
+	test, err := newComparison(fn, op, value)
+	if err != nil {
+		return v, nil, err
+	}
+	return v, func(elem interface{}) (bool, error) {
+		field, err := resolvePath(path, elem)
+		if err != nil {
+			return false, err
+		}
+		return test(field)
+	}, nil
+}
+
+// newComparison returns a predicate testing a value against op and value, which are the
+// arguments of fn at indexes 1 and 2.
+func newComparison(fn, op string, value interface{}) (predicate, error) {
+	switch op {
+	case "==":
+		return func(field interface{}) (bool, error) { return equalValues(field, value), nil }, nil
+	case "!=":
+		return func(field interface{}) (bool, error) { return !equalValues(field, value), nil }, nil
+	case "<", "<=", ">", ">=":
+		return func(field interface{}) (bool, error) {
+			if field == nil || value == nil {
+				return false, nil
+			}
+			c, err := compareValues(field, value)
+			if err != nil {
+				return false, err
+			}
+			switch op {
+			case "<":
+				return c < 0, nil
+			case "<=":
+				return c <= 0, nil
+			case ">":
+				return c > 0, nil
+			}
+			return c >= 0, nil
+		}, nil
+	case "in":
+		set, err := sliceValue(value)
+		if err != nil {
+			return nil, argumentError(fn, 2, ErrInvalidArgument, "operator in requires a slice value: %s", err)
+		}
+		return func(field interface{}) (bool, error) { return sliceContains(set, field), nil }, nil
+	case "contains":
+		return func(field interface{}) (bool, error) { return containsValue(field, value) }, nil
+	case "matches":
+		pattern, ok := value.(string)
+		if !ok {
+			return nil, argumentError(fn, 2, ErrInvalidArgument, "operator matches requires a string pattern, received %s", TypeOf(value))
+		}
+		rex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, wrapArgumentError(fn, 2, err)
+		}
+		return func(field interface{}) (bool, error) {
+			if field == nil {
+				return false, nil
+			}
+			s, err := toString(field)
+			if err != nil {
+				return false, err
+			}
+			return rex.MatchString(s), nil
+		}, nil
+	case "exists":
+		want, ok := value.(bool)
+		if !ok {
+			return nil, argumentError(fn, 2, ErrInvalidArgument, "operator exists requires a bool value, received %s", TypeOf(value))
+		}
+		return func(field interface{}) (bool, error) { return (field != nil) == want, nil }, nil
+	}
+	return nil, argumentError(fn, 1, ErrInvalidArgument, "unknown operator %q: expected ==, !=, <, <=, >, >=, in, contains, matches, or exists", op)
+}
+
+// elementError reports err, returned for element i of the operand of fn, as an *ArgumentError
+// for the operand, which is the final argument of the filtering functions.
+func elementError(fn string, i int, err error) error {
+	return wrapArgumentError(fn, 3, fmt.Errorf("element %d: %w", i, err))
+}
+
+// equalValues compares a and b with compareValues where possible, so that numbers of different
+// types compare by value, and with reflect.DeepEqual otherwise.
+func equalValues(a, b interface{}) bool {
+	if c, err := compareValues(a, b); err == nil {
+		return c == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func sliceContains(set reflect.Value, value interface{}) bool {
+	for i := 0; i < set.Len(); i++ {
+		if equalValues(set.Index(i).Interface(), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsValue(container, value interface{}) (bool, error) {
+	v := reflect.ValueOf(container)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return false, nil
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot search string for %s", TypeOf(value))
+		}
+		return strings.Contains(v.String(), s), nil
+	case reflect.Slice, reflect.Array:
+		return sliceContains(v, value), nil
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if equalValues(key.Interface(), value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("cannot search %s for a value", TypeOf(container))
+}
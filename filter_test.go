@@ -0,0 +1,152 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func TestWhere(t *testing.T) {
+	users := decodeRecords(t, `[
+		{"name": "ann", "age": 31, "status": "active", "tags": ["admin", "ops"], "email": "ann@example.com"},
+		{"name": "bob", "age": 25, "status": "disabled", "tags": []},
+		{"name": "cid", "age": 40, "status": "active", "tags": ["ops"], "email": "cid@example.org"}
+	]`)
+	var tests = []struct {
+		Path     string
+		Op       string
+		Value    interface{}
+		Operand  interface{}
+		Expected []string
+		Valid    bool
+	}{
+		{Path: "status", Op: "==", Value: "active", Operand: users, Expected: []string{"ann", "cid"}, Valid: true},
+		{Path: "status", Op: "!=", Value: "active", Operand: users, Expected: []string{"bob"}, Valid: true},
+		{Path: "age", Op: "==", Value: 25, Operand: users, Expected: []string{"bob"}, Valid: true},
+		{Path: "age", Op: ">", Value: 30, Operand: users, Expected: []string{"ann", "cid"}, Valid: true},
+		{Path: "age", Op: "<=", Value: 31, Operand: users, Expected: []string{"ann", "bob"}, Valid: true},
+		{Path: "name", Op: "in", Value: []string{"bob", "cid", "dan"}, Operand: users, Expected: []string{"bob", "cid"}, Valid: true},
+		{Path: "tags", Op: "contains", Value: "ops", Operand: users, Expected: []string{"ann", "cid"}, Valid: true},
+		{Path: "email", Op: "contains", Value: ".org", Operand: users, Expected: []string{"cid"}, Valid: true},
+		{Path: "email", Op: "matches", Value: `@example\.com$`, Operand: users, Expected: []string{"ann"}, Valid: true},
+		{Path: "email", Op: "exists", Value: true, Operand: users, Expected: []string{"ann", "cid"}, Valid: true},
+		{Path: "email", Op: "exists", Value: false, Operand: users, Expected: []string{"bob"}, Valid: true},
+		{Path: "email", Op: "<", Value: "b", Operand: users, Expected: []string{"ann"}, Valid: true},
+		{Path: "Team", Op: "==", Value: "core", Operand: testRecords, Expected: []string{"api", "db"}, Valid: true},
+		{Path: "Owner.Email", Op: "exists", Value: true, Operand: testRecords, Expected: []string{"api", "db"}, Valid: true},
+		{Path: "age", Op: "~", Value: 1, Operand: users, Valid: false},
+		{Path: "age", Op: ">", Value: "old", Operand: users, Valid: false},
+		{Path: "name", Op: "in", Value: "bob", Operand: users, Valid: false},
+		{Path: "name", Op: "matches", Value: "(", Operand: users, Valid: false},
+		{Path: "name", Op: "exists", Value: "yes", Operand: users, Valid: false},
+		{Path: "Missing", Op: "==", Value: 1, Operand: testRecords, Valid: false},
+		{Path: "name", Op: "==", Value: "ann", Operand: "users", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Where(test.Path, test.Op, test.Value, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Where encountered unexpected error: %s.  Path: %s, Op: %s, Value: %#v", err, test.Path, test.Op, test.Value)
+		}
+		if !test.Valid {
+			if err == nil {
+				t.Errorf("Where failed to return an error.  Path: %s, Op: %s, Value: %#v", test.Path, test.Op, test.Value)
+			}
+			continue
+		}
+		if names := recordNames(t, result); !reflect.DeepEqual(names, test.Expected) {
+			t.Errorf("Where result incorrect.  Path: %s, Op: %s, Value: %#v, Expected: %#v, Received: %#v", test.Path, test.Op, test.Value, test.Expected, names)
+		}
+	}
+}
+
+func TestReject(t *testing.T) {
+	result, err := Reject("Team", "==", "core", testRecords)
+	if err != nil {
+		t.Fatalf("Reject encountered unexpected error: %s", err)
+	}
+	if names := recordNames(t, result); !reflect.DeepEqual(names, []string{"web"}) {
+		t.Errorf("Reject result incorrect.  Received: %#v", names)
+	}
+	if _, ok := result.([]testRecord); !ok {
+		t.Errorf("Reject result type incorrect.  Received: %T", result)
+	}
+}
+
+func TestFind(t *testing.T) {
+	result, err := Find("Score", "<", 8, testRecords)
+	if err != nil || result.(testRecord).Name != "api" {
+		t.Errorf("Find result incorrect.  Received: %#v, Error: %v", result, err)
+	}
+	result, err = Find("Score", ">", 100, testRecords)
+	if err != nil || result != nil {
+		t.Errorf("Find result incorrect.  Expected: nil, Received: %#v, Error: %v", result, err)
+	}
+}
+
+func TestAnyAllNone(t *testing.T) {
+	var tests = []struct {
+		Path    string
+		Op      string
+		Value   interface{}
+		Operand interface{}
+		Any     bool
+		All     bool
+		None    bool
+	}{
+		{Path: "Team", Op: "==", Value: "core", Operand: testRecords, Any: true, All: false, None: false},
+		{Path: "Score", Op: ">", Value: 5, Operand: testRecords, Any: true, All: true, None: false},
+		{Path: "Name", Op: "==", Value: "cache", Operand: testRecords, Any: false, All: false, None: true},
+		{Path: "Name", Op: "==", Value: "cache", Operand: []testRecord{}, Any: false, All: true, None: true},
+	}
+
+	for _, test := range tests {
+		anyResult, anyErr := Any(test.Path, test.Op, test.Value, test.Operand)
+		allResult, allErr := All(test.Path, test.Op, test.Value, test.Operand)
+		noneResult, noneErr := None(test.Path, test.Op, test.Value, test.Operand)
+		if anyErr != nil || allErr != nil || noneErr != nil {
+			t.Errorf("Any/All/None encountered unexpected error.  Path: %s, Op: %s, Value: %#v, Errors: %v, %v, %v", test.Path, test.Op, test.Value, anyErr, allErr, noneErr)
+		}
+		if anyResult != test.Any || allResult != test.All || noneResult != test.None {
+			t.Errorf("Any/All/None result incorrect.  Path: %s, Op: %s, Value: %#v, Expected: %t/%t/%t, Received: %t/%t/%t", test.Path, test.Op, test.Value, test.Any, test.All, test.None, anyResult, allResult, noneResult)
+		}
+	}
+
+	if _, err := All("Score", "<", "high", testRecords); err == nil {
+		t.Errorf("All failed to return an error for incomparable values")
+	}
+}
+
+func TestWhereTemplate(t *testing.T) {
+	users := decodeRecords(t, `[{"name": "ann", "age": 31}, {"name": "bob", "age": 25}]`)
+	tmpl := template.Must(template.New("test").Funcs(FuncMap).Parse(
+		`{{ range Where "age" ">=" 30 . }}{{ .name }}{{ end }}{{ if Any "name" "==" "bob" . }} has bob{{ end }}`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, users); err != nil {
+		t.Fatalf("Template execution encountered unexpected error: %s", err)
+	}
+	if buf.String() != "ann has bob" {
+		t.Errorf("Where template output incorrect.  Received: %s", buf.String())
+	}
+}
@@ -23,6 +23,7 @@ package haven
 import (
 	"bufio"
 	"encoding/base64"
+	"errors"
 	"math/rand"
 	"net/url"
 	"regexp"
@@ -37,65 +38,184 @@ var pseudo = rand.New(rand.NewSource(time.Now().UnixNano()))
 // FuncMap is a map of all functions exported by haven.  It is meant for use with
 // ext/template.Template.Funcs()
 var FuncMap = map[string]interface{}{
-	"Abs":          Abs,
-	"Add":          Add,
-	"Base64Decode": Base64Decode,
-	"Base64Encode": Base64Encode,
-	"CompileERE":   CompileERE,
-	"CompileRegex": CompileRegex,
-	"Contains":     Contains,
-	"ContainsAny":  ContainsAny,
-	"Count":        Count,
-	"Divide":       Divide,
-	"Fields":       Fields,
-	"Grep":         Grep,
-	"HasPrefix":    HasPrefix,
-	"HasSuffix":    HasSuffix,
-	"Head":         Head,
-	"Index":        Index,
-	"IndexAny":     IndexAny,
-	"Intersect":    Intersect,
-	"Join":         Join,
-	"LastIndex":    LastIndex,
-	"LastIndexAny": LastIndexAny,
-	"Lines":        Lines,
-	"Matches":      Matches,
-	"Max":          Max,
-	"Min":          Min,
-	"Modulo":       Modulo,
-	"Multiply":     Multiply,
-	"Now":          Now,
-	"ParseBool":    ParseBool,
-	"ParseFloat":   ParseFloat,
-	"ParseInt":     ParseInt,
-	"ParseTime":    ParseTime,
-	"ParseURL":     ParseURL,
-	"Quote":        Quote,
-	"QuoteRegex":   QuoteRegex,
-	"Repeat":       Repeat,
-	"Replace":      Replace,
-	"Reverse":      Reverse,
-	"Seq":          Seq,
-	"Shuffle":      Shuffle,
-	"Slice":        Slice,
-	"Sort":         Sort,
-	"Split":        Split,
-	"SplitAfter":   SplitAfter,
-	"SplitAfterN":  SplitAfterN,
-	"SplitN":       SplitN,
-	"Subtract":     Subtract,
-	"Tail":         Tail,
-	"Title":        Title,
-	"ToLower":      ToLower,
-	"ToUpper":      ToUpper,
-	"Trim":         Trim,
-	"TrimLeft":     TrimLeft,
-	"TrimPrefix":   TrimPrefix,
-	"TrimRight":    TrimRight,
-	"TrimSpace":    TrimSpace,
-	"TrimSuffix":   TrimSuffix,
-	"Union":        Union,
-	"Unquote":      Unquote,
+	"Abs":                 Abs,
+	"Add":                 Add,
+	"All":                 All,
+	"Any":                 Any,
+	"Append":              Append,
+	"Assert":              Assert,
+	"AssertMatches":       AssertMatches,
+	"AssertType":          AssertType,
+	"Base64Decode":        Base64Decode,
+	"Base64Encode":        Base64Encode,
+	"CIDRContains":        CIDRContains,
+	"CIDRHosts":           CIDRHosts,
+	"CIDRSubnet":          CIDRSubnet,
+	"CSVEscape":           CSVEscape,
+	"Chunk":               Chunk,
+	"Compact":             Compact,
+	"CompileERE":          CompileERE,
+	"CompileRegex":        CompileRegex,
+	"Concat":              Concat,
+	"Contains":            Contains,
+	"ContainsAny":         ContainsAny,
+	"Count":               Count,
+	"CountBy":             CountBy,
+	"DeepCopy":            DeepCopy,
+	"DeepEqual":           DeepEqual,
+	"DeepMerge":           DeepMerge,
+	"Dict":                Dict,
+	"Diff":                Diff,
+	"Difference":          Difference,
+	"Divide":              Divide,
+	"Entries":             Entries,
+	"Enumerate":           Enumerate,
+	"Equal":               Equal,
+	"Fail":                Fail,
+	"Fields":              Fields,
+	"FilterExpr":          FilterExpr,
+	"Find":                Find,
+	"Flatten":             Flatten,
+	"FormatFloat":         FormatFloat,
+	"FormatInt":           FormatInt,
+	"FormatNumber":        FormatNumber,
+	"Grep":                Grep,
+	"GroupBy":             GroupBy,
+	"HTMLEscape":          HTMLEscape,
+	"HTMLUnescape":        HTMLUnescape,
+	"HasPrefix":           HasPrefix,
+	"HasSuffix":           HasSuffix,
+	"HashBucket":          HashBucket,
+	"Head":                Head,
+	"HumanizeBytes":       HumanizeBytes,
+	"HumanizeBytesSI":     HumanizeBytesSI,
+	"HumanizeSI":          HumanizeSI,
+	"IPAdd":               IPAdd,
+	"IPCompare":           IPCompare,
+	"Index":               Index,
+	"IndexAny":            IndexAny,
+	"IndexBy":             IndexBy,
+	"Interleave":          Interleave,
+	"Intersect":           Intersect,
+	"IsIPv4":              IsIPv4,
+	"IsIPv6":              IsIPv6,
+	"IsMap":               IsMap,
+	"IsNumber":            IsNumber,
+	"IsSlice":             IsSlice,
+	"IsString":            IsString,
+	"IsSubset":            IsSubset,
+	"IsUUID":              IsUUID,
+	"JSONPatch":           JSONPatch,
+	"JSStringEscape":      JSStringEscape,
+	"Join":                Join,
+	"KindOf":              KindOf,
+	"LastIndex":           LastIndex,
+	"LastIndexAny":        LastIndexAny,
+	"Lines":               Lines,
+	"List":                List,
+	"MapExpr":             MapExpr,
+	"Matches":             Matches,
+	"Max":                 Max,
+	"MaxBy":               MaxBy,
+	"MergePatch":          MergePatch,
+	"Min":                 Min,
+	"MinBy":               MinBy,
+	"Modulo":              Modulo,
+	"Multiply":            Multiply,
+	"None":                None,
+	"Now":                 Now,
+	"Ordinal":             Ordinal,
+	"ParseBool":           ParseBool,
+	"ParseBytes":          ParseBytes,
+	"ParseCIDR":           ParseCIDR,
+	"ParseFloat":          ParseFloat,
+	"ParseIP":             ParseIP,
+	"ParseInt":            ParseInt,
+	"ParseInt64":          ParseInt64,
+	"ParseIntBase":        ParseIntBase,
+	"ParseTime":           ParseTime,
+	"ParseURL":            ParseURL,
+	"ParseUUID":           ParseUUID,
+	"ParseUint":           ParseUint,
+	"Partition":           Partition,
+	"Percent":             Percent,
+	"Prepend":             Prepend,
+	"Query":               Query,
+	"QueryOne":            QueryOne,
+	"Quote":               Quote,
+	"QuoteRegex":          QuoteRegex,
+	"RandomAlphaNum":      RandomAlphaNum,
+	"RandomBytesBase64":   RandomBytesBase64,
+	"RandomChoice":        RandomChoice,
+	"RandomHex":           RandomHex,
+	"RandomInt":           RandomInt,
+	"RandomPassword":      RandomPassword,
+	"Reject":              Reject,
+	"Repeat":              Repeat,
+	"Replace":             Replace,
+	"Reverse":             Reverse,
+	"Rotate":              Rotate,
+	"SQLQuoteIdent":       SQLQuoteIdent,
+	"SQLQuoteLiteral":     SQLQuoteLiteral,
+	"Sample":              Sample,
+	"SeededShuffle":       SeededShuffle,
+	"SemverBump":          SemverBump,
+	"SemverCompare":       SemverCompare,
+	"SemverParse":         SemverParse,
+	"SemverSatisfies":     SemverSatisfies,
+	"Seq":                 Seq,
+	"ShellQuote":          ShellQuote,
+	"Shuffle":             Shuffle,
+	"Slice":               Slice,
+	"Sort":                Sort,
+	"SortBy":              SortBy,
+	"SortByDesc":          SortByDesc,
+	"SortFold":            SortFold,
+	"SortFoldDesc":        SortFoldDesc,
+	"SortNatural":         SortNatural,
+	"SortNaturalDesc":     SortNaturalDesc,
+	"SortNumeric":         SortNumeric,
+	"SortNumericDesc":     SortNumericDesc,
+	"SortSemver":          SortSemver,
+	"SortStable":          SortStable,
+	"Split":               Split,
+	"SplitAfter":          SplitAfter,
+	"SplitAfterN":         SplitAfterN,
+	"SplitN":              SplitN,
+	"Subtract":            Subtract,
+	"Sum":                 Sum,
+	"SumBy":               SumBy,
+	"SymmetricDifference": SymmetricDifference,
+	"Tail":                Tail,
+	"Title":               Title,
+	"ToBool":              ToBool,
+	"ToFloat":             ToFloat,
+	"ToInt":               ToInt,
+	"ToInt64":             ToInt64,
+	"ToLower":             ToLower,
+	"ToString":            ToString,
+	"ToUpper":             ToUpper,
+	"Trim":                Trim,
+	"TrimLeft":            TrimLeft,
+	"TrimPrefix":          TrimPrefix,
+	"TrimRight":           TrimRight,
+	"TrimSpace":           TrimSpace,
+	"TrimSuffix":          TrimSuffix,
+	"TypeOf":              TypeOf,
+	"UUIDv4":              UUIDv4,
+	"UUIDv5":              UUIDv5,
+	"UUIDv7":              UUIDv7,
+	"Union":               Union,
+	"Uniq":                Uniq,
+	"UniqBy":              UniqBy,
+	"Unquote":             Unquote,
+	"Unzip":               Unzip,
+	"WeightedChoice":      WeightedChoice,
+	"Where":               Where,
+	"Window":              Window,
+	"XMLEscape":           XMLEscape,
+	"XMLUnescape":         XMLUnescape,
+	"Zip":                 Zip,
 }
 
 /*
@@ -143,15 +263,27 @@ func Lines(operand string) (lines []string, err error) {
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
 	}
-	err = scanner.Err()
+	err = wrapArgumentError("Lines", 0, scanner.Err())
 	return
 }
 
 // Quote uses strconv.Quote to return operand in quoted form.
 func Quote(operand string) string { return strconv.Quote(operand) }
 
-// Repeat uses strings.Repeat to return operand repeated count times.
-func Repeat(count int, operand string) string { return strings.Repeat(operand, count) }
+// Repeat uses strings.Repeat to return operand repeated count times.  A negative count, or a
+// count greater than DefaultLimits.MaxElements, returns an *ArgumentError, as does a result
+// longer than DefaultLimits.MaxElements bytes.
+func Repeat(count int, operand string) (string, error) {
+	switch {
+	case count < 0:
+		return "", argumentError("Repeat", 0, ErrInvalidArgument, "count %d is negative", count)
+	case count > DefaultLimits.MaxElements:
+		return "", argumentError("Repeat", 0, ErrLimitExceeded, "count %d exceeds the limit of %d", count, DefaultLimits.MaxElements)
+	case len(operand) > 0 && count > DefaultLimits.MaxElements/len(operand):
+		return "", argumentError("Repeat", 0, ErrLimitExceeded, "repeating %d bytes %d times exceeds the limit of %d bytes", len(operand), count, DefaultLimits.MaxElements)
+	}
+	return strings.Repeat(operand, count), nil
+}
 
 // Replace uses strings.Replace to replace the first n occurrences of old with new.
 func Replace(old, new string, n int, operand string) string {
@@ -204,18 +336,38 @@ func TrimSpace(operand string) string { return strings.TrimSpace(operand) }
 func TrimSuffix(suffix, operand string) string { return strings.TrimSuffix(operand, suffix) }
 
 // Unquote uses strconv.Unquote to the underlying, unquoted string value of operand.
-func Unquote(operand string) (unquoted string, err error) { return strconv.Unquote(operand) }
+func Unquote(operand string) (string, error) {
+	unquoted, err := strconv.Unquote(operand)
+	return unquoted, wrapArgumentError("Unquote", 0, err)
+}
 
 /*
  * Slice Manipulation
  */
 
+// Difference returns the elements of operand that are not present in a:
+// {{ .Hosts | Difference .Excluded }}.  Duplicate elements are removed, and the remaining
+// elements keep the order of their first occurrence in operand.
+func Difference(a, operand []string) []string {
+	inA := stringSet(a)
+	difference := make([]string, 0, len(operand))
+	for _, elem := range Uniq(operand) {
+		if !inA[elem] {
+			difference = append(difference, elem)
+		}
+	}
+	return difference
+}
+
+// Equal checks if a and operand contain the same elements, ignoring order and duplicates.
+func Equal(a, operand []string) bool { return IsSubset(a, operand) && IsSubset(operand, a) }
+
 // Grep filters operand according to pattern, returning a slice of matching elements.
 // Pattern is treated as a regexp.
 func Grep(pattern string, operand []string) ([]string, error) {
 	rex, err := regexp.Compile(pattern)
 	if err != nil {
-		return nil, err
+		return nil, wrapArgumentError("Grep", 0, err)
 	}
 	var matching []string
 	for _, elem := range operand {
@@ -227,32 +379,42 @@ func Grep(pattern string, operand []string) ([]string, error) {
 }
 
 // Head returns the first n elements of operand.  If less than n elements are in operand,
-// it returns all of operand.
-func Head(n int, operand []string) []string {
+// it returns all of operand.  A negative n returns an *ArgumentError.
+func Head(n int, operand []string) ([]string, error) {
+	if n < 0 {
+		return nil, argumentError("Head", 0, ErrInvalidArgument, "n %d is negative", n)
+	}
 	if len(operand) < n {
-		return operand
+		return operand, nil
 	}
-	return operand[:n]
+	return operand[:n], nil
 }
 
-// Intersect returns the intersection of a and operand.  Duplicate elements are removed.
-// Ordering is not preserved.
+// Intersect returns the elements of operand that are also present in a.  Duplicate elements
+// are removed, and the remaining elements keep the order of their first occurrence in operand.
 func Intersect(a, operand []string) []string {
-	intersection := make([]string, Max(len(a), len(operand)))
-	uniqA := make(map[string]bool, len(a))
-	for _, elem := range a {
-		uniqA[elem] = true
+	inA := stringSet(a)
+	intersection := make([]string, 0, Min(len(a), len(operand)))
+	seen := make(map[string]bool, len(operand))
+	for _, elem := range operand {
+		if inA[elem] && !seen[elem] {
+			intersection = append(intersection, elem)
+		}
+		seen[elem] = true
 	}
-	i := 0
-	uniqOp := make(map[string]bool, len(operand))
+	return intersection
+}
+
+// IsSubset checks if every element of operand is present in a:
+// {{ .Requested | IsSubset .Allowed }}.
+func IsSubset(a, operand []string) bool {
+	inA := stringSet(a)
 	for _, elem := range operand {
-		if uniqA[elem] && !uniqOp[elem] {
-			intersection[i] = elem
-			i++
+		if !inA[elem] {
+			return false
 		}
-		uniqOp[elem] = true
 	}
-	return intersection[:i]
+	return true
 }
 
 // Reverse returns a copy of operand with the elements in reverse order.
@@ -267,36 +429,42 @@ func Reverse(operand []string) []string {
 // Seq generates a sequence of ints from first to last.  If incr is specified
 // (an optional third argument), then the sequence will increment by incr.
 // Otherwise, incr defaults to 1.  Incr may be negative to generate a sequence
-// of descending ints.
-func Seq(first, last int, incr ...int) []int {
+// of descending ints.  A zero incr, more than one incr, or a sequence longer
+// than DefaultLimits.MaxElements returns an *ArgumentError.
+func Seq(first, last int, incr ...int) ([]int, error) {
 	j := 1
 	if len(incr) > 1 {
-		panic("Seq incr cannot be more than one value in length")
+		return nil, argumentError("Seq", 3, ErrInvalidArgument, "only one incr may be given, received %d", len(incr))
 	}
 	if len(incr) == 1 {
-		if (incr[0]) == 0 {
-			panic("Seq incr value cannot be zero")
+		if incr[0] == 0 {
+			return nil, argumentError("Seq", 2, ErrInvalidArgument, "incr cannot be zero")
 		}
 		j = incr[0]
 	}
+	if j > 0 && first > last || j < 0 && first < last {
+		return nil, nil
+	}
 
-	var values []int
-	current := first
+	// Count the values in unsigned arithmetic, which cannot overflow for any pair of ints.
+	var span, step uint64
 	if j > 0 {
-		for current <= last {
-			values = append(values, current)
-			current += j
-		}
+		span, step = uint64(last)-uint64(first), uint64(j)
 	} else {
-		for current >= last {
-			values = append(values, current)
-			current += j
-		}
+		span, step = uint64(first)-uint64(last), -uint64(j)
+	}
+	if span/step >= uint64(DefaultLimits.MaxElements) {
+		return nil, argumentError("Seq", 1, ErrLimitExceeded, "sequence exceeds the limit of %d values", DefaultLimits.MaxElements)
 	}
-	return values
+	values := make([]int, span/step+1)
+	for i := range values {
+		values[i] = first + i*j
+	}
+	return values, nil
 }
 
-// Shuffle returns a copy of operand with the elements shuffled pseudo-randomly.
+// Shuffle returns a copy of operand with the elements shuffled pseudo-randomly.  The order differs
+// on every run; use SeededShuffle for a reproducible order.
 func Shuffle(operand []string) []string {
 	shuffled := make([]string, len(operand))
 	for i, p := range pseudo.Perm(len(operand)) {
@@ -305,8 +473,17 @@ func Shuffle(operand []string) []string {
 	return shuffled
 }
 
-// Slice returns operand[first:last].
-func Slice(first, last int, operand []string) []string { return operand[first:last] }
+// Slice returns operand[first:last].  Bounds outside of operand, or a last bound before
+// first, return an *ArgumentError.
+func Slice(first, last int, operand []string) ([]string, error) {
+	if first < 0 || first > len(operand) {
+		return nil, argumentError("Slice", 0, ErrOutOfRange, "index %d is out of range for length %d", first, len(operand))
+	}
+	if last < first || last > len(operand) {
+		return nil, argumentError("Slice", 1, ErrOutOfRange, "index %d is out of range [%d:%d]", last, first, len(operand))
+	}
+	return operand[first:last], nil
+}
 
 // Sort returns a copy of operand sorted by sort.Strings.
 func Sort(operand []string) []string {
@@ -318,32 +495,51 @@ func Sort(operand []string) []string {
 	return sorted
 }
 
+// SymmetricDifference returns the elements of a that are not present in operand, followed by
+// the elements of operand that are not present in a.  Duplicate elements are removed, and the
+// remaining elements keep the order of their first occurrence.
+func SymmetricDifference(a, operand []string) []string {
+	return append(Difference(operand, a), Difference(a, operand)...)
+}
+
 // Tail returns the last n elements of operand.  If less than n elements are in operand,
-// it returns all of operand.
-func Tail(n int, operand []string) []string {
+// it returns all of operand.  A negative n returns an *ArgumentError.
+func Tail(n int, operand []string) ([]string, error) {
+	if n < 0 {
+		return nil, argumentError("Tail", 0, ErrInvalidArgument, "n %d is negative", n)
+	}
 	if len(operand) < n {
-		return operand
+		return operand, nil
 	}
-	return operand[len(operand)-n:]
+	return operand[len(operand)-n:], nil
 }
 
-// Union returns the union of a and operand.  Duplicate elements are removed.
-// Ordering is not preserved.
+// Union returns the elements of a followed by the elements of operand.  Duplicate elements are
+// removed, and the remaining elements keep the order of their first occurrence.
 func Union(a, operand []string) []string {
-	uniq := make(map[string]bool, len(a)+len(operand))
-	for _, elem := range a {
-		uniq[elem] = true
-	}
+	return Uniq(append(append(make([]string, 0, len(a)+len(operand)), a...), operand...))
+}
+
+// Uniq returns a copy of operand with duplicate elements removed.  The remaining elements keep
+// the order of their first occurrence.
+func Uniq(operand []string) []string {
+	uniq := make([]string, 0, len(operand))
+	seen := make(map[string]bool, len(operand))
 	for _, elem := range operand {
-		uniq[elem] = true
+		if !seen[elem] {
+			uniq = append(uniq, elem)
+			seen[elem] = true
+		}
 	}
-	union := make([]string, len(uniq))
-	i := 0
-	for k := range uniq {
-		union[i] = k
-		i++
+	return uniq
+}
+
+func stringSet(elems []string) map[string]bool {
+	set := make(map[string]bool, len(elems))
+	for _, elem := range elems {
+		set[elem] = true
 	}
-	return union[:i]
+	return set
 }
 
 /*
@@ -354,7 +550,10 @@ func Union(a, operand []string) []string {
 func Now() time.Time { return time.Now() }
 
 // ParseTime uses time.Parse to return a time.Time instance of operand parsed according to format.
-func ParseTime(format, operand string) (time.Time, error) { return time.Parse(format, operand) }
+func ParseTime(format, operand string) (time.Time, error) {
+	t, err := time.Parse(format, operand)
+	return t, wrapArgumentError("ParseTime", 1, err)
+}
 
 /*
  * Regular Expressions
@@ -362,15 +561,22 @@ func ParseTime(format, operand string) (time.Time, error) { return time.Parse(fo
 
 // Matches use regexp.MatchString to check if operand matches pattern.
 func Matches(pattern string, operand string) (bool, error) {
-	return regexp.MatchString(pattern, operand)
+	matched, err := regexp.MatchString(pattern, operand)
+	return matched, wrapArgumentError("Matches", 0, err)
 }
 
 // CompileRegex uses regexp.Compile to compile a new *regexp.Regexp according to pattern.
-func CompileRegex(pattern string) (*regexp.Regexp, error) { return regexp.Compile(pattern) }
+func CompileRegex(pattern string) (*regexp.Regexp, error) {
+	rex, err := regexp.Compile(pattern)
+	return rex, wrapArgumentError("CompileRegex", 0, err)
+}
 
 // CompileERE uses regexp.CompilePOSIX to compile a new *regexp.Regexp according to pattern.
 // Uses POSIX extended regexp behavior.
-func CompileERE(pattern string) (*regexp.Regexp, error) { return regexp.CompilePOSIX(pattern) }
+func CompileERE(pattern string) (*regexp.Regexp, error) {
+	rex, err := regexp.CompilePOSIX(pattern)
+	return rex, wrapArgumentError("CompileERE", 0, err)
+}
 
 // QuoteRegex uses regexp.QuoteMeta to returnsoperand with all regex metachars escaped.
 func QuoteRegex(operand string) string { return regexp.QuoteMeta(operand) }
@@ -385,23 +591,99 @@ func Base64Encode(operand string) string { return base64.StdEncoding.EncodeToStr
 // Base64Decode uses base64.StdEncoding to decode operand.
 func Base64Decode(operand string) (string, error) {
 	bytes, err := base64.StdEncoding.DecodeString(operand)
-	return string(bytes), err
+	return string(bytes), wrapArgumentError("Base64Decode", 0, err)
 }
 
 // ParseBool uses strconv.ParseBool to parse operand as a bool.
-func ParseBool(operand string) (value bool, err error) { return strconv.ParseBool(operand) }
+func ParseBool(operand string) (bool, error) {
+	value, err := strconv.ParseBool(operand)
+	return value, wrapArgumentError("ParseBool", 0, err)
+}
 
 // ParseInt uses strconv.ParseInt with base == 0 (auto) and bitsize = 32 to parse operand as an int.
-func ParseInt(operand string) (value int, err error) {
-	i64, err := strconv.ParseInt(operand, 0, 32)
+// See ParseInt64, ParseIntBase, and ParseUint for larger values and explicit bases.
+func ParseInt(operand string) (int, error) {
+	i64, err := parseInt("ParseInt", 0, 0, 32, operand)
 	return int(i64), err
 }
 
+// ParseIntBase uses strconv.ParseInt to parse operand as a signed integer in the given base
+// with the given bitsize.  A base of 0 infers the base from the string prefix ("0x", "0o",
+// "0b", or "0"), and a bitsize of 0 uses the size of int.  Values that don't fit in bitsize
+// return an error describing the valid range.
+func ParseIntBase(base, bitSize int, operand string) (int64, error) {
+	if err := checkIntBase("ParseIntBase", base, bitSize); err != nil {
+		return 0, err
+	}
+	return parseInt("ParseIntBase", 2, base, bitSize, operand)
+}
+
+// ParseInt64 uses strconv.ParseInt with base == 0 (auto) and bitsize == 64 to parse operand as an int64.
+func ParseInt64(operand string) (int64, error) { return parseInt("ParseInt64", 0, 0, 64, operand) }
+
+// parseInt implements ParseInt, ParseIntBase, and ParseInt64, which are reported as fn with
+// operand at index arg in errors.
+func parseInt(fn string, arg, base, bitSize int, operand string) (int64, error) {
+	i64, err := strconv.ParseInt(operand, base, bitSize)
+	if isRangeError(err) {
+		bits := effectiveBitSize(bitSize)
+		max := int64(1)<<uint(bits-1) - 1
+		return 0, argumentError(fn, arg, ErrOutOfRange, "parsing %q: value out of range for %d-bit signed integer [%d, %d]", operand, bits, -max-1, max)
+	}
+	return i64, wrapArgumentError(fn, arg, err)
+}
+
+// ParseUint uses strconv.ParseUint to parse operand as an unsigned integer in the given base
+// with the given bitsize.  Base and bitsize are interpreted as with ParseIntBase.  Values that
+// don't fit in bitsize return an error describing the valid range.
+func ParseUint(base, bitSize int, operand string) (uint64, error) {
+	if err := checkIntBase("ParseUint", base, bitSize); err != nil {
+		return 0, err
+	}
+	u64, err := strconv.ParseUint(operand, base, bitSize)
+	if isRangeError(err) {
+		bits := effectiveBitSize(bitSize)
+		max := uint64(1)<<uint(bits) - 1
+		return 0, argumentError("ParseUint", 2, ErrOutOfRange, "parsing %q: value out of range for %d-bit unsigned integer [0, %d]", operand, bits, max)
+	}
+	return u64, wrapArgumentError("ParseUint", 2, err)
+}
+
+// checkIntBase checks the base and bitSize arguments of fn, which strconv reports only as
+// syntax errors.
+func checkIntBase(fn string, base, bitSize int) error {
+	if base != 0 && (base < 2 || base > 36) {
+		return argumentError(fn, 0, ErrOutOfRange, "base %d is not 0 or between 2 and 36", base)
+	}
+	if bitSize < 0 || bitSize > 64 {
+		return argumentError(fn, 1, ErrOutOfRange, "bit size %d is not between 0 and 64", bitSize)
+	}
+	return nil
+}
+
+func isRangeError(err error) bool {
+	var numErr *strconv.NumError
+	return errors.As(err, &numErr) && numErr.Err == strconv.ErrRange
+}
+
+func effectiveBitSize(bitSize int) int {
+	if bitSize == 0 {
+		return strconv.IntSize
+	}
+	return bitSize
+}
+
 // ParseFloat uses strconv.ParseFloat with bitsize == 64 to parse operand as a float.
-func ParseFloat(operand string) (f float64, err error) { return strconv.ParseFloat(operand, 64) }
+func ParseFloat(operand string) (float64, error) {
+	f, err := strconv.ParseFloat(operand, 64)
+	return f, wrapArgumentError("ParseFloat", 0, err)
+}
 
 // ParseURL uses url.Parse to parse operand as a url.
-func ParseURL(operand string) (url *url.URL, err error) { return url.Parse(operand) }
+func ParseURL(operand string) (*url.URL, error) {
+	u, err := url.Parse(operand)
+	return u, wrapArgumentError("ParseURL", 0, err)
+}
 
 /*
  * Math
@@ -421,11 +703,21 @@ func Add(a, operand int) int { return operand + a }
 // Subtract a from operand.
 func Subtract(a, operand int) int { return operand - a }
 
-// Divide operand by a.
-func Divide(a, operand int) int { return operand / a }
+// Divide operand by a.  Dividing by zero returns an *ArgumentError.
+func Divide(a, operand int) (int, error) {
+	if a == 0 {
+		return 0, argumentError("Divide", 0, ErrInvalidArgument, "division by zero")
+	}
+	return operand / a, nil
+}
 
-// Modulo returns operand modulo a.
-func Modulo(a, operand int) int { return operand % a }
+// Modulo returns operand modulo a.  A zero modulus returns an *ArgumentError.
+func Modulo(a, operand int) (int, error) {
+	if a == 0 {
+		return 0, argumentError("Modulo", 0, ErrInvalidArgument, "modulus is zero")
+	}
+	return operand % a, nil
+}
 
 // Multiply operand and a.
 func Multiply(a, operand int) int { return operand * a }
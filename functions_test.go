@@ -21,10 +21,18 @@
 package haven
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 )
 
+// maxInt and minInt stand in for math.MaxInt and math.MinInt, which need Go 1.17.
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
 /*
  * Functions we implement -- these should be tested thoroughly
  */
@@ -49,6 +57,47 @@ func TestAbs(t *testing.T) {
 	}
 }
 
+func TestDifference(t *testing.T) {
+	var tests = []struct {
+		Operand  []string
+		A        []string
+		Expected []string
+	}{
+		{Operand: []string{"web-1", "web-2", "db-1", "web-3"}, A: []string{"db-1", "web-2"}, Expected: []string{"web-1", "web-3"}},
+		{Operand: []string{"b", "a", "b", "c"}, A: []string{"c"}, Expected: []string{"b", "a"}},
+		{Operand: []string{"dog"}, A: []string{"dog"}, Expected: []string{}},
+		{Operand: []string{"dog"}, A: []string(nil), Expected: []string{"dog"}},
+	}
+
+	for _, test := range tests {
+		result := Difference(test.A, test.Operand)
+		if !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Difference result incorrect.  Operand: %#v, A: %#v, Expected: %#v, Received: %#v", test.Operand, test.A, test.Expected, result)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	var tests = []struct {
+		Operand  []string
+		A        []string
+		Expected bool
+	}{
+		{Operand: []string{"dog", "cat"}, A: []string{"cat", "dog"}, Expected: true},
+		{Operand: []string{"dog", "cat", "dog"}, A: []string{"cat", "dog"}, Expected: true},
+		{Operand: []string{"dog", "cat"}, A: []string{"cat"}, Expected: false},
+		{Operand: []string{"dog"}, A: []string{"cat", "dog"}, Expected: false},
+		{Operand: []string(nil), A: []string{}, Expected: true},
+	}
+
+	for _, test := range tests {
+		result := Equal(test.A, test.Operand)
+		if result != test.Expected {
+			t.Errorf("Equal result incorrect.  Operand: %#v, A: %#v, Expected: %t, Received: %t", test.Operand, test.A, test.Expected, result)
+		}
+	}
+}
+
 func TestGrep(t *testing.T) {
 	var tests = []struct {
 		Operand  []string
@@ -81,10 +130,21 @@ func TestHead(t *testing.T) {
 	}{
 		{Operand: []string{"dog", "cat", "horse"}, N: 2, Expected: []string{"dog", "cat"}},
 		{Operand: []string{"dog", "cat", "horse"}, N: 30, Expected: []string{"dog", "cat", "horse"}},
+		{Operand: []string{"dog", "cat", "horse"}, N: 0, Expected: []string{}},
+		{Operand: []string{"dog", "cat", "horse"}, N: -1, Expected: nil},
 	}
 
 	for _, test := range tests {
-		result := Head(test.N, test.Operand)
+		result, err := Head(test.N, test.Operand)
+		if test.N < 0 {
+			if !errors.Is(err, ErrInvalidArgument) {
+				t.Errorf("Head failed to return ErrInvalidArgument.  N: %d, Received: %v", test.N, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Head encountered unexpected error: %s.  N: %d", err, test.N)
+		}
 		if !reflect.DeepEqual(result, test.Expected) {
 			t.Errorf("Head result incorrect.  Operand: %#v, N: %d, Expected: %#v, Received: %#v", test.Operand, test.N, test.Expected, result)
 		}
@@ -99,6 +159,7 @@ func TestIntersect(t *testing.T) {
 	}{
 		{Operand: []string{"dog", "cat", "horse"}, A: []string{"horse", "dog"}, Expected: []string{"dog", "horse"}},
 		{Operand: []string{"dog", "dog", "cat", "horse"}, A: []string{"horse", "dog"}, Expected: []string{"dog", "horse"}},
+		{Operand: []string{"horse", "cat", "dog", "horse"}, A: []string{"dog", "horse"}, Expected: []string{"horse", "dog"}},
 		{Operand: []string{"dog"}, A: []string{"horse"}, Expected: []string{}},
 		{Operand: []string{"dog"}, A: []string(nil), Expected: []string{}},
 		{Operand: []string(nil), A: []string{"dog"}, Expected: []string{}},
@@ -106,20 +167,29 @@ func TestIntersect(t *testing.T) {
 
 	for _, test := range tests {
 		result := Intersect(test.A, test.Operand)
-		if len(result) != len(test.Expected) {
+		if !reflect.DeepEqual(result, test.Expected) {
 			t.Errorf("Intersect result incorrect.  Operand: %#v, A: %#v, Expected: %#v, Received: %#v", test.Operand, test.A, test.Expected, result)
 		}
-		for _, expectedElem := range test.Expected {
-			foundElem := false
-			for _, resultElem := range result {
-				if resultElem == expectedElem {
-					foundElem = true
-					break
-				}
-			}
-			if !foundElem {
-				t.Errorf("Intersect result incorrect.  Operand: %#v, A: %#v, Expected: %#v, Received: %#v", test.Operand, test.A, test.Expected, result)
-			}
+	}
+}
+
+func TestIsSubset(t *testing.T) {
+	var tests = []struct {
+		Operand  []string
+		A        []string
+		Expected bool
+	}{
+		{Operand: []string{"dog", "cat"}, A: []string{"cat", "horse", "dog"}, Expected: true},
+		{Operand: []string{"dog", "dog"}, A: []string{"dog"}, Expected: true},
+		{Operand: []string{"dog", "cow"}, A: []string{"cat", "horse", "dog"}, Expected: false},
+		{Operand: []string{}, A: []string(nil), Expected: true},
+		{Operand: []string{"dog"}, A: []string(nil), Expected: false},
+	}
+
+	for _, test := range tests {
+		result := IsSubset(test.A, test.Operand)
+		if result != test.Expected {
+			t.Errorf("IsSubset result incorrect.  Operand: %#v, A: %#v, Expected: %t, Received: %t", test.Operand, test.A, test.Expected, result)
 		}
 	}
 }
@@ -209,6 +279,67 @@ func TestMin(t *testing.T) {
 
 }
 
+func TestParseIntBase(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Base     int
+		BitSize  int
+		Expected int64
+		Error    string
+	}{
+		{Operand: "42", Base: 10, BitSize: 32, Expected: 42},
+		{Operand: "ff", Base: 16, BitSize: 32, Expected: 255},
+		{Operand: "0xff", Base: 0, BitSize: 32, Expected: 255},
+		{Operand: "-101", Base: 2, BitSize: 8, Expected: -5},
+		{Operand: "4294967296", Base: 10, BitSize: 64, Expected: 4294967296},
+		{Operand: "128", Base: 10, BitSize: 8, Error: `ParseIntBase: argument 2: parsing "128": value out of range for 8-bit signed integer [-128, 127]`},
+		{Operand: "9223372036854775808", Base: 10, BitSize: 64, Error: `ParseIntBase: argument 2: parsing "9223372036854775808": value out of range for 64-bit signed integer [-9223372036854775808, 9223372036854775807]`},
+		{Operand: "fg", Base: 16, BitSize: 32, Error: `ParseIntBase: argument 2: strconv.ParseInt: parsing "fg": invalid syntax`},
+	}
+
+	for _, test := range tests {
+		result, err := ParseIntBase(test.Base, test.BitSize, test.Operand)
+		if test.Error == "" && err != nil {
+			t.Errorf("ParseIntBase encountered unexpected error: %s.  Operand: %s, Base: %d, BitSize: %d", err, test.Operand, test.Base, test.BitSize)
+		}
+		if test.Error != "" && (err == nil || err.Error() != test.Error) {
+			t.Errorf("ParseIntBase error incorrect.  Operand: %s, Base: %d, BitSize: %d, Expected: %s, Received: %v", test.Operand, test.Base, test.BitSize, test.Error, err)
+		}
+		if result != test.Expected {
+			t.Errorf("ParseIntBase result incorrect.  Operand: %s, Base: %d, BitSize: %d, Expected: %d, Received: %d", test.Operand, test.Base, test.BitSize, test.Expected, result)
+		}
+	}
+}
+
+func TestParseUint(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Base     int
+		BitSize  int
+		Expected uint64
+		Error    string
+	}{
+		{Operand: "255", Base: 10, BitSize: 8, Expected: 255},
+		{Operand: "deadbeef", Base: 16, BitSize: 32, Expected: 0xdeadbeef},
+		{Operand: "18446744073709551615", Base: 10, BitSize: 64, Expected: 18446744073709551615},
+		{Operand: "256", Base: 10, BitSize: 8, Error: `ParseUint: argument 2: parsing "256": value out of range for 8-bit unsigned integer [0, 255]`},
+		{Operand: "-1", Base: 10, BitSize: 8, Error: `ParseUint: argument 2: strconv.ParseUint: parsing "-1": invalid syntax`},
+	}
+
+	for _, test := range tests {
+		result, err := ParseUint(test.Base, test.BitSize, test.Operand)
+		if test.Error == "" && err != nil {
+			t.Errorf("ParseUint encountered unexpected error: %s.  Operand: %s, Base: %d, BitSize: %d", err, test.Operand, test.Base, test.BitSize)
+		}
+		if test.Error != "" && (err == nil || err.Error() != test.Error) {
+			t.Errorf("ParseUint error incorrect.  Operand: %s, Base: %d, BitSize: %d, Expected: %s, Received: %v", test.Operand, test.Base, test.BitSize, test.Error, err)
+		}
+		if result != test.Expected {
+			t.Errorf("ParseUint result incorrect.  Operand: %s, Base: %d, BitSize: %d, Expected: %d, Received: %d", test.Operand, test.Base, test.BitSize, test.Expected, result)
+		}
+	}
+}
+
 func TestReverse(t *testing.T) {
 	var tests = []struct {
 		Operand  []string
@@ -241,12 +372,42 @@ func TestSeq(t *testing.T) {
 		{First: -1, Last: 3, Expected: []int{-1, 0, 1, 2, 3}},
 		{First: 4, Last: 0, Expected: nil},
 		{First: 4, Last: 0, Incr: []int{-1}, Expected: []int{4, 3, 2, 1, 0}},
+		{First: 4, Last: -5, Incr: []int{-4}, Expected: []int{4, 0, -4}},
+		{First: maxInt - 2, Last: maxInt, Expected: []int{maxInt - 2, maxInt - 1, maxInt}},
+		{First: minInt, Last: minInt + 1, Incr: []int{2}, Expected: []int{minInt}},
 	}
 
 	for _, test := range tests {
-		result := Seq(test.First, test.Last, test.Incr...)
+		result, err := Seq(test.First, test.Last, test.Incr...)
+		if err != nil {
+			t.Errorf("Seq encountered unexpected error: %s.  First: %d, Last: %d, Incr: %#v", err, test.First, test.Last, test.Incr)
+		}
 		if !reflect.DeepEqual(result, test.Expected) {
-			t.Errorf("Reverse result incorrect.  First: %d, Last: %d, Incr: %#v, Expected: %#v, Received: %#v", test.First, test.Last, test.Incr, test.Expected, result)
+			t.Errorf("Seq result incorrect.  First: %d, Last: %d, Incr: %#v, Expected: %#v, Received: %#v", test.First, test.Last, test.Incr, test.Expected, result)
+		}
+	}
+}
+
+func TestSeqErrors(t *testing.T) {
+	var tests = []struct {
+		First int
+		Last  int
+		Incr  []int
+		Arg   int
+		Class error
+	}{
+		{First: 0, Last: 5, Incr: []int{0}, Arg: 2, Class: ErrInvalidArgument},
+		{First: 0, Last: 5, Incr: []int{1, 2}, Arg: 3, Class: ErrInvalidArgument},
+		{First: 1, Last: 1000000, Arg: 1, Class: ErrLimitExceeded},
+		{First: minInt, Last: maxInt, Arg: 1, Class: ErrLimitExceeded},
+		{First: maxInt, Last: minInt, Incr: []int{-1}, Arg: 1, Class: ErrLimitExceeded},
+	}
+
+	for _, test := range tests {
+		_, err := Seq(test.First, test.Last, test.Incr...)
+		var argErr *ArgumentError
+		if !errors.As(err, &argErr) || argErr.Func != "Seq" || argErr.Arg != test.Arg || !errors.Is(err, test.Class) {
+			t.Errorf("Seq error incorrect.  First: %d, Last: %d, Incr: %#v, Expected: argument %d %s, Received: %#v", test.First, test.Last, test.Incr, test.Arg, test.Class, err)
 		}
 	}
 }
@@ -294,6 +455,25 @@ func TestSort(t *testing.T) {
 	}
 }
 
+func TestSymmetricDifference(t *testing.T) {
+	var tests = []struct {
+		Operand  []string
+		A        []string
+		Expected []string
+	}{
+		{Operand: []string{"cat", "cow", "dog"}, A: []string{"horse", "dog", "pig"}, Expected: []string{"horse", "pig", "cat", "cow"}},
+		{Operand: []string{"dog", "dog"}, A: []string{"cat", "cat"}, Expected: []string{"cat", "dog"}},
+		{Operand: []string{"dog"}, A: []string{"dog"}, Expected: []string{}},
+	}
+
+	for _, test := range tests {
+		result := SymmetricDifference(test.A, test.Operand)
+		if !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("SymmetricDifference result incorrect.  Operand: %#v, A: %#v, Expected: %#v, Received: %#v", test.Operand, test.A, test.Expected, result)
+		}
+	}
+}
+
 func TestTail(t *testing.T) {
 	var tests = []struct {
 		Operand  []string
@@ -302,10 +482,21 @@ func TestTail(t *testing.T) {
 	}{
 		{Operand: []string{"dog", "cat", "horse"}, N: 2, Expected: []string{"cat", "horse"}},
 		{Operand: []string{"dog", "cat", "horse"}, N: 30, Expected: []string{"dog", "cat", "horse"}},
+		{Operand: []string{"dog", "cat", "horse"}, N: 0, Expected: []string{}},
+		{Operand: []string{"dog", "cat", "horse"}, N: -1, Expected: nil},
 	}
 
 	for _, test := range tests {
-		result := Tail(test.N, test.Operand)
+		result, err := Tail(test.N, test.Operand)
+		if test.N < 0 {
+			if !errors.Is(err, ErrInvalidArgument) {
+				t.Errorf("Tail failed to return ErrInvalidArgument.  N: %d, Received: %v", test.N, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Tail encountered unexpected error: %s.  N: %d", err, test.N)
+		}
 		if !reflect.DeepEqual(result, test.Expected) {
 			t.Errorf("Tail result incorrect.  Operand: %#v, N: %d, Expected: %#v, Received: %#v", test.Operand, test.N, test.Expected, result)
 		}
@@ -318,9 +509,9 @@ func TestUnion(t *testing.T) {
 		A        []string
 		Expected []string
 	}{
-		{Operand: []string{"dog", "cat"}, A: []string{"horse", "dog"}, Expected: []string{"cat", "dog", "horse"}},
-		{Operand: []string{"dog"}, A: []string{"horse"}, Expected: []string{"dog", "horse"}},
-		{Operand: []string{"dog", "dog", "cat"}, A: []string{"horse"}, Expected: []string{"cat", "dog", "horse"}},
+		{Operand: []string{"dog", "cat"}, A: []string{"horse", "dog"}, Expected: []string{"horse", "dog", "cat"}},
+		{Operand: []string{"dog"}, A: []string{"horse"}, Expected: []string{"horse", "dog"}},
+		{Operand: []string{"dog", "dog", "cat"}, A: []string{"horse"}, Expected: []string{"horse", "dog", "cat"}},
 		{Operand: []string{"dog"}, A: []string{}, Expected: []string{"dog"}},
 		{Operand: []string{"dog"}, A: []string(nil), Expected: []string{"dog"}},
 		{Operand: []string{}, A: []string{"dog"}, Expected: []string{"dog"}},
@@ -330,20 +521,33 @@ func TestUnion(t *testing.T) {
 
 	for _, test := range tests {
 		result := Union(test.A, test.Operand)
-		if len(result) != len(test.Expected) {
+		if !reflect.DeepEqual(result, test.Expected) {
 			t.Errorf("Union result incorrect.  Operand: %#v, A: %#v, Expected: %#v, Received: %#v", test.Operand, test.A, test.Expected, result)
 		}
-		for _, expectedElem := range test.Expected {
-			foundElem := false
-			for _, resultElem := range result {
-				if resultElem == expectedElem {
-					foundElem = true
-					break
-				}
-			}
-			if !foundElem {
-				t.Errorf("Union result incorrect.  Operand: %#v, A: %#v, Expected: %#v, Received: %#v", test.Operand, test.A, test.Expected, result)
-			}
+	}
+
+	for i := 0; i < 20; i++ {
+		result := Union([]string{"e", "d", "c"}, []string{"b", "a", "e"})
+		if !reflect.DeepEqual(result, []string{"e", "d", "c", "b", "a"}) {
+			t.Fatalf("Union result not deterministic.  Received: %#v", result)
+		}
+	}
+}
+
+func TestUniq(t *testing.T) {
+	var tests = []struct {
+		Operand  []string
+		Expected []string
+	}{
+		{Operand: []string{"dog", "cat", "dog", "horse", "cat"}, Expected: []string{"dog", "cat", "horse"}},
+		{Operand: []string{"b", "a"}, Expected: []string{"b", "a"}},
+		{Operand: []string(nil), Expected: []string{}},
+	}
+
+	for _, test := range tests {
+		result := Uniq(test.Operand)
+		if !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Uniq result incorrect.  Operand: %#v, Expected: %#v, Received: %#v", test.Operand, test.Expected, result)
 		}
 	}
 }
@@ -420,9 +624,12 @@ func TestCount(t *testing.T) {
 
 func TestDivide(t *testing.T) {
 	operand, a, expected := 42, 2, 21
-	result := Divide(a, operand)
-	if result != expected {
-		t.Errorf("Divide result incorrect.  Operand: %d, A: %d, Expected: %d, Received: %d", operand, a, expected, result)
+	result, err := Divide(a, operand)
+	if err != nil || result != expected {
+		t.Errorf("Divide result incorrect.  Operand: %d, A: %d, Expected: %d, Received: %d, Error: %v", operand, a, expected, result, err)
+	}
+	if _, err := Divide(0, operand); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Divide failed to return ErrInvalidArgument for division by zero.  Received: %v", err)
 	}
 }
 
@@ -503,9 +710,12 @@ func TestMatches(t *testing.T) {
 
 func TestModulo(t *testing.T) {
 	operand, a, expected := 42, 10, 2
-	result := Modulo(a, operand)
-	if result != expected {
-		t.Errorf("Modulo result incorrect.  Operand: %d, A: %d, Expected: %d, Received: %d", operand, a, expected, result)
+	result, err := Modulo(a, operand)
+	if err != nil || result != expected {
+		t.Errorf("Modulo result incorrect.  Operand: %d, A: %d, Expected: %d, Received: %d, Error: %v", operand, a, expected, result, err)
+	}
+	if _, err := Modulo(0, operand); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Modulo failed to return ErrInvalidArgument for a zero modulus.  Received: %v", err)
 	}
 }
 
@@ -554,6 +764,17 @@ func TestParseInt(t *testing.T) {
 	}
 }
 
+func TestParseInt64(t *testing.T) {
+	operand, expected := "0x100000000", int64(4294967296)
+	result, err := ParseInt64(operand)
+	if err != nil {
+		t.Errorf("ParseInt64 encountered unexpected error: %s.  Operand: %s", err, operand)
+	}
+	if result != expected {
+		t.Errorf("ParseInt64 result incorrect.  Operand: %s, Expected: %d, Received: %d", operand, expected, result)
+	}
+}
+
 func TestParseTime(t *testing.T) {
 	operand, format, day, year := "2013-Feb-03", "2006-Jan-02", 3, 2013
 	result, err := ParseTime(format, operand)
@@ -583,6 +804,20 @@ func TestParseURL(t *testing.T) {
 	if result.Path != path {
 		t.Errorf("ParseURL path incorrect.  Operand: %s, Expected: %s, Received: %s", operand, path, result.Path)
 	}
+
+	for _, relative := range []string{"/path?q=1", "foo", "../up", ""} {
+		result, err := ParseURL(relative)
+		if err != nil {
+			t.Errorf("ParseURL encountered unexpected error: %s.  Operand: %s", err, relative)
+			continue
+		}
+		if result.String() != relative {
+			t.Errorf("ParseURL result incorrect.  Operand: %s, Received: %s", relative, result)
+		}
+	}
+	if _, err := ParseURL("%zz"); err == nil {
+		t.Errorf("ParseURL failed to return an error.  Operand: %%zz")
+	}
 }
 
 func TestQuote(t *testing.T) {
@@ -603,9 +838,25 @@ func TestQuoteRegex(t *testing.T) {
 
 func TestRepeat(t *testing.T) {
 	operand, count, expected := "duck", 2, "duckduck"
-	result := Repeat(count, operand)
-	if result != expected {
-		t.Errorf("Repeat result incorrect.  Operand: %s, Count: %d, Expected: %s, Received: %s", operand, count, expected, result)
+	result, err := Repeat(count, operand)
+	if err != nil || result != expected {
+		t.Errorf("Repeat result incorrect.  Operand: %s, Count: %d, Expected: %s, Received: %s, Error: %v", operand, count, expected, result, err)
+	}
+	if _, err := Repeat(-1, operand); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Repeat failed to return ErrInvalidArgument for a negative count.  Received: %v", err)
+	}
+	if _, err := Repeat(maxInt, operand); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Repeat failed to return ErrLimitExceeded for a huge count.  Received: %v", err)
+	}
+	long := strings.Repeat("x", 1024)
+	if _, err := Repeat(DefaultLimits.MaxElements/1024, long); err != nil {
+		t.Errorf("Repeat encountered unexpected error at the byte limit: %s", err)
+	}
+	if _, err := Repeat(DefaultLimits.MaxElements/1024+1, long); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Repeat failed to return ErrLimitExceeded for a huge result.  Received: %v", err)
+	}
+	if _, err := MapExpr(`Repeat(65536, x)`, []string{long}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("MapExpr failed to return ErrLimitExceeded for a huge Repeat.  Received: %v", err)
 	}
 }
 
@@ -618,10 +869,34 @@ func TestReplace(t *testing.T) {
 }
 
 func TestSlice(t *testing.T) {
-	operand, first, last, expected := []string{"cat", "dog", "mouse"}, 1, 3, []string{"dog", "mouse"}
-	result := Slice(first, last, operand)
-	if !reflect.DeepEqual(result, expected) {
-		t.Errorf("Slice result incorrect.  Operand: %s, First: %d, Last: %d, Expected: %s, Received: %s", operand, first, last, expected, result)
+	var tests = []struct {
+		First    int
+		Last     int
+		Expected []string
+		Arg      int
+	}{
+		{First: 1, Last: 3, Expected: []string{"dog", "mouse"}},
+		{First: 0, Last: 0, Expected: []string{}},
+		{First: 3, Last: 3, Expected: []string{}},
+		{First: -1, Last: 2, Arg: 0},
+		{First: 4, Last: 5, Arg: 0},
+		{First: 2, Last: 1, Arg: 1},
+		{First: 0, Last: 4, Arg: 1},
+	}
+
+	operand := []string{"cat", "dog", "mouse"}
+	for _, test := range tests {
+		result, err := Slice(test.First, test.Last, operand)
+		if test.Expected == nil {
+			var argErr *ArgumentError
+			if !errors.As(err, &argErr) || argErr.Arg != test.Arg || !errors.Is(err, ErrOutOfRange) {
+				t.Errorf("Slice error incorrect.  First: %d, Last: %d, Expected: argument %d out of range, Received: %v", test.First, test.Last, test.Arg, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Slice result incorrect.  Operand: %s, First: %d, Last: %d, Expected: %s, Received: %s, Error: %v", operand, test.First, test.Last, test.Expected, result, err)
+		}
 	}
 }
 
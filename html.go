@@ -0,0 +1,92 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"html/template"
+	"reflect"
+)
+
+// HTMLFuncMap returns a copy of funcs, such as FuncMap or the result of NewFuncMap, for use with
+// html/template.Template.Funcs().  If funcs is nil, FuncMap is used.  HTMLFuncMap may be applied
+// to a map bound to a context by NewFuncMap, and its functions still observe the context.
+//
+// html/template escapes the result of every function according to the context in which it
+// appears, unless the result has one of the html/template content types, such as
+// template.HTML.  HTMLFuncMap replaces the functions whose output haven has already escaped
+// with versions returning the matching content type, so that their output is not escaped a
+// second time:
+//
+//	HTMLEscape(operand string) template.HTML
+//	JSStringEscape(operand string) template.JSStr
+//
+// No other haven function returns a content type, so all other results, including those
+// built from template data such as Dict values or ParseURL results, are escaped and filtered
+// by html/template as usual.  In particular, no function returns template.URL, since haven does
+// not sanitize URL schemes.
+func HTMLFuncMap(funcs map[string]interface{}) map[string]interface{} {
+	if funcs == nil {
+		funcs = FuncMap
+	}
+	htmlFuncs := make(map[string]interface{}, len(funcs))
+	for name, fn := range funcs {
+		htmlFuncs[name] = fn
+	}
+	if fn, ok := funcs["HTMLEscape"]; ok {
+		htmlFuncs["HTMLEscape"] = withContentType(fn, reflect.TypeOf(template.HTML("")))
+	}
+	if fn, ok := funcs["JSStringEscape"]; ok {
+		htmlFuncs["JSStringEscape"] = withContentType(fn, reflect.TypeOf(template.JSStr("")))
+	}
+	return htmlFuncs
+}
+
+// withContentType returns a function that calls fn and converts its first result to typ, a
+// string-based html/template content type.  Wrapping fn itself, rather than substituting a
+// package-level function, keeps the behavior of functions bound by NewFuncMap, such as their
+// context checks.  Fn is returned unchanged if its first result cannot be converted.
+func withContentType(fn interface{}, typ reflect.Type) interface{} {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumOut() < 1 || !t.Out(0).ConvertibleTo(typ) {
+		return fn
+	}
+	in := make([]reflect.Type, t.NumIn())
+	for i := range in {
+		in[i] = t.In(i)
+	}
+	out := make([]reflect.Type, t.NumOut())
+	for i := range out {
+		out[i] = t.Out(i)
+	}
+	out[0] = typ
+	wrapped := reflect.FuncOf(in, out, t.IsVariadic())
+	return reflect.MakeFunc(wrapped, func(args []reflect.Value) []reflect.Value {
+		var results []reflect.Value
+		if t.IsVariadic() {
+			results = v.CallSlice(args)
+		} else {
+			results = v.Call(args)
+		}
+		results[0] = results[0].Convert(typ)
+		return results
+	}).Interface()
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHTMLFuncMapContentTypes(t *testing.T) {
+	allowed := map[string]reflect.Type{
+		"HTMLEscape":     reflect.TypeOf(template.HTML("")),
+		"JSStringEscape": reflect.TypeOf(template.JSStr("")),
+	}
+	funcs := HTMLFuncMap(nil)
+	if len(funcs) != len(FuncMap) {
+		t.Errorf("HTMLFuncMap result incorrect.  Expected %d functions, Received: %d", len(FuncMap), len(funcs))
+	}
+	for name, fn := range funcs {
+		ft := reflect.TypeOf(fn)
+		for i := 0; i < ft.NumOut(); i++ {
+			out := ft.Out(i)
+			if out.PkgPath() != "html/template" {
+				continue
+			}
+			if allowed[name] != out {
+				t.Errorf("HTMLFuncMap function returns an html/template content type.  Function: %s, Type: %s", name, out)
+			}
+		}
+	}
+	for name, typ := range allowed {
+		if out := reflect.TypeOf(funcs[name]).Out(0); out != typ {
+			t.Errorf("HTMLFuncMap function result type incorrect.  Function: %s, Expected: %s, Received: %s", name, typ, out)
+		}
+	}
+
+	partial := HTMLFuncMap(map[string]interface{}{"Join": Join})
+	if len(partial) != 1 || partial["Join"] == nil {
+		t.Errorf("HTMLFuncMap result incorrect for a partial map.  Received: %#v", partial)
+	}
+}
+
+func TestHTMLFuncMapContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	funcs := HTMLFuncMap(NewFuncMap(Options{Context: ctx}))
+	tmpl := template.Must(template.New("test").Funcs(funcs).Parse(`<p>{{ HTMLEscape "a&b" }}</p><script>var s = '{{ JSStringEscape "it's" }}';</script>`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil || buf.String() != `<p>a&amp;b</p><script>var s = 'it\u0027s';</script>` {
+		t.Errorf("HTMLFuncMap template output incorrect for a bound map.  Received: %s, Error: %v", buf.String(), err)
+	}
+
+	cancel()
+	for _, name := range []string{"HTMLEscape", "JSStringEscape"} {
+		results := reflect.ValueOf(funcs[name]).Call([]reflect.Value{reflect.ValueOf("a&b")})
+		if err, _ := results[len(results)-1].Interface().(error); !errors.Is(err, context.Canceled) {
+			t.Errorf("HTMLFuncMap function ignored a canceled context.  Function: %s", name)
+		}
+	}
+}
+
+func TestHTMLFuncMapEscaping(t *testing.T) {
+	var tests = []struct {
+		Template string
+		Expected string
+	}{
+		{Template: `<p>{{ HTMLEscape "Tom & Jerry" }}</p>`, Expected: `<p>Tom &amp; Jerry</p>`},
+		{Template: `<a title="{{ HTMLEscape "a&b" }}">`, Expected: `<a title="a&amp;b">`},
+		{Template: `<script>var s = '{{ JSStringEscape "it's" }}';</script>`, Expected: `<script>var s = 'it\u0027s';</script>`},
+		{Template: `<script>var s = {{ JSStringEscape "a\"b" }};</script>`, Expected: `<script>var s = "a\"b";</script>`},
+		{Template: `<p>{{ Split "," "a,b" | Join "<br>" }}</p>`, Expected: `<p>a&lt;br&gt;b</p>`},
+	}
+
+	for _, test := range tests {
+		tmpl := template.Must(template.New("test").Funcs(HTMLFuncMap(nil)).Parse(test.Template))
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, nil); err != nil {
+			t.Errorf("Template execution encountered unexpected error: %s.  Template: %s", err, test.Template)
+			continue
+		}
+		if buf.String() != test.Expected {
+			t.Errorf("HTMLFuncMap template output incorrect.  Template: %s, Expected: %s, Received: %s", test.Template, test.Expected, buf.String())
+		}
+	}
+}
+
+func TestHTMLFuncMapXSS(t *testing.T) {
+	payloads := []string{
+		`<script>evil()</script>`,
+		`"><img src=x onerror=evil()>`,
+		`' onmouseover='evil()`,
+		`javascript:evil()`,
+		`</script><script>evil()</script>`,
+		`&lt;script&gt;evil()&lt;/script&gt;`,
+		`&#60;img src=x onerror=evil()&#62;`,
+		"\u2028evil()//",
+	}
+	forbidden := []string{"<script>evil", "<img", "</script><script>", "onmouseover='"}
+	var tests = []struct {
+		Template  string
+		Forbidden []string
+	}{
+		{Template: `<p>{{ . }}</p>`, Forbidden: nil},
+		{Template: `<p>{{ HTMLEscape . }}</p>`, Forbidden: nil},
+		{Template: `<p>{{ HTMLUnescape . }}</p>`, Forbidden: nil},
+		{Template: `<p>{{ XMLEscape . }}</p>`, Forbidden: nil},
+		{Template: `<p>{{ Lines . | Join "\n" | ToUpper | ToLower }}</p>`, Forbidden: nil},
+		{Template: `<p>{{ ShellQuote . }} {{ CSVEscape . }} {{ Quote . }}</p>`, Forbidden: nil},
+		{Template: `<p>{{ with Dict "x" (HTMLUnescape .) }}{{ .x }}{{ end }}</p>`, Forbidden: nil},
+		{Template: `<p>{{ range List (HTMLUnescape .) . }}{{ . }}{{ end }}</p>`, Forbidden: nil},
+		{Template: `<a title="{{ HTMLEscape . }}">x</a>`, Forbidden: nil},
+		{Template: `<a title={{ HTMLUnescape . }}>x</a>`, Forbidden: nil},
+		{Template: `<a href="{{ HTMLUnescape . }}">x</a>`, Forbidden: []string{`href="javascript:`}},
+		{Template: `<a href="/search?q={{ HTMLEscape . }}">x</a>`, Forbidden: []string{`href="javascript:`}},
+		{Template: `<a onclick="f('{{ JSStringEscape . }}')">x</a>`, Forbidden: []string{"\u2028"}},
+		{Template: `<script>var s = '{{ JSStringEscape . }}';</script>`, Forbidden: []string{"\u2028"}},
+		{Template: `<script>var s = "{{ HTMLUnescape . }}";</script>`, Forbidden: []string{"\u2028"}},
+		{Template: `<script>var s = {{ JSStringEscape . }};</script>`, Forbidden: []string{"\u2028"}},
+		{Template: `<script>var s = {{ HTMLEscape . }};</script>`, Forbidden: []string{"\u2028"}},
+		{Template: `<p style="color: {{ HTMLUnescape . }}">x</p>`, Forbidden: nil},
+	}
+
+	for _, test := range tests {
+		tmpl := template.Must(template.New("test").Funcs(HTMLFuncMap(nil)).Parse(test.Template))
+		for _, payload := range payloads {
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, payload); err != nil {
+				t.Errorf("Template execution encountered unexpected error: %s.  Template: %s, Payload: %q", err, test.Template, payload)
+				continue
+			}
+			for _, f := range append(forbidden, test.Forbidden...) {
+				if strings.Contains(buf.String(), f) {
+					t.Errorf("HTMLFuncMap template output contains unescaped payload.  Template: %s, Payload: %q, Received: %q", test.Template, payload, buf.String())
+				}
+			}
+		}
+	}
+}
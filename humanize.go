@@ -0,0 +1,305 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	iecPrefixes = []string{"", "Ki", "Mi", "Gi", "Ti", "Pi", "Ei"}
+	siPrefixes  = []string{"", "k", "M", "G", "T", "P", "E"}
+	siFractions = []string{"", "m", "µ", "n", "p"}
+	byteUnits   = map[string]float64{
+		"":    1,
+		"b":   1,
+		"k":   1e3,
+		"kb":  1e3,
+		"m":   1e6,
+		"mb":  1e6,
+		"g":   1e9,
+		"gb":  1e9,
+		"t":   1e12,
+		"tb":  1e12,
+		"p":   1e15,
+		"pb":  1e15,
+		"e":   1e18,
+		"eb":  1e18,
+		"ki":  1 << 10,
+		"kib": 1 << 10,
+		"mi":  1 << 20,
+		"mib": 1 << 20,
+		"gi":  1 << 30,
+		"gib": 1 << 30,
+		"ti":  1 << 40,
+		"tib": 1 << 40,
+		"pi":  1 << 50,
+		"pib": 1 << 50,
+		"ei":  1 << 60,
+		"eib": 1 << 60,
+	}
+	bytesRegex = regexp.MustCompile(`^([0-9]*\.?[0-9]+)\s*([a-zA-Z]*)$`)
+)
+
+/*
+ * Number Formatting
+ */
+
+// FormatNumber formats operand with comma thousands separators and exactly decimals digits
+// after the decimal point.  Example: 1073741824.5 with decimals == 1 formats as
+// "1,073,741,824.5".  Integer operands are formatted without loss of precision.  Decimals
+// may not exceed 100.
+func FormatNumber(decimals int, operand interface{}) (string, error) {
+	if err := checkDecimals("FormatNumber", 0, decimals); err != nil {
+		return "", err
+	}
+	formatted, err := formatDecimal(decimals, operand)
+	if err != nil {
+		return "", wrapArgumentError("FormatNumber", 1, err)
+	}
+	return groupThousands(formatted, ","), nil
+}
+
+// HumanizeBytes formats operand, a number of bytes, using IEC binary prefixes (powers of 1024).
+// Example: 1073741824 formats as "1.0 GiB".
+func HumanizeBytes(operand interface{}) (string, error) {
+	return humanizeBytes("HumanizeBytes", 1024, iecPrefixes, operand)
+}
+
+// HumanizeBytesSI formats operand, a number of bytes, using SI decimal prefixes (powers of 1000).
+// Example: 1073741824 formats as "1.1 GB".
+func HumanizeBytesSI(operand interface{}) (string, error) {
+	return humanizeBytes("HumanizeBytesSI", 1000, siPrefixes, operand)
+}
+
+// ParseBytes parses operand as a byte quantity with an optional SI or IEC unit, returning the
+// number of bytes.  Units are case-insensitive and the trailing "B" is optional, so "1.5GiB",
+// "1.5 gi", and "1536MiB" are equivalent.  SI units such as "GB" are powers of 1000.
+func ParseBytes(operand string) (int64, error) {
+	matches := bytesRegex.FindStringSubmatch(strings.TrimSpace(operand))
+	if matches == nil {
+		return 0, argumentError("ParseBytes", 0, ErrInvalidArgument, "invalid byte quantity %q", operand)
+	}
+	multiplier, ok := byteUnits[strings.ToLower(matches[2])]
+	if !ok {
+		return 0, argumentError("ParseBytes", 0, ErrInvalidArgument, "invalid byte quantity %q: unknown unit %q", operand, matches[2])
+	}
+	f, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, wrapArgumentError("ParseBytes", 0, err)
+	}
+	bytes := math.Floor(f * multiplier)
+	if bytes >= math.MaxInt64 {
+		return 0, argumentError("ParseBytes", 0, ErrOutOfRange, "invalid byte quantity %q: value overflows int64", operand)
+	}
+	return int64(bytes), nil
+}
+
+// HumanizeSI formats operand with an SI prefix and three significant digits.  Examples: 1234
+// formats as "1.23k", 0.0042 formats as "4.2m", and 950 formats as "950".
+func HumanizeSI(operand interface{}) (string, error) {
+	f, err := toFloat(operand)
+	if err != nil {
+		return "", wrapArgumentError("HumanizeSI", 0, err)
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", argumentError("HumanizeSI", 0, ErrInvalidArgument, "cannot format %v as a number", f)
+	}
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+	if f == 0 {
+		return "0", nil
+	}
+
+	exp := int(math.Floor(math.Log10(f) / 3))
+	if exp >= len(siPrefixes) {
+		exp = len(siPrefixes) - 1
+	}
+	if exp <= -len(siFractions) {
+		exp = -len(siFractions) + 1
+	}
+	scaled := f / math.Pow(1000, float64(exp))
+	digits := strconv.FormatFloat(scaled, 'f', significantDecimals(scaled, 3), 64)
+	if rounded, _ := strconv.ParseFloat(digits, 64); rounded >= 1000 && exp < len(siPrefixes)-1 {
+		exp++
+		scaled = rounded / 1000
+		digits = strconv.FormatFloat(scaled, 'f', significantDecimals(scaled, 3), 64)
+	}
+	if strings.Contains(digits, ".") {
+		digits = strings.TrimRight(strings.TrimRight(digits, "0"), ".")
+	}
+
+	prefix := ""
+	if exp > 0 {
+		prefix = siPrefixes[exp]
+	} else if exp < 0 {
+		prefix = siFractions[-exp]
+	}
+	return sign + digits + prefix, nil
+}
+
+// Ordinal returns operand formatted as an English ordinal number, such as "1st", "22nd",
+// "13th", or "103rd".
+func Ordinal(operand interface{}) (string, error) {
+	i64, err := toInt64(operand)
+	if err != nil {
+		return "", wrapArgumentError("Ordinal", 0, err)
+	}
+	abs := i64
+	if abs < 0 {
+		abs = -abs
+	}
+	suffix := "th"
+	switch abs % 100 {
+	case 11, 12, 13:
+	default:
+		switch abs % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.FormatInt(i64, 10) + suffix, nil
+}
+
+// Percent formats operand, a ratio, as a percentage with exactly decimals digits after the
+// decimal point.  Example: 0.4567 with decimals == 1 formats as "45.7%".  Decimals may not
+// exceed 100.
+func Percent(decimals int, operand interface{}) (string, error) {
+	if err := checkDecimals("Percent", 0, decimals); err != nil {
+		return "", err
+	}
+	f, err := toFloat(operand)
+	if err != nil {
+		return "", wrapArgumentError("Percent", 1, err)
+	}
+	f *= 100
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", argumentError("Percent", 1, ErrInvalidArgument, "cannot format %v as a percentage", operand)
+	}
+	return strconv.FormatFloat(f, 'f', decimals, 64) + "%", nil
+}
+
+// humanizeBytes implements HumanizeBytes and HumanizeBytesSI, which are reported as fn in errors.
+func humanizeBytes(fn string, base float64, prefixes []string, operand interface{}) (string, error) {
+	i64, err := toInt64(operand)
+	if err != nil {
+		return "", wrapArgumentError(fn, 0, err)
+	}
+	sign := ""
+	f := float64(i64)
+	if f < 0 {
+		sign, f = "-", -f
+	}
+	if f < base {
+		return fmt.Sprintf("%s%d B", sign, int64(f)), nil
+	}
+	exp := 0
+	for f >= base && exp < len(prefixes)-1 {
+		f /= base
+		exp++
+	}
+	formatted := strconv.FormatFloat(f, 'f', 1, 64)
+	if formatted == strconv.FormatFloat(base, 'f', 1, 64) && exp < len(prefixes)-1 {
+		formatted = "1.0"
+		exp++
+	}
+	return fmt.Sprintf("%s%s %sB", sign, formatted, prefixes[exp]), nil
+}
+
+// maxDecimals is the largest number of digits after the decimal point that the number
+// formatting functions accept.  Larger precisions carry no information for a float64 and would
+// only let a template allocate arbitrarily long strings.
+const maxDecimals = 100
+
+// checkDecimals checks decimals, which is argument arg of fn.
+func checkDecimals(fn string, arg, decimals int) error {
+	if decimals < 0 || decimals > maxDecimals {
+		return argumentError(fn, arg, ErrOutOfRange, "decimals %d is not between 0 and %d", decimals, maxDecimals)
+	}
+	return nil
+}
+
+// formatDecimal formats operand with exactly decimals digits after the decimal point and no
+// grouping.  Integer operands are formatted without loss of precision.  Callers check decimals
+// with checkDecimals.
+func formatDecimal(decimals int, operand interface{}) (string, error) {
+	var formatted string
+	switch reflect.ValueOf(operand).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		formatted = strconv.FormatInt(reflect.ValueOf(operand).Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		formatted = strconv.FormatUint(reflect.ValueOf(operand).Uint(), 10)
+	default:
+		f, err := toFloat(operand)
+		if err != nil {
+			return "", err
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("cannot format %v as a number", f)
+		}
+		return strconv.FormatFloat(f, 'f', decimals, 64), nil
+	}
+	if decimals > 0 {
+		formatted += "." + strings.Repeat("0", decimals)
+	}
+	return formatted, nil
+}
+
+// groupThousands inserts sep between each group of three digits in the integer portion of
+// formatted, a number formatted with strconv.
+func groupThousands(formatted, sep string) string {
+	sign := ""
+	if strings.HasPrefix(formatted, "-") {
+		sign, formatted = "-", formatted[1:]
+	}
+	integer, fraction := formatted, ""
+	if i := strings.Index(formatted, "."); i >= 0 {
+		integer, fraction = formatted[:i], formatted[i:]
+	}
+	var grouped []string
+	for len(integer) > 3 {
+		grouped = append([]string{integer[len(integer)-3:]}, grouped...)
+		integer = integer[:len(integer)-3]
+	}
+	grouped = append([]string{integer}, grouped...)
+	return sign + strings.Join(grouped, sep) + fraction
+}
+
+// significantDecimals returns the number of decimal places needed to display f, which must
+// be positive, with the given number of significant digits.
+func significantDecimals(f float64, digits int) int {
+	decimals := digits - 1 - int(math.Floor(math.Log10(f)))
+	if decimals < 0 {
+		return 0
+	}
+	return decimals
+}
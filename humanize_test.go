@@ -0,0 +1,223 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormatNumber(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		Decimals int
+		Expected string
+		Valid    bool
+	}{
+		{Operand: 1073741824, Decimals: 0, Expected: "1,073,741,824", Valid: true},
+		{Operand: 999, Decimals: 0, Expected: "999", Valid: true},
+		{Operand: 1000, Decimals: 2, Expected: "1,000.00", Valid: true},
+		{Operand: -1234567, Decimals: 0, Expected: "-1,234,567", Valid: true},
+		{Operand: uint64(18446744073709551615), Decimals: 0, Expected: "18,446,744,073,709,551,615", Valid: true},
+		{Operand: 1234.5678, Decimals: 2, Expected: "1,234.57", Valid: true},
+		{Operand: "123456.7", Decimals: 1, Expected: "123,456.7", Valid: true},
+		{Operand: 1, Decimals: -1, Valid: false},
+		{Operand: "dog", Decimals: 0, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := FormatNumber(test.Decimals, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("FormatNumber encountered unexpected error: %s.  Operand: %#v, Decimals: %d", err, test.Operand, test.Decimals)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("FormatNumber failed to return an error.  Operand: %#v, Decimals: %d, Received: %s", test.Operand, test.Decimals, result)
+		}
+		if result != test.Expected {
+			t.Errorf("FormatNumber result incorrect.  Operand: %#v, Decimals: %d, Expected: %s, Received: %s", test.Operand, test.Decimals, test.Expected, result)
+		}
+	}
+}
+
+func TestHumanizeBytes(t *testing.T) {
+	var tests = []struct {
+		Operand interface{}
+		IEC     string
+		SI      string
+	}{
+		{Operand: 0, IEC: "0 B", SI: "0 B"},
+		{Operand: 999, IEC: "999 B", SI: "999 B"},
+		{Operand: 1000, IEC: "1000 B", SI: "1.0 kB"},
+		{Operand: 1024, IEC: "1.0 KiB", SI: "1.0 kB"},
+		{Operand: 1536, IEC: "1.5 KiB", SI: "1.5 kB"},
+		{Operand: 1048575, IEC: "1.0 MiB", SI: "1.0 MB"},
+		{Operand: 1073741824, IEC: "1.0 GiB", SI: "1.1 GB"},
+		{Operand: "-2048", IEC: "-2.0 KiB", SI: "-2.0 kB"},
+		{Operand: int64(9223372036854775807), IEC: "8.0 EiB", SI: "9.2 EB"},
+	}
+
+	for _, test := range tests {
+		result, err := HumanizeBytes(test.Operand)
+		if err != nil {
+			t.Errorf("HumanizeBytes encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if result != test.IEC {
+			t.Errorf("HumanizeBytes result incorrect.  Operand: %#v, Expected: %s, Received: %s", test.Operand, test.IEC, result)
+		}
+		result, err = HumanizeBytesSI(test.Operand)
+		if err != nil {
+			t.Errorf("HumanizeBytesSI encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if result != test.SI {
+			t.Errorf("HumanizeBytesSI result incorrect.  Operand: %#v, Expected: %s, Received: %s", test.Operand, test.SI, result)
+		}
+	}
+
+	if _, err := HumanizeBytes("dog"); err == nil {
+		t.Errorf("HumanizeBytes failed to return an error.  Operand: dog")
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Expected int64
+		Valid    bool
+	}{
+		{Operand: "42", Expected: 42, Valid: true},
+		{Operand: "42B", Expected: 42, Valid: true},
+		{Operand: "1.5GiB", Expected: 1610612736, Valid: true},
+		{Operand: "1.5 gi", Expected: 1610612736, Valid: true},
+		{Operand: "1536MiB", Expected: 1610612736, Valid: true},
+		{Operand: "1.5GB", Expected: 1500000000, Valid: true},
+		{Operand: " 10 kB ", Expected: 10000, Valid: true},
+		{Operand: ".5KiB", Expected: 512, Valid: true},
+		{Operand: "8EiB", Valid: false},
+		{Operand: "10 parsecs", Valid: false},
+		{Operand: "-1KiB", Valid: false},
+		{Operand: "", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := ParseBytes(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("ParseBytes encountered unexpected error: %s.  Operand: %s", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("ParseBytes failed to return an error.  Operand: %s, Received: %d", test.Operand, result)
+		}
+		if result != test.Expected {
+			t.Errorf("ParseBytes result incorrect.  Operand: %s, Expected: %d, Received: %d", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestHumanizeSI(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		Expected string
+	}{
+		{Operand: 0, Expected: "0"},
+		{Operand: 1, Expected: "1"},
+		{Operand: 950, Expected: "950"},
+		{Operand: 999.9, Expected: "1k"},
+		{Operand: 1234, Expected: "1.23k"},
+		{Operand: 12345, Expected: "12.3k"},
+		{Operand: 123456, Expected: "123k"},
+		{Operand: 1500000, Expected: "1.5M"},
+		{Operand: -2500, Expected: "-2.5k"},
+		{Operand: 0.0042, Expected: "4.2m"},
+		{Operand: 0.000001, Expected: "1µ"},
+		{Operand: 1e21, Expected: "1000E"},
+	}
+
+	for _, test := range tests {
+		result, err := HumanizeSI(test.Operand)
+		if err != nil {
+			t.Errorf("HumanizeSI encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if result != test.Expected {
+			t.Errorf("HumanizeSI result incorrect.  Operand: %#v, Expected: %s, Received: %s", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		Expected string
+	}{
+		{Operand: 0, Expected: "0th"},
+		{Operand: 1, Expected: "1st"},
+		{Operand: 2, Expected: "2nd"},
+		{Operand: 3, Expected: "3rd"},
+		{Operand: 4, Expected: "4th"},
+		{Operand: 11, Expected: "11th"},
+		{Operand: 12, Expected: "12th"},
+		{Operand: 13, Expected: "13th"},
+		{Operand: 22, Expected: "22nd"},
+		{Operand: 101, Expected: "101st"},
+		{Operand: 111, Expected: "111th"},
+		{Operand: "103", Expected: "103rd"},
+		{Operand: -1, Expected: "-1st"},
+	}
+
+	for _, test := range tests {
+		result, err := Ordinal(test.Operand)
+		if err != nil {
+			t.Errorf("Ordinal encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if result != test.Expected {
+			t.Errorf("Ordinal result incorrect.  Operand: %#v, Expected: %s, Received: %s", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	var tests = []struct {
+		Operand  interface{}
+		Decimals int
+		Expected string
+		Valid    bool
+	}{
+		{Operand: 0.4567, Decimals: 1, Expected: "45.7%", Valid: true},
+		{Operand: 1, Decimals: 0, Expected: "100%", Valid: true},
+		{Operand: "0.05", Decimals: 2, Expected: "5.00%", Valid: true},
+		{Operand: 0.5, Decimals: -1, Valid: false},
+		{Operand: math.NaN(), Decimals: 0, Valid: false},
+		{Operand: math.Inf(1), Decimals: 0, Valid: false},
+		{Operand: math.Inf(-1), Decimals: 0, Valid: false},
+		{Operand: math.MaxFloat64, Decimals: 0, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Percent(test.Decimals, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Percent encountered unexpected error: %s.  Operand: %#v, Decimals: %d", err, test.Operand, test.Decimals)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Percent failed to return an error.  Operand: %#v, Decimals: %d, Received: %s", test.Operand, test.Decimals, result)
+		}
+		if result != test.Expected {
+			t.Errorf("Percent result incorrect.  Operand: %#v, Decimals: %d, Expected: %s, Received: %s", test.Operand, test.Decimals, test.Expected, result)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+// Limits bounds the resources consumed by a single haven function call, protecting the host
+// from templates that would otherwise generate unbounded amounts of data.
+type Limits struct {
+	// MaxElements is the maximum number of elements a function may generate, counting the
+	// bytes of generated strings as elements.
+	MaxElements int
+
+	// MaxSteps is the maximum number of evaluation steps a function may take to evaluate
+	// expressions, such as those passed to MapExpr and FilterExpr.
+	MaxSteps int
+
+	// MaxDepth is the maximum nesting depth of the maps and slices a function may traverse,
+	// such as those passed to DeepMerge.  It also stops functions from recursing forever on
+	// values that contain themselves.
+	MaxDepth int
+}
+
+// DefaultLimits are the limits enforced by haven functions.  They may be adjusted before
+// executing templates, but should not be modified while templates are executing.
+var DefaultLimits = Limits{
+	MaxElements: 65536,
+	MaxSteps:    1000000,
+	MaxDepth:    1000,
+}
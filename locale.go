@@ -0,0 +1,193 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type locale struct {
+	decimal        string
+	group          string
+	minus          string
+	minGrouping    int
+	currencyFormat string
+	symbols        map[string]string
+	months         [12]string
+	shortMonths    [12]string
+	days           [7]string
+	shortDays      [7]string
+	dateStyles     map[string]string
+	caseMapping    unicode.SpecialCase
+	// collation lists letters that sort as distinct letters rather than as accented variants.
+	// Each entry is a base letter followed by the letters that sort after it, in order.
+	collation []string
+}
+
+// LocaleFuncMap returns a map of functions bound to the locale identified by tag, such as "de",
+// "pt-BR", or "tr_TR".  If no data exists for the full tag, the language portion of the tag is
+// used.  Locale data is embedded in haven, so the host's locale settings are never consulted.
+// The returned map is meant for use with text/template.Template.Funcs() alongside FuncMap:
+//
+//	FormatNumberLocale(decimals int, operand interface{}) (string, error)
+//	    Formats operand like FormatNumber using the locale's decimal and grouping separators.
+//	FormatCurrency(code string, operand interface{}) (string, error)
+//	    Formats operand as an amount of the currency identified by the ISO 4217 code, using the
+//	    currency's standard number of decimals and the locale's symbol placement.  Codes for
+//	    which haven has no data, including unassigned codes, are rejected.
+//	FormatDateLocale(layout string, operand time.Time) string
+//	    Formats operand like time.Time.Format with month and weekday names in the locale's
+//	    language.  Layout may also be one of the locale's "short", "medium", "long", or "full"
+//	    date styles.
+//	ToLower(operand string) string
+//	ToUpper(operand string) string
+//	    Replace the FuncMap functions of the same name with versions that apply the locale's
+//	    case mapping rules, such as the Turkish dotted and dotless I.
+func LocaleFuncMap(tag string) (map[string]interface{}, error) {
+	l, err := lookupLocale(tag)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"FormatCurrency":     l.formatCurrency,
+		"FormatDateLocale":   l.formatDate,
+		"FormatNumberLocale": l.formatNumber,
+		"ToLower":            l.toLower,
+		"ToUpper":            l.toUpper,
+	}, nil
+}
+
+func lookupLocale(tag string) (*locale, error) {
+	if l, ok := locales[normalizeLanguage(tag)]; ok {
+		return l, nil
+	}
+	if l, ok := locales[baseLanguage(tag)]; ok {
+		return l, nil
+	}
+	return nil, fmt.Errorf("unsupported locale %q", tag)
+}
+
+func (l *locale) formatNumber(decimals int, operand interface{}) (string, error) {
+	if err := checkDecimals("FormatNumberLocale", 0, decimals); err != nil {
+		return "", err
+	}
+	formatted, err := formatDecimal(decimals, operand)
+	if err != nil {
+		return "", wrapArgumentError("FormatNumberLocale", 1, err)
+	}
+	negative, number := l.localizeDecimal(formatted)
+	if negative {
+		return l.minus + number, nil
+	}
+	return number, nil
+}
+
+func (l *locale) formatCurrency(code string, operand interface{}) (string, error) {
+	cur, ok := currencies[code]
+	if !ok {
+		return "", argumentError("FormatCurrency", 0, ErrInvalidArgument, "unknown currency code %q", code)
+	}
+	if symbol, ok := l.symbols[code]; ok {
+		cur.symbol = symbol
+	}
+
+	formatted, err := formatDecimal(cur.digits, operand)
+	if err != nil {
+		return "", wrapArgumentError("FormatCurrency", 1, err)
+	}
+	negative, number := l.localizeDecimal(formatted)
+	result := strings.Replace(strings.Replace(l.currencyFormat, "#", number, 1), "¤", cur.symbol, 1)
+	if negative {
+		return l.minus + result, nil
+	}
+	return result, nil
+}
+
+// localizeDecimal converts formatted, a number formatted by formatDecimal, to the locale's
+// separators.  The sign is returned separately so callers may place the locale's minus sign.
+func (l *locale) localizeDecimal(formatted string) (negative bool, number string) {
+	if strings.HasPrefix(formatted, "-") {
+		negative, formatted = true, formatted[1:]
+	}
+	integer, fraction := formatted, ""
+	if i := strings.Index(formatted, "."); i >= 0 {
+		integer, fraction = formatted[:i], formatted[i+1:]
+	}
+	if len(integer) >= 3+l.minGrouping {
+		integer = groupThousands(integer, l.group)
+	}
+	if fraction == "" {
+		return negative, integer
+	}
+	return negative, integer + l.decimal + fraction
+}
+
+func (l *locale) formatDate(layout string, operand time.Time) string {
+	if style, ok := l.dateStyles[layout]; ok {
+		layout = style
+	}
+	var buf bytes.Buffer
+	for layout != "" {
+		i, token := nextNameChunk(layout)
+		if i < 0 {
+			buf.WriteString(operand.Format(layout))
+			break
+		}
+		buf.WriteString(operand.Format(layout[:i]))
+		switch token {
+		case "January":
+			buf.WriteString(l.months[operand.Month()-1])
+		case "Jan":
+			buf.WriteString(l.shortMonths[operand.Month()-1])
+		case "Monday":
+			buf.WriteString(l.days[operand.Weekday()])
+		case "Mon":
+			buf.WriteString(l.shortDays[operand.Weekday()])
+		}
+		layout = layout[i+len(token):]
+	}
+	return buf.String()
+}
+
+// nextNameChunk returns the position and value of the first month or weekday name element in
+// layout, or -1 if there are none.
+func nextNameChunk(layout string) (int, string) {
+	for i := range layout {
+		for _, token := range []string{"January", "Jan", "Monday", "Mon"} {
+			if strings.HasPrefix(layout[i:], token) {
+				return i, token
+			}
+		}
+	}
+	return -1, ""
+}
+
+func (l *locale) toLower(operand string) string {
+	return strings.ToLowerSpecial(l.caseMapping, operand)
+}
+
+func (l *locale) toUpper(operand string) string {
+	return strings.ToUpperSpecial(l.caseMapping, operand)
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestLocaleFuncMap(t *testing.T) {
+	var tests = []struct {
+		Tag   string
+		Valid bool
+	}{
+		{Tag: "en", Valid: true},
+		{Tag: "en-GB", Valid: true},
+		{Tag: "en_US", Valid: true},
+		{Tag: "pt-BR", Valid: true},
+		{Tag: "TR", Valid: true},
+		{Tag: "xx", Valid: false},
+		{Tag: "", Valid: false},
+	}
+
+	for _, test := range tests {
+		funcs, err := LocaleFuncMap(test.Tag)
+		if test.Valid && (err != nil || len(funcs) == 0) {
+			t.Errorf("LocaleFuncMap encountered unexpected error: %v.  Tag: %s", err, test.Tag)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("LocaleFuncMap failed to return an error.  Tag: %s", test.Tag)
+		}
+	}
+}
+
+func TestFormatNumberLocale(t *testing.T) {
+	var tests = []struct {
+		Tag      string
+		Operand  interface{}
+		Decimals int
+		Expected string
+	}{
+		{Tag: "en", Operand: 1234567.891, Decimals: 2, Expected: "1,234,567.89"},
+		{Tag: "de", Operand: 1234567.891, Decimals: 2, Expected: "1.234.567,89"},
+		{Tag: "fr", Operand: 1234567.891, Decimals: 2, Expected: "1\u202f234\u202f567,89"},
+		{Tag: "es", Operand: 1234, Decimals: 0, Expected: "1234"},
+		{Tag: "es", Operand: 12345, Decimals: 0, Expected: "12.345"},
+		{Tag: "sv", Operand: -1234.5, Decimals: 1, Expected: "−1\u00a0234,5"},
+		{Tag: "ja", Operand: 1234, Decimals: 0, Expected: "1,234"},
+	}
+
+	for _, test := range tests {
+		l, _ := lookupLocale(test.Tag)
+		result, err := l.formatNumber(test.Decimals, test.Operand)
+		if err != nil {
+			t.Errorf("FormatNumberLocale encountered unexpected error: %s.  Tag: %s, Operand: %#v", err, test.Tag, test.Operand)
+		}
+		if result != test.Expected {
+			t.Errorf("FormatNumberLocale result incorrect.  Tag: %s, Operand: %#v, Decimals: %d, Expected: %q, Received: %q", test.Tag, test.Operand, test.Decimals, test.Expected, result)
+		}
+	}
+}
+
+func TestFormatCurrency(t *testing.T) {
+	var tests = []struct {
+		Tag      string
+		Code     string
+		Operand  interface{}
+		Expected string
+		Valid    bool
+	}{
+		{Tag: "en", Code: "USD", Operand: 1234.5, Expected: "$1,234.50", Valid: true},
+		{Tag: "en", Code: "USD", Operand: -1234.5, Expected: "-$1,234.50", Valid: true},
+		{Tag: "en", Code: "JPY", Operand: 1234.5, Expected: "¥1,234", Valid: true},
+		{Tag: "en", Code: "SEK", Operand: 10, Expected: "SEK10.00", Valid: true},
+		{Tag: "en-GB", Code: "USD", Operand: 10, Expected: "US$10.00", Valid: true},
+		{Tag: "de", Code: "EUR", Operand: 1234.5, Expected: "1.234,50\u00a0€", Valid: true},
+		{Tag: "nl", Code: "EUR", Operand: 1234.5, Expected: "€\u00a01.234,50", Valid: true},
+		{Tag: "sv", Code: "SEK", Operand: 99, Expected: "99,00\u00a0kr", Valid: true},
+		{Tag: "ja", Code: "JPY", Operand: 5000, Expected: "￥5,000", Valid: true},
+		{Tag: "en", Code: "KWD", Operand: 1.5, Expected: "KWD1.500", Valid: true},
+		{Tag: "en", Code: "KRW", Operand: 1234.5, Expected: "₩1,234", Valid: true},
+		{Tag: "en", Code: "XTS", Operand: 1, Valid: false},
+		{Tag: "en", Code: "USS", Operand: 1, Valid: false},
+		{Tag: "en", Code: "usd", Operand: 1, Valid: false},
+		{Tag: "en", Code: "USD", Operand: "dog", Valid: false},
+	}
+
+	for _, test := range tests {
+		l, _ := lookupLocale(test.Tag)
+		result, err := l.formatCurrency(test.Code, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("FormatCurrency encountered unexpected error: %s.  Tag: %s, Code: %s, Operand: %#v", err, test.Tag, test.Code, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("FormatCurrency failed to return an error.  Tag: %s, Code: %s, Operand: %#v, Received: %q", test.Tag, test.Code, test.Operand, result)
+		}
+		if result != test.Expected {
+			t.Errorf("FormatCurrency result incorrect.  Tag: %s, Code: %s, Operand: %#v, Expected: %q, Received: %q", test.Tag, test.Code, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestFormatDateLocale(t *testing.T) {
+	operand := time.Date(2016, time.March, 7, 15, 4, 5, 0, time.UTC)
+	var tests = []struct {
+		Tag      string
+		Layout   string
+		Expected string
+	}{
+		{Tag: "en", Layout: "long", Expected: "March 7, 2016"},
+		{Tag: "en", Layout: "Mon Jan _2 15:04:05 2006", Expected: "Mon Mar  7 15:04:05 2016"},
+		{Tag: "de", Layout: "full", Expected: "Montag, 7. März 2016"},
+		{Tag: "de", Layout: "Mon, 02 Jan 2006", Expected: "Mo., 07 März 2016"},
+		{Tag: "fr", Layout: "full", Expected: "lundi 7 mars 2016"},
+		{Tag: "es", Layout: "long", Expected: "7 de marzo de 2016"},
+		{Tag: "ru", Layout: "long", Expected: "7 марта 2016 г."},
+		{Tag: "ja", Layout: "full", Expected: "2016年3月7日月曜日"},
+		{Tag: "tr", Layout: "Monday 15:04", Expected: "Pazartesi 15:04"},
+		{Tag: "sv", Layout: "short", Expected: "2016-03-07"},
+	}
+
+	for _, test := range tests {
+		l, _ := lookupLocale(test.Tag)
+		result := l.formatDate(test.Layout, operand)
+		if result != test.Expected {
+			t.Errorf("FormatDateLocale result incorrect.  Tag: %s, Layout: %s, Expected: %q, Received: %q", test.Tag, test.Layout, test.Expected, result)
+		}
+	}
+}
+
+func TestLocaleCase(t *testing.T) {
+	var tests = []struct {
+		Tag   string
+		Upper string
+		Lower string
+	}{
+		{Tag: "en", Upper: "ISTANBUL", Lower: "istanbul"},
+		{Tag: "tr", Upper: "İSTANBUL", Lower: "ıstanbul"},
+	}
+
+	for _, test := range tests {
+		l, _ := lookupLocale(test.Tag)
+		if result := l.toUpper("istanbul"); result != test.Upper {
+			t.Errorf("ToUpper result incorrect.  Tag: %s, Operand: istanbul, Expected: %s, Received: %s", test.Tag, test.Upper, result)
+		}
+		if result := l.toLower("ISTANBUL"); result != test.Lower {
+			t.Errorf("ToLower result incorrect.  Tag: %s, Operand: ISTANBUL, Expected: %s, Received: %s", test.Tag, test.Lower, result)
+		}
+	}
+}
+
+func TestLocaleTemplate(t *testing.T) {
+	funcs, err := LocaleFuncMap("de-DE")
+	if err != nil {
+		t.Fatalf("LocaleFuncMap encountered unexpected error: %s", err)
+	}
+	tmpl := template.Must(template.New("test").Funcs(FuncMap).Funcs(funcs).Parse(`{{ .Total | FormatCurrency "EUR" }} {{ .Count | FormatNumberLocale 0 }} {{ ToUpper "köln" }}`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]interface{}{"Total": 1999.9, "Count": 12000}); err != nil {
+		t.Fatalf("Template execution encountered unexpected error: %s", err)
+	}
+	expected := "1.999,90\u00a0€ 12.000 KÖLN"
+	if buf.String() != expected {
+		t.Errorf("Template result incorrect.  Expected: %q, Received: %q", expected, buf.String())
+	}
+}
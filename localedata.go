@@ -0,0 +1,328 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import "unicode"
+
+// The locale data below is a subset of the Unicode CLDR, embedded so that locale-aware
+// formatting never consults the host system.
+
+type currency struct {
+	symbol string
+	digits int
+}
+
+// currencies lists the ISO 4217 currencies FormatCurrency supports, with their English symbols
+// and the number of fraction digits ISO 4217 assigns them.
+var currencies = map[string]currency{
+	"AED": {symbol: "AED", digits: 2},
+	"ARS": {symbol: "ARS", digits: 2},
+	"AUD": {symbol: "A$", digits: 2},
+	"BHD": {symbol: "BHD", digits: 3},
+	"BRL": {symbol: "R$", digits: 2},
+	"CAD": {symbol: "CA$", digits: 2},
+	"CHF": {symbol: "CHF", digits: 2},
+	"CLP": {symbol: "CLP", digits: 0},
+	"CNY": {symbol: "CN¥", digits: 2},
+	"COP": {symbol: "COP", digits: 2},
+	"CZK": {symbol: "CZK", digits: 2},
+	"DKK": {symbol: "DKK", digits: 2},
+	"EGP": {symbol: "EGP", digits: 2},
+	"EUR": {symbol: "€", digits: 2},
+	"GBP": {symbol: "£", digits: 2},
+	"HKD": {symbol: "HK$", digits: 2},
+	"HUF": {symbol: "HUF", digits: 2},
+	"IDR": {symbol: "IDR", digits: 2},
+	"ILS": {symbol: "₪", digits: 2},
+	"INR": {symbol: "₹", digits: 2},
+	"ISK": {symbol: "ISK", digits: 0},
+	"JOD": {symbol: "JOD", digits: 3},
+	"JPY": {symbol: "¥", digits: 0},
+	"KRW": {symbol: "₩", digits: 0},
+	"KWD": {symbol: "KWD", digits: 3},
+	"MXN": {symbol: "MX$", digits: 2},
+	"MYR": {symbol: "MYR", digits: 2},
+	"NGN": {symbol: "NGN", digits: 2},
+	"NOK": {symbol: "NOK", digits: 2},
+	"NZD": {symbol: "NZ$", digits: 2},
+	"OMR": {symbol: "OMR", digits: 3},
+	"PHP": {symbol: "₱", digits: 2},
+	"PKR": {symbol: "PKR", digits: 2},
+	"PLN": {symbol: "PLN", digits: 2},
+	"PYG": {symbol: "PYG", digits: 0},
+	"RON": {symbol: "RON", digits: 2},
+	"RUB": {symbol: "RUB", digits: 2},
+	"SAR": {symbol: "SAR", digits: 2},
+	"SEK": {symbol: "SEK", digits: 2},
+	"SGD": {symbol: "SGD", digits: 2},
+	"THB": {symbol: "THB", digits: 2},
+	"TND": {symbol: "TND", digits: 3},
+	"TRY": {symbol: "TRY", digits: 2},
+	"TWD": {symbol: "NT$", digits: 2},
+	"UAH": {symbol: "UAH", digits: 2},
+	"UGX": {symbol: "UGX", digits: 0},
+	"USD": {symbol: "$", digits: 2},
+	"VND": {symbol: "₫", digits: 0},
+	"XAF": {symbol: "FCFA", digits: 0},
+	"XOF": {symbol: "F\u202fCFA", digits: 0},
+	"ZAR": {symbol: "ZAR", digits: 2},
+}
+
+var locales = map[string]*locale{
+	"en": {
+		decimal:        ".",
+		group:          ",",
+		minus:          "-",
+		minGrouping:    1,
+		currencyFormat: "¤#",
+		months:         [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		shortMonths:    [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		days:           [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		shortDays:      [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		dateStyles: map[string]string{
+			"short":  "1/2/06",
+			"medium": "Jan 2, 2006",
+			"long":   "January 2, 2006",
+			"full":   "Monday, January 2, 2006",
+		},
+	},
+	"en-gb": {
+		decimal:        ".",
+		group:          ",",
+		minus:          "-",
+		minGrouping:    1,
+		currencyFormat: "¤#",
+		symbols:        map[string]string{"USD": "US$"},
+		months:         [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		shortMonths:    [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sept", "Oct", "Nov", "Dec"},
+		days:           [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		shortDays:      [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		dateStyles: map[string]string{
+			"short":  "02/01/2006",
+			"medium": "2 Jan 2006",
+			"long":   "2 January 2006",
+			"full":   "Monday, 2 January 2006",
+		},
+	},
+	"de": {
+		decimal:        ",",
+		group:          ".",
+		minus:          "-",
+		minGrouping:    1,
+		currencyFormat: "#\u00a0¤",
+		months:         [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths:    [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		days:           [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortDays:      [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		dateStyles: map[string]string{
+			"short":  "02.01.06",
+			"medium": "02.01.2006",
+			"long":   "2. January 2006",
+			"full":   "Monday, 2. January 2006",
+		},
+	},
+	"es": {
+		decimal:        ",",
+		group:          ".",
+		minus:          "-",
+		minGrouping:    2,
+		currencyFormat: "#\u00a0¤",
+		symbols:        map[string]string{"USD": "US$"},
+		months:         [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths:    [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		days:           [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortDays:      [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		dateStyles: map[string]string{
+			"short":  "2/1/06",
+			"medium": "2 Jan 2006",
+			"long":   "2 de January de 2006",
+			"full":   "Monday, 2 de January de 2006",
+		},
+		collation: []string{"nñ"},
+	},
+	"fr": {
+		decimal:        ",",
+		group:          "\u202f",
+		minus:          "-",
+		minGrouping:    1,
+		currencyFormat: "#\u00a0¤",
+		symbols:        map[string]string{"USD": "$US"},
+		months:         [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths:    [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		days:           [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortDays:      [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		dateStyles: map[string]string{
+			"short":  "02/01/2006",
+			"medium": "2 Jan 2006",
+			"long":   "2 January 2006",
+			"full":   "Monday 2 January 2006",
+		},
+	},
+	"it": {
+		decimal:        ",",
+		group:          ".",
+		minus:          "-",
+		minGrouping:    1,
+		currencyFormat: "#\u00a0¤",
+		symbols:        map[string]string{"USD": "USD"},
+		months:         [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		shortMonths:    [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		days:           [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		shortDays:      [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+		dateStyles: map[string]string{
+			"short":  "02/01/06",
+			"medium": "2 Jan 2006",
+			"long":   "2 January 2006",
+			"full":   "Monday 2 January 2006",
+		},
+	},
+	"ja": {
+		decimal:        ".",
+		group:          ",",
+		minus:          "-",
+		minGrouping:    1,
+		currencyFormat: "¤#",
+		symbols:        map[string]string{"JPY": "￥", "CNY": "元"},
+		months:         [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		shortMonths:    [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		days:           [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"},
+		shortDays:      [7]string{"日", "月", "火", "水", "木", "金", "土"},
+		dateStyles: map[string]string{
+			"short":  "2006/01/02",
+			"medium": "2006/01/02",
+			"long":   "2006年1月2日",
+			"full":   "2006年1月2日Monday",
+		},
+	},
+	"nl": {
+		decimal:        ",",
+		group:          ".",
+		minus:          "-",
+		minGrouping:    1,
+		currencyFormat: "¤\u00a0#",
+		symbols:        map[string]string{"USD": "US$"},
+		months:         [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		shortMonths:    [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		days:           [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		shortDays:      [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+		dateStyles: map[string]string{
+			"short":  "02-01-2006",
+			"medium": "2 Jan 2006",
+			"long":   "2 January 2006",
+			"full":   "Monday 2 January 2006",
+		},
+	},
+	"pl": {
+		decimal:        ",",
+		group:          "\u00a0",
+		minus:          "-",
+		minGrouping:    2,
+		currencyFormat: "#\u00a0¤",
+		symbols:        map[string]string{"PLN": "zł", "USD": "USD"},
+		months:         [12]string{"stycznia", "lutego", "marca", "kwietnia", "maja", "czerwca", "lipca", "sierpnia", "września", "października", "listopada", "grudnia"},
+		shortMonths:    [12]string{"sty", "lut", "mar", "kwi", "maj", "cze", "lip", "sie", "wrz", "paź", "lis", "gru"},
+		days:           [7]string{"niedziela", "poniedziałek", "wtorek", "środa", "czwartek", "piątek", "sobota"},
+		shortDays:      [7]string{"niedz.", "pon.", "wt.", "śr.", "czw.", "pt.", "sob."},
+		dateStyles: map[string]string{
+			"short":  "2.01.2006",
+			"medium": "2 Jan 2006",
+			"long":   "2 January 2006",
+			"full":   "Monday, 2 January 2006",
+		},
+		collation: []string{"aą", "cć", "eę", "lł", "nń", "oó", "sś", "zźż"},
+	},
+	"pt": {
+		decimal:        ",",
+		group:          ".",
+		minus:          "-",
+		minGrouping:    1,
+		currencyFormat: "¤\u00a0#",
+		symbols:        map[string]string{"USD": "US$"},
+		months:         [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		shortMonths:    [12]string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."},
+		days:           [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		shortDays:      [7]string{"dom.", "seg.", "ter.", "qua.", "qui.", "sex.", "sáb."},
+		dateStyles: map[string]string{
+			"short":  "02/01/2006",
+			"medium": "2 de Jan de 2006",
+			"long":   "2 de January de 2006",
+			"full":   "Monday, 2 de January de 2006",
+		},
+	},
+	"ru": {
+		decimal:        ",",
+		group:          "\u00a0",
+		minus:          "-",
+		minGrouping:    1,
+		currencyFormat: "#\u00a0¤",
+		symbols:        map[string]string{"RUB": "₽", "USD": "$"},
+		months:         [12]string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"},
+		shortMonths:    [12]string{"янв.", "февр.", "мар.", "апр.", "мая", "июн.", "июл.", "авг.", "сент.", "окт.", "нояб.", "дек."},
+		days:           [7]string{"воскресенье", "понедельник", "вторник", "среда", "четверг", "пятница", "суббота"},
+		shortDays:      [7]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"},
+		dateStyles: map[string]string{
+			"short":  "02.01.2006",
+			"medium": "2 Jan 2006 г.",
+			"long":   "2 January 2006 г.",
+			"full":   "Monday, 2 January 2006 г.",
+		},
+		collation: []string{"её"},
+	},
+	"sv": {
+		decimal:        ",",
+		group:          "\u00a0",
+		minus:          "\u2212",
+		minGrouping:    1,
+		currencyFormat: "#\u00a0¤",
+		symbols:        map[string]string{"SEK": "kr", "USD": "US$"},
+		months:         [12]string{"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"},
+		shortMonths:    [12]string{"jan.", "feb.", "mars", "apr.", "maj", "juni", "juli", "aug.", "sep.", "okt.", "nov.", "dec."},
+		days:           [7]string{"söndag", "måndag", "tisdag", "onsdag", "torsdag", "fredag", "lördag"},
+		shortDays:      [7]string{"sön", "mån", "tis", "ons", "tors", "fre", "lör"},
+		dateStyles: map[string]string{
+			"short":  "2006-01-02",
+			"medium": "2 Jan 2006",
+			"long":   "2 January 2006",
+			"full":   "Monday 2 January 2006",
+		},
+		collation: []string{"zåäö"},
+	},
+	"tr": {
+		decimal:        ",",
+		group:          ".",
+		minus:          "-",
+		minGrouping:    1,
+		currencyFormat: "¤#",
+		symbols:        map[string]string{"TRY": "₺", "USD": "$"},
+		months:         [12]string{"Ocak", "Şubat", "Mart", "Nisan", "Mayıs", "Haziran", "Temmuz", "Ağustos", "Eylül", "Ekim", "Kasım", "Aralık"},
+		shortMonths:    [12]string{"Oca", "Şub", "Mar", "Nis", "May", "Haz", "Tem", "Ağu", "Eyl", "Eki", "Kas", "Ara"},
+		days:           [7]string{"Pazar", "Pazartesi", "Salı", "Çarşamba", "Perşembe", "Cuma", "Cumartesi"},
+		shortDays:      [7]string{"Paz", "Pzt", "Sal", "Çar", "Per", "Cum", "Cmt"},
+		dateStyles: map[string]string{
+			"short":  "2.01.2006",
+			"medium": "2 Jan 2006",
+			"long":   "2 January 2006",
+			"full":   "2 January 2006 Monday",
+		},
+		caseMapping: unicode.TurkishCase,
+		collation:   []string{"cç", "gğ", "hı", "oö", "sş", "uü"},
+	},
+}
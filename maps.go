@@ -0,0 +1,820 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Change describes a single difference between two values.  It is returned by Diff.
+type Change struct {
+	// Op is "add", "remove", or "replace", as in a JSON Patch operation.
+	Op string
+
+	// Path is the JSON Pointer (RFC 6901) of the changed value, such as "/spec/replicas".
+	Path string
+
+	// Old is the value before the change.  It is nil for additions.
+	Old interface{}
+
+	// New is the value after the change.  It is nil for removals.
+	New interface{}
+}
+
+/*
+ * Map Manipulation
+ *
+ * These functions operate on nested maps and slices, such as data decoded from JSON or YAML.
+ * Maps must have string keys.  Structs are treated as maps of their exported fields.  Results
+ * are built from map[string]interface{} and []interface{}, and never share storage with the
+ * operands, which are left unmodified.  Operands nested more than DefaultLimits.MaxDepth levels
+ * deep, including those that contain themselves, are an error.
+ */
+
+// DeepMerge merges layers from left to right, so that later layers take precedence:
+// {{ .Service | DeepMerge "unique" .Defaults .Environment }}.  Nested maps are merged
+// recursively.  Strategy determines how slices present in several layers are combined:
+// "replace" keeps the later slice, "append" concatenates them, and "unique" concatenates them
+// and removes duplicate elements.  Nil layers are ignored.  The layers together may hold at most
+// DefaultLimits.MaxElements map entries and slice elements.
+func DeepMerge(strategy string, layers ...interface{}) (map[string]interface{}, error) {
+	if strategy != "replace" && strategy != "append" && strategy != "unique" {
+		return nil, argumentError("DeepMerge", 0, ErrInvalidArgument, "unknown merge strategy %q: expected replace, append, or unique", strategy)
+	}
+	merged := make(map[string]interface{})
+	n := &normalizer{limit: DefaultLimits.MaxElements}
+	for i, layer := range layers {
+		if layer == nil {
+			continue
+		}
+		normalized, err := n.normalizeArg("DeepMerge", i+1, layer)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := normalized.(map[string]interface{})
+		if !ok {
+			return nil, argumentError("DeepMerge", i+1, ErrInvalidArgument, "cannot merge layer %d of type %s: expected map", i, TypeOf(layer))
+		}
+		mergeMaps(strategy, merged, m)
+	}
+	return merged, nil
+}
+
+// DeepEqual checks if a and operand hold the same nested values.  Unlike reflect.DeepEqual,
+// numbers compare by value regardless of type, so int 1 equals float64 1, and structs equal maps
+// of the same fields.  Values nested more than DefaultLimits.MaxDepth levels deep, or holding
+// more than DefaultLimits.MaxElements map entries and slice elements, are not equal.
+func DeepEqual(a, operand interface{}) bool {
+	na, err := normalizeValue(a)
+	if err != nil {
+		return false
+	}
+	nb, err := normalizeValue(operand)
+	if err != nil {
+		return false
+	}
+	return deepEqualValues(na, nb)
+}
+
+// DeepCopy returns a copy of operand that shares no maps, slices, or pointers with it.  Unlike
+// the other map functions, DeepCopy keeps operand's types, and values that operand reaches
+// more than once, including operand itself, are copied once and reached the same way in the
+// copy.  Unexported struct fields cannot be set by reflection and are copied as is, so a
+// struct's unexported maps, slices, and pointers remain shared with operand.
+func DeepCopy(operand interface{}) interface{} {
+	if operand == nil {
+		return nil
+	}
+	c := &deepCopier{copies: make(map[copyKey]reflect.Value)}
+	return c.copy(reflect.ValueOf(operand)).Interface()
+}
+
+// Diff returns the changes that transform a into operand, ordered by path.  Map keys are
+// compared by name and slices by index.  Applying the changes to a with JSONPatch yields operand.
+// A and operand together may hold at most DefaultLimits.MaxElements map entries and slice
+// elements.
+func Diff(a, operand interface{}) ([]Change, error) {
+	n := &normalizer{limit: DefaultLimits.MaxElements}
+	na, err := n.normalizeArg("Diff", 0, a)
+	if err != nil {
+		return nil, err
+	}
+	nb, err := n.normalizeArg("Diff", 1, operand)
+	if err != nil {
+		return nil, err
+	}
+	return diffValues("", na, nb, []Change{}), nil
+}
+
+// JSONPatch applies the JSON Patch (RFC 6902) patch to operand and returns the result.  Patch
+// may be a JSON string, a decoded list of operations, or the []Change returned by Diff.  The
+// add, remove, replace, move, copy, and test operations are supported.  If any operation fails,
+// JSONPatch returns an error and no result.  Operand and the values the operations add or copy
+// may together hold at most DefaultLimits.MaxElements map entries and slice elements, and no
+// value may end up nested more than DefaultLimits.MaxDepth levels deep.
+func JSONPatch(patch interface{}, operand interface{}) (interface{}, error) {
+	ops, err := decodePatch(patch)
+	if err != nil {
+		return nil, wrapArgumentError("JSONPatch", 0, err)
+	}
+	n := &normalizer{limit: DefaultLimits.MaxElements}
+	doc, err := n.normalizeArg("JSONPatch", 1, operand)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		if doc, err = applyPatchOp(n, doc, op); err != nil {
+			return nil, wrapArgumentError("JSONPatch", 0, fmt.Errorf("patch operation %d (%s %s): %w", i, op["op"], op["path"], err))
+		}
+	}
+	return doc, nil
+}
+
+// MergePatch applies the JSON Merge Patch (RFC 7396) patch to operand and returns the result.
+// Patch may be a JSON string or a decoded value.  Keys set to null in the patch are removed.
+// Patch and operand together may hold at most DefaultLimits.MaxElements map entries and slice
+// elements.
+func MergePatch(patch interface{}, operand interface{}) (interface{}, error) {
+	p, err := decodeJSONArg(patch)
+	if err != nil {
+		return nil, wrapArgumentError("MergePatch", 0, err)
+	}
+	n := &normalizer{limit: DefaultLimits.MaxElements}
+	if p, err = n.normalizeArg("MergePatch", 0, p); err != nil {
+		return nil, err
+	}
+	doc, err := n.normalizeArg("MergePatch", 1, operand)
+	if err != nil {
+		return nil, err
+	}
+	return mergePatchValue(p, doc), nil
+}
+
+func mergeMaps(strategy string, dst, src map[string]interface{}) {
+	for k, v := range src {
+		existing, ok := dst[k]
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		switch value := v.(type) {
+		case map[string]interface{}:
+			if existingMap, ok := existing.(map[string]interface{}); ok {
+				mergeMaps(strategy, existingMap, value)
+				continue
+			}
+		case []interface{}:
+			if existingSlice, ok := existing.([]interface{}); ok && strategy != "replace" {
+				combined := append(append([]interface{}{}, existingSlice...), value...)
+				if strategy == "unique" {
+					combined = uniqValues(combined)
+				}
+				dst[k] = combined
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
+// uniqValues removes duplicate elements from values, keeping the first of each.  Elements are
+// grouped by uniqKey so that only those that may be equal are compared.
+func uniqValues(values []interface{}) []interface{} {
+	uniq := make([]interface{}, 0, len(values))
+	seen := make(map[string][]int)
+	for _, v := range values {
+		key := uniqKey(v)
+		duplicate := false
+		for _, i := range seen[key] {
+			if deepEqualValues(uniq[i], v) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			seen[key] = append(seen[key], len(uniq))
+			uniq = append(uniq, v)
+		}
+	}
+	return uniq
+}
+
+// uniqKey returns a key for a normalized value such that values equal under deepEqualValues
+// have equal keys.  Unequal values may share a key.
+func uniqKey(value interface{}) string {
+	var b strings.Builder
+	writeUniqKey(&b, value)
+	return b.String()
+}
+
+func writeUniqKey(b *strings.Builder, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("n")
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("{")
+		for _, k := range keys {
+			b.WriteString(strconv.Quote(k))
+			writeUniqKey(b, v[k])
+		}
+		b.WriteString("}")
+	case []interface{}:
+		b.WriteString("[")
+		for _, elem := range v {
+			writeUniqKey(b, elem)
+			b.WriteString(",")
+		}
+		b.WriteString("]")
+	case time.Time:
+		b.WriteString("t" + v.UTC().Format(time.RFC3339Nano))
+	default:
+		rv := reflect.ValueOf(value)
+		switch {
+		case IsNumber(value):
+			// Numbers compare by value, so 1 and 1.0 share a key.
+			f, _ := toFloat(value)
+			if f == 0 {
+				f = 0 // -0
+			}
+			b.WriteString("f" + strconv.FormatFloat(f, 'g', -1, 64))
+		case rv.Kind() == reflect.String:
+			b.WriteString("s" + strconv.Quote(rv.String()))
+		case rv.Kind() == reflect.Bool:
+			b.WriteString("b" + strconv.FormatBool(rv.Bool()))
+		default:
+			b.WriteString("?")
+		}
+	}
+}
+
+// normalizer builds normalized copies of values for normalizeValue, counting the map entries
+// and slice elements it creates.  If limit is positive, creating more than limit of them is an
+// error.
+type normalizer struct {
+	elements int
+	limit    int
+}
+
+// errNestedTooDeep is returned by normalizer for values nested more than DefaultLimits.MaxDepth
+// levels deep.
+var errNestedTooDeep = errors.New("value is nested too deeply")
+
+// errTooManyElements is returned by normalizer for values with more elements than its limit.
+var errTooManyElements = errors.New("value has too many elements")
+
+// normalizeValue returns a copy of operand built from map[string]interface{} for maps and
+// structs and []interface{} for slices and arrays.  Other values are returned unchanged, except
+// for []byte, which is copied.  Values reached more than once are copied each time, so the copy
+// may hold at most DefaultLimits.MaxElements map entries and slice elements.
+func normalizeValue(operand interface{}) (interface{}, error) {
+	n := &normalizer{limit: DefaultLimits.MaxElements}
+	normalized, err := n.normalize(operand, 0)
+	return normalized, n.classify(err)
+}
+
+// normalizeArg is like normalizeValue, but reports errors as an *ArgumentError for argument arg
+// of fn.
+func (n *normalizer) normalizeArg(fn string, arg int, operand interface{}) (interface{}, error) {
+	normalized, err := n.normalize(operand, 0)
+	return normalized, wrapArgumentError(fn, arg, n.classify(err))
+}
+
+// classify replaces errNestedTooDeep and errTooManyElements, returned by n.normalize, with
+// errors that describe the limit and wrap ErrLimitExceeded.
+func (n *normalizer) classify(err error) error {
+	switch err {
+	case errNestedTooDeep:
+		return limitErrorf("value is nested more than %d levels deep", DefaultLimits.MaxDepth)
+	case errTooManyElements:
+		return limitErrorf("result of %d elements exceeds the limit of %d", n.elements, n.limit)
+	}
+	return err
+}
+
+func (n *normalizer) normalize(operand interface{}, depth int) (interface{}, error) {
+	if depth > DefaultLimits.MaxDepth {
+		return nil, errNestedTooDeep
+	}
+	if b, ok := operand.([]byte); ok {
+		return append([]byte(nil), b...), nil
+	}
+	v := derefValue(reflect.ValueOf(operand))
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		if n.elements += v.Len(); n.limit > 0 && n.elements > n.limit {
+			return nil, errTooManyElements
+		}
+	}
+	switch v.Kind() {
+	case reflect.Invalid, reflect.Interface, reflect.Ptr:
+		return nil, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("cannot use %s: map keys must be strings", v.Type())
+		}
+		m := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			value, err := n.normalize(v.MapIndex(key).Interface(), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			m[key.String()] = value
+		}
+		return m, nil
+	case reflect.Struct:
+		// Structs with a string form, such as time.Time, are values rather than records.
+		if _, ok := v.Interface().(fmt.Stringer); ok {
+			return v.Interface(), nil
+		}
+		m := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			value, err := n.normalize(v.Field(i).Interface(), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			m[v.Type().Field(i).Name] = value
+		}
+		return m, nil
+	case reflect.Slice, reflect.Array:
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			value, err := n.normalize(v.Index(i).Interface(), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = value
+		}
+		return s, nil
+	}
+	return v.Interface(), nil
+}
+
+func deepEqualValues(a, b interface{}) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			other, ok := bv[k]
+			if !ok || !deepEqualValues(v, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !deepEqualValues(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return equalValues(a, b)
+}
+
+// deepCopier copies values for DeepCopy.  It records the copy of each pointer, map, and slice
+// it has copied, so that a value reached more than once, such as one that contains itself, is
+// copied only once.
+type deepCopier struct {
+	copies map[copyKey]reflect.Value
+}
+
+// copyKey identifies a pointer, map, or slice by its type, address, and, for slices, length.
+type copyKey struct {
+	typ  reflect.Type
+	ptr  uintptr
+	size int
+}
+
+func (c *deepCopier) copy(v reflect.Value) reflect.Value {
+	var key copyKey
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		key = copyKey{typ: v.Type(), ptr: v.Pointer()}
+		if v.Kind() == reflect.Slice {
+			key.size = v.Len()
+		}
+		if copied, ok := c.copies[key]; ok {
+			return copied
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(c.copy(v.Elem()))
+		return copied
+	case reflect.Ptr:
+		copied := reflect.New(v.Type().Elem())
+		c.copies[key] = copied
+		copied.Elem().Set(c.copy(v.Elem()))
+		return copied
+	case reflect.Map:
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		c.copies[key] = copied
+		for _, k := range v.MapKeys() {
+			copied.SetMapIndex(k, c.copy(v.MapIndex(k)))
+		}
+		return copied
+	case reflect.Slice:
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		c.copies[key] = copied
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(c.copy(v.Index(i)))
+		}
+		return copied
+	case reflect.Array:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(c.copy(v.Index(i)))
+		}
+		return copied
+	case reflect.Struct:
+		// Unexported fields cannot be set, so they keep the values copied by Set.
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				copied.Field(i).Set(c.copy(v.Field(i)))
+			}
+		}
+		return copied
+	}
+	return v
+}
+
+func diffValues(path string, a, b interface{}, changes []Change) []Change {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := path + "/" + escapePointer(k)
+			old, inA := av[k]
+			value, inB := bv[k]
+			switch {
+			case !inB:
+				changes = append(changes, Change{Op: "remove", Path: childPath, Old: old})
+			case !inA:
+				changes = append(changes, Change{Op: "add", Path: childPath, New: value})
+			default:
+				changes = diffValues(childPath, old, value, changes)
+			}
+		}
+		return changes
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			changes = diffValues(path+"/"+strconv.Itoa(i), av[i], bv[i], changes)
+		}
+		for i := len(av); i < len(bv); i++ {
+			changes = append(changes, Change{Op: "add", Path: path + "/" + strconv.Itoa(i), New: bv[i]})
+		}
+		for i := len(av) - 1; i >= len(bv); i-- {
+			changes = append(changes, Change{Op: "remove", Path: path + "/" + strconv.Itoa(i), Old: av[i]})
+		}
+		return changes
+	}
+	if !deepEqualValues(a, b) {
+		changes = append(changes, Change{Op: "replace", Path: path, Old: a, New: b})
+	}
+	return changes
+}
+
+func mergePatchValue(patch, target interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatchValue(v, t[k])
+	}
+	return t
+}
+
+// decodeJSONArg decodes operand if it is a JSON string or []byte, and normalizes it otherwise.
+func decodeJSONArg(operand interface{}) (interface{}, error) {
+	var data []byte
+	switch v := operand.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return normalizeValue(operand)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+func decodePatch(patch interface{}) ([]map[string]interface{}, error) {
+	if changes, ok := patch.([]Change); ok {
+		ops := make([]map[string]interface{}, len(changes))
+		for i, c := range changes {
+			ops[i] = map[string]interface{}{"op": c.Op, "path": c.Path, "value": c.New}
+		}
+		return ops, nil
+	}
+	decoded, err := decodeJSONArg(patch)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := decoded.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("patch must be a list of operations, received %s", TypeOf(decoded))
+	}
+	ops := make([]map[string]interface{}, len(list))
+	for i, item := range list {
+		if ops[i], ok = item.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("patch operation %d must be an object, received %s", i, TypeOf(item))
+		}
+	}
+	return ops, nil
+}
+
+// applyPatchOp applies op to doc, charging the values it adds or copies to n.
+func applyPatchOp(n *normalizer, doc interface{}, op map[string]interface{}) (interface{}, error) {
+	path, err := patchPointer(op, "path")
+	if err != nil {
+		return nil, err
+	}
+	value, hasValue := op["value"]
+	needsValue := op["op"] == "add" || op["op"] == "replace" || op["op"] == "test"
+	if needsValue && !hasValue {
+		return nil, fmt.Errorf("missing value")
+	}
+	if hasValue {
+		if value, err = n.normalize(value, len(path)); err != nil {
+			return nil, n.classify(err)
+		}
+	}
+
+	switch op["op"] {
+	case "add":
+		return pointerUpdate(doc, path, value, addChild)
+	case "remove":
+		if len(path) == 0 {
+			return nil, fmt.Errorf("cannot remove the document root")
+		}
+		return pointerUpdate(doc, path, nil, removeChild)
+	case "replace":
+		return pointerUpdate(doc, path, value, replaceChild)
+	case "move", "copy":
+		from, err := patchPointer(op, "from")
+		if err != nil {
+			return nil, err
+		}
+		moved, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op["op"] == "move" {
+			if isPointerPrefix(from, path) && len(from) != len(path) {
+				return nil, fmt.Errorf("cannot move a value into itself")
+			}
+			if len(from) == 0 {
+				return nil, fmt.Errorf("cannot move the document root")
+			}
+			if doc, err = pointerUpdate(doc, from, nil, removeChild); err != nil {
+				return nil, err
+			}
+			// Moved values are not new, but may still end up nested more deeply.
+			if _, err = (&normalizer{}).normalize(moved, len(path)); err != nil {
+				return nil, n.classify(err)
+			}
+		} else if moved, err = n.normalize(moved, len(path)); err != nil {
+			return nil, n.classify(err)
+		}
+		return pointerUpdate(doc, path, moved, addChild)
+	case "test":
+		current, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !deepEqualValues(current, value) {
+			return nil, fmt.Errorf("test failed: value is %v", current)
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation %v", op["op"])
+}
+
+func patchPointer(op map[string]interface{}, field string) ([]string, error) {
+	s, ok := op[field].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing %s", field)
+	}
+	return parsePointer(s)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+func escapePointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+func isPointerPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func pointerGet(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("no value at key %q", token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := pointerIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("cannot index %s with %q", TypeOf(doc), token)
+		}
+	}
+	return doc, nil
+}
+
+type childUpdate func(parent interface{}, token string, value interface{}) (interface{}, error)
+
+// pointerUpdate applies update to the parent of the value at tokens, returning the new document.
+// An empty pointer replaces the whole document.
+func pointerUpdate(doc interface{}, tokens []string, value interface{}, update childUpdate) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	if len(tokens) == 1 {
+		return update(doc, tokens[0], value)
+	}
+	child, err := pointerGet(doc, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+	if child, err = pointerUpdate(child, tokens[1:], value, update); err != nil {
+		return nil, err
+	}
+	return replaceChild(doc, tokens[0], child)
+}
+
+func addChild(parent interface{}, token string, value interface{}) (interface{}, error) {
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[token] = value
+		return node, nil
+	case []interface{}:
+		i := len(node)
+		if token != "-" {
+			var err error
+			if i, err = pointerIndex(token, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		node = append(node, nil)
+		copy(node[i+1:], node[i:])
+		node[i] = value
+		return node, nil
+	}
+	return nil, fmt.Errorf("cannot add %q to %s", token, TypeOf(parent))
+}
+
+func removeChild(parent interface{}, token string, _ interface{}) (interface{}, error) {
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := node[token]; !ok {
+			return nil, fmt.Errorf("no value at key %q", token)
+		}
+		delete(node, token)
+		return node, nil
+	case []interface{}:
+		i, err := pointerIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		return append(node[:i], node[i+1:]...), nil
+	}
+	return nil, fmt.Errorf("cannot remove %q from %s", token, TypeOf(parent))
+}
+
+func replaceChild(parent interface{}, token string, value interface{}) (interface{}, error) {
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := node[token]; !ok {
+			return nil, fmt.Errorf("no value at key %q", token)
+		}
+		node[token] = value
+		return node, nil
+	case []interface{}:
+		i, err := pointerIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = value
+		return node, nil
+	}
+	return nil, fmt.Errorf("cannot replace %q in %s", token, TypeOf(parent))
+}
+
+// pointerIndex parses token as an array index no greater than max.
+func pointerIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
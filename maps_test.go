@@ -0,0 +1,369 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func decodeJSON(t *testing.T, data string) interface{} {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatalf("Failed to decode test JSON: %s", err)
+	}
+	return decoded
+}
+
+func TestDeepMerge(t *testing.T) {
+	defaults := decodeJSON(t, `{"replicas": 1, "image": {"name": "app", "tag": "latest"}, "ports": [80], "env": {"LOG": "info"}}`)
+	override := decodeJSON(t, `{"replicas": 3, "image": {"tag": "v2"}, "ports": [443, 80], "env": null}`)
+	var tests = []struct {
+		Strategy string
+		Layers   []interface{}
+		Expected string
+		Valid    bool
+	}{
+		{Strategy: "replace", Layers: []interface{}{defaults, override}, Expected: `{"replicas": 3, "image": {"name": "app", "tag": "v2"}, "ports": [443, 80], "env": null}`, Valid: true},
+		{Strategy: "append", Layers: []interface{}{defaults, override}, Expected: `{"replicas": 3, "image": {"name": "app", "tag": "v2"}, "ports": [80, 443, 80], "env": null}`, Valid: true},
+		{Strategy: "unique", Layers: []interface{}{defaults, override}, Expected: `{"replicas": 3, "image": {"name": "app", "tag": "v2"}, "ports": [80, 443], "env": null}`, Valid: true},
+		{Strategy: "replace", Layers: []interface{}{nil, map[string]int{"a": 1}, testOwner{Email: "x"}}, Expected: `{"a": 1, "Email": "x"}`, Valid: true},
+		{Strategy: "replace", Layers: []interface{}{}, Expected: `{}`, Valid: true},
+		{Strategy: "deep", Layers: []interface{}{defaults}, Valid: false},
+		{Strategy: "replace", Layers: []interface{}{defaults, []string{"a"}}, Valid: false},
+		{Strategy: "replace", Layers: []interface{}{map[int]int{1: 1}}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := DeepMerge(test.Strategy, test.Layers...)
+		if test.Valid && err != nil {
+			t.Errorf("DeepMerge encountered unexpected error: %s.  Strategy: %s", err, test.Strategy)
+		}
+		if !test.Valid {
+			if err == nil {
+				t.Errorf("DeepMerge failed to return an error.  Strategy: %s, Layers: %#v", test.Strategy, test.Layers)
+			}
+			continue
+		}
+		if expected := decodeJSON(t, test.Expected); !DeepEqual(result, expected) {
+			t.Errorf("DeepMerge result incorrect.  Strategy: %s, Expected: %#v, Received: %#v", test.Strategy, expected, result)
+		}
+	}
+
+	if !DeepEqual(defaults, decodeJSON(t, `{"replicas": 1, "image": {"name": "app", "tag": "latest"}, "ports": [80], "env": {"LOG": "info"}}`)) {
+		t.Errorf("DeepMerge modified its operands.  Received: %#v", defaults)
+	}
+}
+
+func TestDeepEqual(t *testing.T) {
+	when := time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		A        interface{}
+		Operand  interface{}
+		Expected bool
+	}{
+		{A: map[string]interface{}{"a": []interface{}{1, "x"}}, Operand: map[string]interface{}{"a": []interface{}{1.0, "x"}}, Expected: true},
+		{A: map[string]int{"a": 1}, Operand: map[string]interface{}{"a": int64(1)}, Expected: true},
+		{A: testOwner{Email: "x"}, Operand: map[string]string{"Email": "x"}, Expected: true},
+		{A: []int{1, 2}, Operand: [2]float64{1, 2}, Expected: true},
+		{A: when, Operand: when, Expected: true},
+		{A: map[string]interface{}{"a": 1}, Operand: map[string]interface{}{"a": 1, "b": nil}, Expected: false},
+		{A: []int{1, 2}, Operand: []int{2, 1}, Expected: false},
+		{A: "1", Operand: 1, Expected: false},
+		{A: nil, Operand: nil, Expected: true},
+	}
+
+	for _, test := range tests {
+		result := DeepEqual(test.A, test.Operand)
+		if result != test.Expected {
+			t.Errorf("DeepEqual result incorrect.  A: %#v, Operand: %#v, Expected: %t, Received: %t", test.A, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestDeepCopy(t *testing.T) {
+	owner := &testOwner{Email: "a@example.com"}
+	original := map[string]interface{}{
+		"list":   []interface{}{1, map[string]interface{}{"b": 2}},
+		"record": testRecord{Name: "api", Owner: owner},
+		"array":  [2][]int{{1}, {2}},
+	}
+	copied := DeepCopy(original).(map[string]interface{})
+	if !reflect.DeepEqual(copied, original) {
+		t.Fatalf("DeepCopy result incorrect.  Expected: %#v, Received: %#v", original, copied)
+	}
+
+	copied["list"].([]interface{})[1].(map[string]interface{})["b"] = 3
+	copied["record"].(testRecord).Owner.Email = "changed"
+	copied["array"].([2][]int)[0][0] = 9
+	if original["list"].([]interface{})[1].(map[string]interface{})["b"] != 2 || owner.Email != "a@example.com" || original["array"].([2][]int)[0][0] != 1 {
+		t.Errorf("DeepCopy result shares storage with operand.  Received: %#v", original)
+	}
+	if DeepCopy(nil) != nil {
+		t.Errorf("DeepCopy result incorrect for nil")
+	}
+
+	cyclic := map[string]interface{}{"list": []interface{}{1, nil}}
+	cyclic["self"] = cyclic
+	cyclic["list"].([]interface{})[1] = cyclic["list"]
+	copiedCycle := DeepCopy(cyclic).(map[string]interface{})
+	self := copiedCycle["self"].(map[string]interface{})
+	list := copiedCycle["list"].([]interface{})
+	if reflect.ValueOf(self).Pointer() != reflect.ValueOf(copiedCycle).Pointer() || reflect.ValueOf(list[1]).Pointer() != reflect.ValueOf(list).Pointer() {
+		t.Errorf("DeepCopy result incorrect for self-referential operand.  Received: %#v", copiedCycle)
+	}
+	if reflect.ValueOf(self).Pointer() == reflect.ValueOf(cyclic).Pointer() {
+		t.Errorf("DeepCopy result shares storage with self-referential operand")
+	}
+}
+
+func TestMapLimits(t *testing.T) {
+	saved := DefaultLimits
+	defer func() { DefaultLimits = saved }()
+	DefaultLimits.MaxElements = 4
+
+	cyclic := map[string]interface{}{}
+	cyclic["self"] = cyclic
+	if _, err := Diff(cyclic, map[string]interface{}{}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Diff failed to reject a self-referential operand.  Received: %v", err)
+	}
+	if DeepEqual(cyclic, cyclic) {
+		t.Errorf("DeepEqual result incorrect for a self-referential operand")
+	}
+
+	_, err := DeepMerge("append", map[string]interface{}{"a": []int{1, 2}}, map[string]interface{}{"a": []int{3, 4}})
+	var argErr *ArgumentError
+	if !errors.As(err, &argErr) || argErr.Err != ErrLimitExceeded || argErr.Arg != 2 {
+		t.Errorf("DeepMerge failed to enforce the element limit.  Received: %v", err)
+	}
+
+	pair := map[string]interface{}{"a": []int{1, 2}}
+	if _, err := Diff(pair, map[string]interface{}{"a": []int{1, 2, 3}}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Diff failed to enforce the element limit.  Received: %v", err)
+	}
+	if _, err := MergePatch(`{"b": [1, 2]}`, pair); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("MergePatch failed to enforce the element limit.  Received: %v", err)
+	}
+	if _, err := JSONPatch(`[{"op": "add", "path": "/b", "value": [1, 2]}]`, pair); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("JSONPatch failed to enforce the element limit on added values.  Received: %v", err)
+	}
+
+	// Each copy doubles the document, so the copies must be charged to the limit.
+	DefaultLimits = saved
+	doubling := make([]map[string]interface{}, 20)
+	for i := range doubling {
+		doubling[i] = map[string]interface{}{"op": "copy", "from": "/a", "path": "/a/-"}
+	}
+	_, err = JSONPatch(doubling, map[string]interface{}{"a": []interface{}{1, 2}})
+	if !errors.As(err, &argErr) || argErr.Err != ErrLimitExceeded || argErr.Func != "JSONPatch" {
+		t.Errorf("JSONPatch failed to enforce the element limit on copied values.  Received: %v", err)
+	}
+
+	// Sharing a map at every level makes the operand exponentially large once copied.
+	var shared interface{} = map[string]interface{}{}
+	for i := 0; i < 64; i++ {
+		shared = map[string]interface{}{"a": shared, "b": shared}
+	}
+	if DeepEqual(shared, shared) {
+		t.Errorf("DeepEqual result incorrect for an operand exceeding the element limit")
+	}
+	if _, err := MergePatch(map[string]interface{}{}, shared); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("MergePatch failed to enforce the element limit on a shared operand.  Received: %v", err)
+	}
+	if _, err := JSONPatch([]interface{}{}, shared); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("JSONPatch failed to enforce the element limit on a shared operand.  Received: %v", err)
+	}
+	if _, err := JSONPatch([]interface{}{shared}, map[string]interface{}{}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("JSONPatch failed to enforce the element limit on a shared patch.  Received: %v", err)
+	}
+	if _, err := MergePatch(shared, map[string]interface{}{}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("MergePatch failed to enforce the element limit on a shared patch.  Received: %v", err)
+	}
+
+	DefaultLimits.MaxDepth = 2
+	nesting := `[{"op": "copy", "from": "", "path": "/a"}, {"op": "copy", "from": "", "path": "/a"}]`
+	if _, err := JSONPatch(nesting, map[string]interface{}{"a": map[string]interface{}{}}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("JSONPatch failed to enforce the depth limit on copied values.  Received: %v", err)
+	}
+	if _, err := JSONPatch(`[{"op": "add", "path": "/a", "value": {"b": {"c": {}}}}]`, pair); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("JSONPatch failed to enforce the depth limit on added values.  Received: %v", err)
+	}
+}
+
+func TestMapBytes(t *testing.T) {
+	data := []byte("abc")
+	merged, err := DeepMerge("replace", map[string]interface{}{"data": data})
+	if err != nil {
+		t.Fatalf("DeepMerge encountered unexpected error: %s", err)
+	}
+	merged["data"].([]byte)[0] = 'x'
+	if string(data) != "abc" {
+		t.Errorf("DeepMerge result shares storage with operand.  Received: %s", data)
+	}
+}
+
+func TestUniqValues(t *testing.T) {
+	values := []interface{}{
+		1, 1.0, int64(1), "1", nil, nil, true, true,
+		map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1.0}, []interface{}{"x"}, []interface{}{"x"},
+		math.Copysign(0, -1), 0,
+		time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 1, 1, 0, 0, 0, time.FixedZone("CET", 3600)),
+	}
+	expected := []interface{}{
+		1, "1", nil, true, map[string]interface{}{"a": 1}, []interface{}{"x"}, math.Copysign(0, -1),
+		time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if result := uniqValues(values); !reflect.DeepEqual(result, expected) {
+		t.Errorf("uniqValues result incorrect.  Expected: %#v, Received: %#v", expected, result)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := decodeJSON(t, `{"name": "app", "replicas": 1, "ports": [80, 443, 8080], "labels": {"tier": "web"}, "old": true}`)
+	b := decodeJSON(t, `{"name": "app", "replicas": 3, "ports": [80, 8443], "labels": {"tier": "web", "a/b": "c"}, "new": {"x": 1}}`)
+	expected := []Change{
+		{Op: "add", Path: "/labels/a~1b", New: "c"},
+		{Op: "add", Path: "/new", New: map[string]interface{}{"x": 1.0}},
+		{Op: "remove", Path: "/old", Old: true},
+		{Op: "replace", Path: "/ports/1", Old: 443.0, New: 8443.0},
+		{Op: "remove", Path: "/ports/2", Old: 8080.0},
+		{Op: "replace", Path: "/replicas", Old: 1.0, New: 3.0},
+	}
+
+	result, err := Diff(a, b)
+	if err != nil {
+		t.Fatalf("Diff encountered unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Diff result incorrect.  Expected: %#v, Received: %#v", expected, result)
+	}
+
+	patched, err := JSONPatch(result, a)
+	if err != nil || !DeepEqual(patched, b) {
+		t.Errorf("JSONPatch of Diff result incorrect.  Expected: %#v, Received: %#v, Error: %v", b, patched, err)
+	}
+
+	if result, _ := Diff(a, a); len(result) != 0 {
+		t.Errorf("Diff result incorrect for equal values.  Received: %#v", result)
+	}
+	if result, _ := Diff([]int{1}, []int{1, 2, 3}); !reflect.DeepEqual(result, []Change{{Op: "add", Path: "/1", New: 2}, {Op: "add", Path: "/2", New: 3}}) {
+		t.Errorf("Diff result incorrect for slices.  Received: %#v", result)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	var tests = []struct {
+		Doc      string
+		Patch    string
+		Expected string
+		Valid    bool
+	}{
+		{Doc: `{"foo": "bar"}`, Patch: `[{"op": "add", "path": "/baz", "value": "qux"}]`, Expected: `{"baz": "qux", "foo": "bar"}`, Valid: true},
+		{Doc: `{"foo": ["bar", "baz"]}`, Patch: `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, Expected: `{"foo": ["bar", "qux", "baz"]}`, Valid: true},
+		{Doc: `{"foo": ["bar"]}`, Patch: `[{"op": "add", "path": "/foo/-", "value": ["abc"]}]`, Expected: `{"foo": ["bar", ["abc"]]}`, Valid: true},
+		{Doc: `{"baz": "qux", "foo": "bar"}`, Patch: `[{"op": "remove", "path": "/baz"}]`, Expected: `{"foo": "bar"}`, Valid: true},
+		{Doc: `{"foo": ["bar", "qux", "baz"]}`, Patch: `[{"op": "remove", "path": "/foo/1"}]`, Expected: `{"foo": ["bar", "baz"]}`, Valid: true},
+		{Doc: `{"baz": "qux", "foo": "bar"}`, Patch: `[{"op": "replace", "path": "/baz", "value": "boo"}]`, Expected: `{"baz": "boo", "foo": "bar"}`, Valid: true},
+		{Doc: `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`, Patch: `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`, Expected: `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`, Valid: true},
+		{Doc: `{"foo": ["all", "grass", "cows", "eat"]}`, Patch: `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`, Expected: `{"foo": ["all", "cows", "eat", "grass"]}`, Valid: true},
+		{Doc: `{"foo": {"a": 1}}`, Patch: `[{"op": "copy", "from": "/foo", "path": "/bar"}, {"op": "replace", "path": "/bar/a", "value": 2}]`, Expected: `{"foo": {"a": 1}, "bar": {"a": 2}}`, Valid: true},
+		{Doc: `{"baz": "qux", "foo": ["a", 2, "c"]}`, Patch: `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`, Expected: `{"baz": "qux", "foo": ["a", 2, "c"]}`, Valid: true},
+		{Doc: `{"/": 1, "~": 2}`, Patch: `[{"op": "replace", "path": "/~1", "value": 3}, {"op": "remove", "path": "/~0"}]`, Expected: `{"/": 3}`, Valid: true},
+		{Doc: `{"foo": 1}`, Patch: `[{"op": "replace", "path": "", "value": [1]}]`, Expected: `[1]`, Valid: true},
+		{Doc: `{"baz": "qux"}`, Patch: `[{"op": "test", "path": "/baz", "value": "bar"}]`, Valid: false},
+		{Doc: `{"foo": "bar"}`, Patch: `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`, Valid: false},
+		{Doc: `{"foo": "bar"}`, Patch: `[{"op": "replace", "path": "/baz", "value": "qux"}]`, Valid: false},
+		{Doc: `{"foo": ["bar"]}`, Patch: `[{"op": "add", "path": "/foo/2", "value": "qux"}]`, Valid: false},
+		{Doc: `{"foo": ["bar"]}`, Patch: `[{"op": "remove", "path": "/foo/01"}]`, Valid: false},
+		{Doc: `{"foo": {"a": 1}}`, Patch: `[{"op": "move", "from": "/foo", "path": "/foo/a/b"}]`, Valid: false},
+		{Doc: `{"foo": 1}`, Patch: `[{"op": "remove", "path": ""}]`, Valid: false},
+		{Doc: `{"foo": 1}`, Patch: `[{"op": "add", "path": "/bar"}]`, Valid: false},
+		{Doc: `{"foo": 1}`, Patch: `[{"op": "frob", "path": "/foo"}]`, Valid: false},
+		{Doc: `{"foo": 1}`, Patch: `[{"op": "add", "path": "foo", "value": 1}]`, Valid: false},
+		{Doc: `{"foo": 1}`, Patch: `{"op": "add"}`, Valid: false},
+		{Doc: `{"foo": 1}`, Patch: `[1]`, Valid: false},
+		{Doc: `{"foo": 1}`, Patch: `[`, Valid: false},
+	}
+
+	for _, test := range tests {
+		doc := decodeJSON(t, test.Doc)
+		result, err := JSONPatch(test.Patch, doc)
+		if test.Valid && err != nil {
+			t.Errorf("JSONPatch encountered unexpected error: %s.  Doc: %s, Patch: %s", err, test.Doc, test.Patch)
+		}
+		if !test.Valid {
+			if err == nil {
+				t.Errorf("JSONPatch failed to return an error.  Doc: %s, Patch: %s, Received: %#v", test.Doc, test.Patch, result)
+			}
+			continue
+		}
+		if expected := decodeJSON(t, test.Expected); !DeepEqual(result, expected) {
+			t.Errorf("JSONPatch result incorrect.  Doc: %s, Patch: %s, Expected: %#v, Received: %#v", test.Doc, test.Patch, expected, result)
+		}
+		if !DeepEqual(doc, decodeJSON(t, test.Doc)) {
+			t.Errorf("JSONPatch modified its operand.  Doc: %s, Received: %#v", test.Doc, doc)
+		}
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	var tests = []struct {
+		Doc      string
+		Patch    string
+		Expected string
+	}{
+		{Doc: `{"a": "b"}`, Patch: `{"a": "c"}`, Expected: `{"a": "c"}`},
+		{Doc: `{"a": "b"}`, Patch: `{"b": "c"}`, Expected: `{"a": "b", "b": "c"}`},
+		{Doc: `{"a": "b", "b": "c"}`, Patch: `{"a": null}`, Expected: `{"b": "c"}`},
+		{Doc: `{"a": ["b"]}`, Patch: `{"a": "c"}`, Expected: `{"a": "c"}`},
+		{Doc: `{"a": {"b": "c"}}`, Patch: `{"a": {"b": "d", "c": null}}`, Expected: `{"a": {"b": "d"}}`},
+		{Doc: `{"a": [{"b": "c"}]}`, Patch: `{"a": [1]}`, Expected: `{"a": [1]}`},
+		{Doc: `["a", "b"]`, Patch: `["c", "d"]`, Expected: `["c", "d"]`},
+		{Doc: `{"a": "foo"}`, Patch: `"bar"`, Expected: `"bar"`},
+		{Doc: `{"e": null}`, Patch: `{"a": 1}`, Expected: `{"e": null, "a": 1}`},
+		{Doc: `[1, 2]`, Patch: `{"a": "b", "c": null}`, Expected: `{"a": "b"}`},
+		{Doc: `{}`, Patch: `{"a": {"bb": {"ccc": null}}}`, Expected: `{"a": {"bb": {}}}`},
+	}
+
+	for _, test := range tests {
+		doc := decodeJSON(t, test.Doc)
+		result, err := MergePatch(test.Patch, doc)
+		if err != nil {
+			t.Errorf("MergePatch encountered unexpected error: %s.  Doc: %s, Patch: %s", err, test.Doc, test.Patch)
+		}
+		if expected := decodeJSON(t, test.Expected); !DeepEqual(result, expected) {
+			t.Errorf("MergePatch result incorrect.  Doc: %s, Patch: %s, Expected: %#v, Received: %#v", test.Doc, test.Patch, expected, result)
+		}
+	}
+
+	patch := map[string]interface{}{"a": map[string]interface{}{"b": nil}}
+	result, err := MergePatch(patch, map[string]interface{}{"a": map[string]int{"b": 1, "c": 2}})
+	if err != nil || !DeepEqual(result, map[string]interface{}{"a": map[string]interface{}{"c": 2}}) {
+		t.Errorf("MergePatch result incorrect for decoded patch.  Received: %#v, Error: %v", result, err)
+	}
+	if _, err := MergePatch("{", nil); err == nil {
+		t.Errorf("MergePatch failed to return an error for invalid JSON")
+	}
+}
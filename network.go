@@ -0,0 +1,165 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"math/big"
+	"net"
+	"strings"
+)
+
+/*
+ * Networking
+ */
+
+// ParseIP uses net.ParseIP to parse operand as an IPv4 or IPv6 address.
+func ParseIP(operand string) (net.IP, error) {
+	ip := net.ParseIP(operand)
+	if ip == nil {
+		return nil, argumentError("ParseIP", 0, ErrInvalidArgument, "invalid IP address %q", operand)
+	}
+	if !strings.Contains(operand, ":") {
+		ip = ip.To4()
+	}
+	return ip, nil
+}
+
+// IsIPv4 checks if operand is a valid IPv4 address in dotted decimal form.
+func IsIPv4(operand string) bool {
+	return net.ParseIP(operand) != nil && !strings.Contains(operand, ":")
+}
+
+// IsIPv6 checks if operand is a valid IPv6 address, including IPv4-mapped addresses
+// such as "::ffff:192.0.2.1".
+func IsIPv6(operand string) bool {
+	return net.ParseIP(operand) != nil && strings.Contains(operand, ":")
+}
+
+// ParseCIDR uses net.ParseCIDR to parse operand as a network in CIDR notation, such as
+// "192.0.2.0/24".  The host bits of operand are cleared.
+func ParseCIDR(operand string) (*net.IPNet, error) {
+	_, network, err := net.ParseCIDR(operand)
+	return network, wrapArgumentError("ParseCIDR", 0, err)
+}
+
+// CIDRContains checks if the network cidr contains the IP address operand.
+func CIDRContains(cidr string, operand string) (bool, error) {
+	network, err := ParseCIDR(cidr)
+	if err != nil {
+		return false, wrapArgumentError("CIDRContains", 0, err)
+	}
+	ip, err := ParseIP(operand)
+	if err != nil {
+		return false, wrapArgumentError("CIDRContains", 1, err)
+	}
+	return network.Contains(ip), nil
+}
+
+// CIDRHosts returns the host addresses of the network operand.  For IPv4 networks with a
+// prefix length of 30 or less, the network and broadcast addresses are excluded.  Networks
+// with more hosts than DefaultLimits.MaxElements return an error.
+func CIDRHosts(operand string) ([]string, error) {
+	network, err := ParseCIDR(operand)
+	if err != nil {
+		return nil, wrapArgumentError("CIDRHosts", 0, err)
+	}
+	ones, bits := network.Mask.Size()
+	first := ipToInt(network.IP)
+	count := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	if bits == 32 && ones <= 30 {
+		first.Add(first, big.NewInt(1))
+		count.Sub(count, big.NewInt(2))
+	}
+	if count.Cmp(big.NewInt(int64(DefaultLimits.MaxElements))) > 0 {
+		return nil, argumentError("CIDRHosts", 0, ErrLimitExceeded, "network %s has %s hosts, exceeding the limit of %d", operand, count, DefaultLimits.MaxElements)
+	}
+
+	hosts := make([]string, count.Int64())
+	for i := range hosts {
+		hosts[i] = intToIP(first, len(network.IP)).String()
+		first.Add(first, big.NewInt(1))
+	}
+	return hosts, nil
+}
+
+// CIDRSubnet calculates a subnet address within the network operand, like Terraform's
+// cidrsubnet function.  The prefix length of operand is extended by newbits, and netnum
+// selects which of the resulting subnets to return.  Example: {{ "10.0.0.0/16" | CIDRSubnet
+// 8 2 }} returns "10.0.2.0/24".
+func CIDRSubnet(newbits, netnum int, operand string) (string, error) {
+	network, err := ParseCIDR(operand)
+	if err != nil {
+		return "", wrapArgumentError("CIDRSubnet", 2, err)
+	}
+	ones, bits := network.Mask.Size()
+	if newbits < 0 || ones+newbits > bits {
+		return "", argumentError("CIDRSubnet", 0, ErrOutOfRange, "cannot extend prefix length of %s by %d bits", operand, newbits)
+	}
+	maxNetnum := new(big.Int).Lsh(big.NewInt(1), uint(newbits))
+	if netnum < 0 || big.NewInt(int64(netnum)).Cmp(maxNetnum) >= 0 {
+		return "", argumentError("CIDRSubnet", 1, ErrOutOfRange, "netnum %d is out of range for %d new bits", netnum, newbits)
+	}
+
+	subnet := ipToInt(network.IP)
+	subnet.Or(subnet, new(big.Int).Lsh(big.NewInt(int64(netnum)), uint(bits-ones-newbits)))
+	result := &net.IPNet{IP: intToIP(subnet, len(network.IP)), Mask: net.CIDRMask(ones+newbits, bits)}
+	return result.String(), nil
+}
+
+// IPAdd returns the IP address operand incremented by n.  N may be negative.  Results
+// outside of the address family's range return an error.
+func IPAdd(n int, operand string) (string, error) {
+	ip, err := ParseIP(operand)
+	if err != nil {
+		return "", wrapArgumentError("IPAdd", 1, err)
+	}
+	result := ipToInt(ip)
+	result.Add(result, big.NewInt(int64(n)))
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(len(ip)*8))
+	if result.Sign() < 0 || result.Cmp(limit) >= 0 {
+		return "", argumentError("IPAdd", 0, ErrOutOfRange, "adding %d to %s overflows the address range", n, operand)
+	}
+	return intToIP(result, len(ip)).String(), nil
+}
+
+// IPCompare compares the IP addresses a and operand, returning -1 if operand is less than a,
+// 0 if they are equal, and 1 if operand is greater than a.  IPv4 addresses are compared as
+// IPv4-mapped IPv6 addresses.
+func IPCompare(a, operand string) (int, error) {
+	ipA, err := ParseIP(a)
+	if err != nil {
+		return 0, wrapArgumentError("IPCompare", 0, err)
+	}
+	ipOp, err := ParseIP(operand)
+	if err != nil {
+		return 0, wrapArgumentError("IPCompare", 1, err)
+	}
+	return ipToInt(ipOp.To16()).Cmp(ipToInt(ipA.To16())), nil
+}
+
+func ipToInt(ip net.IP) *big.Int { return new(big.Int).SetBytes(ip) }
+
+func intToIP(i *big.Int, size int) net.IP {
+	ip := make(net.IP, size)
+	b := i.Bytes()
+	copy(ip[size-len(b):], b)
+	return ip
+}
@@ -0,0 +1,252 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIP(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Expected string
+		Length   int
+		Valid    bool
+	}{
+		{Operand: "192.0.2.1", Expected: "192.0.2.1", Length: 4, Valid: true},
+		{Operand: "2001:DB8::1", Expected: "2001:db8::1", Length: 16, Valid: true},
+		{Operand: "::ffff:192.0.2.1", Expected: "192.0.2.1", Length: 16, Valid: true},
+		{Operand: "192.0.2.256", Valid: false},
+		{Operand: "example.com", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := ParseIP(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("ParseIP encountered unexpected error: %s.  Operand: %s", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("ParseIP failed to return an error.  Operand: %s, Received: %s", test.Operand, result)
+		}
+		if test.Valid && (result.String() != test.Expected || len(result) != test.Length) {
+			t.Errorf("ParseIP result incorrect.  Operand: %s, Expected: %s (%d bytes), Received: %s (%d bytes)", test.Operand, test.Expected, test.Length, result, len(result))
+		}
+	}
+}
+
+func TestIsIPv4(t *testing.T) {
+	var tests = []struct {
+		Operand string
+		IPv4    bool
+		IPv6    bool
+	}{
+		{Operand: "192.0.2.1", IPv4: true},
+		{Operand: "2001:db8::1", IPv6: true},
+		{Operand: "::ffff:192.0.2.1", IPv6: true},
+		{Operand: "192.0.2", IPv4: false},
+		{Operand: "", IPv4: false},
+	}
+
+	for _, test := range tests {
+		if result := IsIPv4(test.Operand); result != test.IPv4 {
+			t.Errorf("IsIPv4 result incorrect.  Operand: %s, Expected: %t, Received: %t", test.Operand, test.IPv4, result)
+		}
+		if result := IsIPv6(test.Operand); result != test.IPv6 {
+			t.Errorf("IsIPv6 result incorrect.  Operand: %s, Expected: %t, Received: %t", test.Operand, test.IPv6, result)
+		}
+	}
+}
+
+func TestCIDRContains(t *testing.T) {
+	var tests = []struct {
+		CIDR     string
+		Operand  string
+		Expected bool
+		Valid    bool
+	}{
+		{CIDR: "10.0.0.0/8", Operand: "10.1.2.3", Expected: true, Valid: true},
+		{CIDR: "10.0.0.0/8", Operand: "11.0.0.0", Expected: false, Valid: true},
+		{CIDR: "10.1.2.3/8", Operand: "10.255.255.255", Expected: true, Valid: true},
+		{CIDR: "2001:db8::/32", Operand: "2001:db8:1::1", Expected: true, Valid: true},
+		{CIDR: "2001:db8::/32", Operand: "10.0.0.1", Expected: false, Valid: true},
+		{CIDR: "10.0.0.0/33", Operand: "10.0.0.1", Valid: false},
+		{CIDR: "10.0.0.0/8", Operand: "10.0.0", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := CIDRContains(test.CIDR, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("CIDRContains encountered unexpected error: %s.  CIDR: %s, Operand: %s", err, test.CIDR, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("CIDRContains failed to return an error.  CIDR: %s, Operand: %s", test.CIDR, test.Operand)
+		}
+		if result != test.Expected {
+			t.Errorf("CIDRContains result incorrect.  CIDR: %s, Operand: %s, Expected: %t, Received: %t", test.CIDR, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestCIDRHosts(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Expected []string
+		Valid    bool
+	}{
+		{Operand: "192.0.2.0/30", Expected: []string{"192.0.2.1", "192.0.2.2"}, Valid: true},
+		{Operand: "192.0.2.7/29", Expected: []string{"192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4", "192.0.2.5", "192.0.2.6"}, Valid: true},
+		{Operand: "192.0.2.0/31", Expected: []string{"192.0.2.0", "192.0.2.1"}, Valid: true},
+		{Operand: "192.0.2.9/32", Expected: []string{"192.0.2.9"}, Valid: true},
+		{Operand: "2001:db8::/126", Expected: []string{"2001:db8::", "2001:db8::1", "2001:db8::2", "2001:db8::3"}, Valid: true},
+		{Operand: "10.0.0.0/8", Valid: false},
+		{Operand: "2001:db8::/32", Valid: false},
+		{Operand: "dog", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := CIDRHosts(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("CIDRHosts encountered unexpected error: %s.  Operand: %s", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("CIDRHosts failed to return an error.  Operand: %s, Received: %d hosts", test.Operand, len(result))
+		}
+		if !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("CIDRHosts result incorrect.  Operand: %s, Expected: %#v, Received: %#v", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestCIDRHostsLimit(t *testing.T) {
+	defer func(limits Limits) { DefaultLimits = limits }(DefaultLimits)
+	DefaultLimits.MaxElements = 2
+	if _, err := CIDRHosts("192.0.2.0/30"); err != nil {
+		t.Errorf("CIDRHosts encountered unexpected error: %s", err)
+	}
+	if _, err := CIDRHosts("192.0.2.0/29"); err == nil {
+		t.Errorf("CIDRHosts failed to enforce DefaultLimits.MaxElements")
+	}
+}
+
+func TestCIDRSubnet(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Newbits  int
+		Netnum   int
+		Expected string
+		Valid    bool
+	}{
+		{Operand: "10.0.0.0/16", Newbits: 8, Netnum: 2, Expected: "10.0.2.0/24", Valid: true},
+		{Operand: "172.16.0.0/12", Newbits: 4, Netnum: 15, Expected: "172.31.0.0/16", Valid: true},
+		{Operand: "10.1.2.0/24", Newbits: 4, Netnum: 1, Expected: "10.1.2.16/28", Valid: true},
+		{Operand: "10.0.0.0/16", Newbits: 0, Netnum: 0, Expected: "10.0.0.0/16", Valid: true},
+		{Operand: "fd00:fd12:3456:7890::/56", Newbits: 16, Netnum: 162, Expected: "fd00:fd12:3456:7800:a200::/72", Valid: true},
+		{Operand: "10.0.0.0/16", Newbits: 8, Netnum: 256, Valid: false},
+		{Operand: "10.0.0.0/16", Newbits: 8, Netnum: -1, Valid: false},
+		{Operand: "10.0.0.0/16", Newbits: 17, Netnum: 0, Valid: false},
+		{Operand: "10.0.0.0/16", Newbits: -1, Netnum: 0, Valid: false},
+		{Operand: "10.0.0.0", Newbits: 8, Netnum: 0, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := CIDRSubnet(test.Newbits, test.Netnum, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("CIDRSubnet encountered unexpected error: %s.  Operand: %s, Newbits: %d, Netnum: %d", err, test.Operand, test.Newbits, test.Netnum)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("CIDRSubnet failed to return an error.  Operand: %s, Newbits: %d, Netnum: %d, Received: %s", test.Operand, test.Newbits, test.Netnum, result)
+		}
+		if result != test.Expected {
+			t.Errorf("CIDRSubnet result incorrect.  Operand: %s, Newbits: %d, Netnum: %d, Expected: %s, Received: %s", test.Operand, test.Newbits, test.Netnum, test.Expected, result)
+		}
+	}
+}
+
+func TestIPAdd(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		N        int
+		Expected string
+		Valid    bool
+	}{
+		{Operand: "192.0.2.1", N: 1, Expected: "192.0.2.2", Valid: true},
+		{Operand: "192.0.2.255", N: 1, Expected: "192.0.3.0", Valid: true},
+		{Operand: "192.0.2.1", N: -2, Expected: "192.0.1.255", Valid: true},
+		{Operand: "2001:db8::ffff", N: 1, Expected: "2001:db8::1:0", Valid: true},
+		{Operand: "255.255.255.255", N: 1, Valid: false},
+		{Operand: "0.0.0.0", N: -1, Valid: false},
+		{Operand: "dog", N: 1, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := IPAdd(test.N, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("IPAdd encountered unexpected error: %s.  Operand: %s, N: %d", err, test.Operand, test.N)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("IPAdd failed to return an error.  Operand: %s, N: %d, Received: %s", test.Operand, test.N, result)
+		}
+		if result != test.Expected {
+			t.Errorf("IPAdd result incorrect.  Operand: %s, N: %d, Expected: %s, Received: %s", test.Operand, test.N, test.Expected, result)
+		}
+	}
+}
+
+func TestIPCompare(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		A        string
+		Expected int
+		Valid    bool
+	}{
+		{Operand: "10.0.0.2", A: "10.0.0.10", Expected: -1, Valid: true},
+		{Operand: "10.0.0.10", A: "10.0.0.2", Expected: 1, Valid: true},
+		{Operand: "10.0.0.1", A: "::ffff:10.0.0.1", Expected: 0, Valid: true},
+		{Operand: "2001:db8::1", A: "10.0.0.1", Expected: 1, Valid: true},
+		{Operand: "10.0.0.1", A: "dog", Valid: false},
+		{Operand: "dog", A: "10.0.0.1", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := IPCompare(test.A, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("IPCompare encountered unexpected error: %s.  Operand: %s, A: %s", err, test.Operand, test.A)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("IPCompare failed to return an error.  Operand: %s, A: %s", test.Operand, test.A)
+		}
+		if result != test.Expected {
+			t.Errorf("IPCompare result incorrect.  Operand: %s, A: %s, Expected: %d, Received: %d", test.Operand, test.A, test.Expected, result)
+		}
+	}
+}
+
+func TestParseCIDR(t *testing.T) {
+	operand, expected := "192.0.2.17/24", "192.0.2.0/24"
+	result, err := ParseCIDR(operand)
+	if err != nil {
+		t.Errorf("ParseCIDR encountered unexpected error: %s.  Operand: %s", err, operand)
+	}
+	if result.String() != expected {
+		t.Errorf("ParseCIDR result incorrect.  Operand: %s, Expected: %s, Received: %s", operand, expected, result)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"io"
+	"reflect"
+	"time"
+)
+
+// Options configures the functions returned by NewFuncMap.
+type Options struct {
+	// Rand is the source of random bytes for functions such as UUIDv4 and RandomHex.  If nil,
+	// crypto/rand.Reader is used.  Supplying a seeded source, such as a *math/rand.Rand,
+	// makes those functions deterministic and avoids reading host entropy.
+	Rand io.Reader
+
+	// Clock returns the current time for functions such as Now and UUIDv7.  If nil,
+	// time.Now is used.
+	Clock func() time.Time
+
+	// Context, if non-nil, is checked by every function before it runs, and by MapExpr,
+	// FilterExpr, and Query at each step of evaluation.  Once the context is done, functions
+	// return its error instead, which stops template execution at the next function call.
+	Context context.Context
+}
+
+// env holds the sources bound to functions that depend on the environment.  The exported
+// package-level functions use defaultEnv.
+type env struct {
+	rand  io.Reader
+	clock func() time.Time
+
+	// funcs holds the functions callable from expressions evaluated by MapExpr, FilterExpr,
+	// and Query, and ctx, if non-nil, stops their evaluation.
+	funcs map[string]interface{}
+	ctx   context.Context
+}
+
+var defaultEnv = env{rand: cryptorand.Reader, clock: time.Now}
+
+// NewFuncMap returns a copy of FuncMap with the functions that depend on randomness or the
+// current time bound to the sources in opts, and every function bound to opts.Context if it is
+// set.  It is meant for use with text/template.Template.Funcs() in place of FuncMap.
+func NewFuncMap(opts Options) map[string]interface{} {
+	e := defaultEnv
+	if opts.Rand != nil {
+		e.rand = opts.Rand
+	}
+	if opts.Clock != nil {
+		e.clock = opts.Clock
+	}
+
+	funcs := make(map[string]interface{}, len(FuncMap))
+	for name, fn := range FuncMap {
+		funcs[name] = fn
+	}
+	funcs["Now"] = e.now
+	funcs["RandomAlphaNum"] = e.randomAlphaNum
+	funcs["RandomBytesBase64"] = e.randomBytesBase64
+	funcs["RandomChoice"] = e.randomChoice
+	funcs["RandomHex"] = e.randomHex
+	funcs["RandomInt"] = e.randomInt
+	funcs["RandomPassword"] = e.randomPassword
+	funcs["Sample"] = e.sample
+	funcs["UUIDv4"] = e.uuidV4
+	funcs["UUIDv7"] = e.uuidV7
+	funcs["WeightedChoice"] = e.weightedChoice
+	if opts.Context != nil {
+		funcs = bindContext(opts.Context, funcs)
+	}
+
+	// Expressions call the functions bound above rather than those in FuncMap.
+	e.funcs, e.ctx = exprFuncs(funcs), opts.Context
+	funcs["FilterExpr"] = e.filterExpr
+	funcs["MapExpr"] = e.mapExpr
+	funcs["Query"] = e.query
+	funcs["QueryOne"] = e.queryOne
+	return funcs
+}
+
+func (e env) now() time.Time { return e.clock() }
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// bindContext returns a copy of funcs in which each function returns ctx.Err() without running
+// once ctx is done.  Functions returning a single value are changed to also return an error.
+func bindContext(ctx context.Context, funcs map[string]interface{}) map[string]interface{} {
+	bound := make(map[string]interface{}, len(funcs))
+	for name, fn := range funcs {
+		bound[name] = bindFuncContext(ctx, fn)
+	}
+	return bound
+}
+
+func bindFuncContext(ctx context.Context, fn interface{}) interface{} {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumOut() < 1 || t.NumOut() > 2 {
+		return fn
+	}
+	in := make([]reflect.Type, t.NumIn())
+	for i := range in {
+		in[i] = t.In(i)
+	}
+	out := []reflect.Type{t.Out(0), errorType}
+	wrapped := reflect.FuncOf(in, out, t.IsVariadic())
+	return reflect.MakeFunc(wrapped, func(args []reflect.Value) []reflect.Value {
+		if err := ctx.Err(); err != nil {
+			return []reflect.Value{reflect.Zero(out[0]), reflect.ValueOf(&err).Elem()}
+		}
+		var results []reflect.Value
+		if t.IsVariadic() {
+			results = v.CallSlice(args)
+		} else {
+			results = v.Call(args)
+		}
+		if len(results) == 1 {
+			results = append(results, reflect.Zero(errorType))
+		}
+		return results
+	}).Interface()
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestNewFuncMap(t *testing.T) {
+	clock := time.Date(2016, 4, 1, 12, 0, 0, 0, time.UTC)
+	render := func() string {
+		funcs := NewFuncMap(Options{
+			Rand:  rand.New(rand.NewSource(7)),
+			Clock: func() time.Time { return clock },
+		})
+		tmpl := template.Must(template.New("test").Funcs(funcs).Parse(`{{ UUIDv4 }} {{ UUIDv7 }} {{ Now.Year }} {{ Split "," "a,b" | Join "+" }}`))
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, nil); err != nil {
+			t.Fatalf("Template execution encountered unexpected error: %s", err)
+		}
+		return buf.String()
+	}
+
+	first, second := render(), render()
+	if first != second {
+		t.Errorf("NewFuncMap output not deterministic.  First: %s, Second: %s", first, second)
+	}
+	if !bytes.HasSuffix([]byte(first), []byte(" 2016 a+b")) {
+		t.Errorf("NewFuncMap output incorrect.  Received: %s", first)
+	}
+	if len(NewFuncMap(Options{})) != len(FuncMap) {
+		t.Errorf("NewFuncMap returned an incomplete map")
+	}
+}
+
+func TestNewFuncMapContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	funcs := NewFuncMap(Options{Context: ctx})
+	calls := 0
+	funcs["Tick"] = bindFuncContext(ctx, func(n int) int {
+		if calls++; calls == 10 {
+			cancel()
+		}
+		return n
+	})
+
+	tmpl := template.Must(template.New("test").Funcs(funcs).Parse(`{{ range Seq 1 1000 }}{{ Tick . | Add 1 }}{{ end }}`))
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("NewFuncMap failed to stop execution on cancellation.  Received: %v", err)
+	}
+	if calls != 10 {
+		t.Errorf("NewFuncMap function ran after cancellation.  Calls: %d", calls)
+	}
+
+	// Expressions stop at their next step, not only before the call.
+	mapExpr := funcs["MapExpr"].(func(string, interface{}) ([]interface{}, error))
+	if _, err := mapExpr("x + 1", []int{1}); !errors.Is(err, context.Canceled) {
+		t.Errorf("MapExpr failed to stop on cancellation.  Received: %v", err)
+	}
+	query := funcs["Query"].(func(string, interface{}) ([]interface{}, error))
+	if _, err := query("$..*", []int{1}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Query failed to stop on cancellation.  Received: %v", err)
+	}
+
+	var tests = []struct {
+		Template string
+		Expected string
+	}{
+		{Template: `{{ Split "," "a,b" | Join "+" }}`, Expected: "a+b"},
+		{Template: `{{ Dict "a" 1 "b" 2 | Entries | len }}`, Expected: "2"},
+		{Template: `{{ Seq 1 5 2 | len }}`, Expected: "3"},
+	}
+	live := NewFuncMap(Options{Context: context.Background()})
+	for _, test := range tests {
+		tmpl := template.Must(template.New("test").Funcs(live).Parse(test.Template))
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, nil); err != nil {
+			t.Errorf("Template execution encountered unexpected error: %s.  Template: %s", err, test.Template)
+		}
+		if buf.String() != test.Expected {
+			t.Errorf("NewFuncMap template output incorrect.  Template: %s, Expected: %s, Received: %s", test.Template, test.Expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// pluralOperands holds the CLDR plural operands of a number: i is the integer digits and v
+// is the number of visible fraction digits.  The other CLDR operands aren't needed by the
+// rules haven embeds.
+type pluralOperands struct {
+	n float64
+	i int64
+	v int
+}
+
+// pluralRule returns the CLDR cardinal plural category for the given operands.
+type pluralRule func(op pluralOperands) string
+
+// pluralForms lists, for each supported language, the plural categories in the order used by
+// gettext's msgstr[N] entries, followed by the rule selecting between them.  Languages are keyed
+// by base language, or by full tag where a region's rules differ from its base language's.
+var pluralForms = map[string]struct {
+	categories []string
+	rule       pluralRule
+}{
+	"de":    {categories: []string{"one", "other"}, rule: pluralOneInteger},
+	"en":    {categories: []string{"one", "other"}, rule: pluralOneInteger},
+	"es":    {categories: []string{"one", "many", "other"}, rule: pluralSpanish},
+	"fr":    {categories: []string{"one", "many", "other"}, rule: pluralFrench},
+	"it":    {categories: []string{"one", "other"}, rule: pluralOneInteger},
+	"ja":    {categories: []string{"other"}, rule: func(pluralOperands) string { return "other" }},
+	"nl":    {categories: []string{"one", "other"}, rule: pluralOneInteger},
+	"pl":    {categories: []string{"one", "few", "many"}, rule: pluralPolish},
+	"pt":    {categories: []string{"one", "many", "other"}, rule: pluralFrench},
+	"pt-pt": {categories: []string{"one", "many", "other"}, rule: pluralEuropeanPortuguese},
+	"ru":    {categories: []string{"one", "few", "many"}, rule: pluralRussian},
+	"sv":    {categories: []string{"one", "other"}, rule: pluralOneInteger},
+	"tr":    {categories: []string{"one", "other"}, rule: pluralOneExact},
+}
+
+// lookupPluralForms returns the plural forms of lang, falling back to its base language.
+func lookupPluralForms(lang string) (categories []string, rule pluralRule, err error) {
+	forms, ok := pluralForms[normalizeLanguage(lang)]
+	if !ok {
+		forms, ok = pluralForms[baseLanguage(lang)]
+	}
+	if !ok {
+		return nil, nil, fmt.Errorf("no plural rules for language %q", lang)
+	}
+	return forms.categories, forms.rule, nil
+}
+
+// one: i = 1 and v = 0
+func pluralOneInteger(op pluralOperands) string {
+	if op.i == 1 && op.v == 0 {
+		return "one"
+	}
+	return "other"
+}
+
+// one: n = 1
+func pluralOneExact(op pluralOperands) string {
+	if op.n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+// one: n = 1
+// many: e = 0 and i != 0 and i % 1000000 = 0 and v = 0
+func pluralSpanish(op pluralOperands) string {
+	if op.n == 1 {
+		return "one"
+	}
+	if pluralMillions(op) {
+		return "many"
+	}
+	return "other"
+}
+
+// one: i = 0,1
+// many: e = 0 and i != 0 and i % 1000000 = 0 and v = 0
+func pluralFrench(op pluralOperands) string {
+	if op.i == 0 || op.i == 1 {
+		return "one"
+	}
+	if pluralMillions(op) {
+		return "many"
+	}
+	return "other"
+}
+
+// one: i = 1 and v = 0
+// many: e = 0 and i != 0 and i % 1000000 = 0 and v = 0
+func pluralEuropeanPortuguese(op pluralOperands) string {
+	if op.i == 1 && op.v == 0 {
+		return "one"
+	}
+	if pluralMillions(op) {
+		return "many"
+	}
+	return "other"
+}
+
+// pluralMillions reports whether op is a non-zero whole number of millions.  Haven never
+// formats counts in compact exponent notation, so the CLDR operand e is always 0.
+func pluralMillions(op pluralOperands) bool {
+	return op.v == 0 && op.i != 0 && op.i%1000000 == 0
+}
+
+// one: v = 0 and i % 10 = 1 and i % 100 != 11
+// few: v = 0 and i % 10 = 2..4 and i % 100 != 12..14
+// many: v = 0 and (i % 10 = 0 or i % 10 = 5..9 or i % 100 = 11..14)
+func pluralRussian(op pluralOperands) string {
+	if op.v != 0 {
+		return "other"
+	}
+	mod10, mod100 := op.i%10, op.i%100
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return "one"
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return "few"
+	}
+	return "many"
+}
+
+// one: i = 1 and v = 0
+// few: v = 0 and i % 10 = 2..4 and i % 100 != 12..14
+// many: v = 0 and i != 1 and (i % 10 = 0..1 or i % 10 = 5..9 or i % 100 = 12..14)
+func pluralPolish(op pluralOperands) string {
+	if op.v != 0 {
+		return "other"
+	}
+	mod10, mod100 := op.i%10, op.i%100
+	switch {
+	case op.i == 1:
+		return "one"
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return "few"
+	}
+	return "many"
+}
+
+// pluralCategory returns the CLDR plural category of count in lang.
+func pluralCategory(lang string, count interface{}) (string, error) {
+	_, rule, err := lookupPluralForms(lang)
+	if err != nil {
+		return "", err
+	}
+	op, err := newPluralOperands(count)
+	if err != nil {
+		return "", err
+	}
+	return rule(op), nil
+}
+
+// newPluralOperands computes the plural operands of count.  String counts preserve visible
+// fraction digits, so "1.0" has v = 1 while the float 1.0 has v = 0.
+func newPluralOperands(count interface{}) (pluralOperands, error) {
+	var formatted string
+	switch reflect.ValueOf(count).Kind() {
+	case reflect.String:
+		formatted = strings.TrimSpace(reflect.ValueOf(count).String())
+	case reflect.Float32, reflect.Float64:
+		formatted = strconv.FormatFloat(reflect.ValueOf(count).Float(), 'f', -1, 64)
+	default:
+		i64, err := toInt64(count)
+		if err != nil {
+			return pluralOperands{}, err
+		}
+		formatted = strconv.FormatInt(i64, 10)
+	}
+
+	n, err := strconv.ParseFloat(formatted, 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return pluralOperands{}, fmt.Errorf("invalid plural count %q", formatted)
+	}
+	n = math.Abs(n)
+	op := pluralOperands{n: n, i: int64(n)}
+	if n >= 1e18 {
+		// The rules only examine i%1000000 and whether i is 0 or 1, so reduce i to a value
+		// that fits in an int64 with the same trailing digits.
+		op.i = int64(math.Mod(math.Trunc(n), 1e6)) + 1e6
+	}
+	if i := strings.Index(formatted, "."); i >= 0 {
+		op.v = len(formatted) - i - 1
+	}
+	return op, nil
+}
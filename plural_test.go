@@ -0,0 +1,90 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"testing"
+)
+
+func TestPluralCategory(t *testing.T) {
+	var tests = []struct {
+		Lang     string
+		Count    interface{}
+		Expected string
+		Valid    bool
+	}{
+		{Lang: "en", Count: 1, Expected: "one", Valid: true},
+		{Lang: "en", Count: 0, Expected: "other", Valid: true},
+		{Lang: "en", Count: 2, Expected: "other", Valid: true},
+		{Lang: "en", Count: 1.5, Expected: "other", Valid: true},
+		{Lang: "en", Count: "1.0", Expected: "other", Valid: true},
+		{Lang: "en", Count: -1, Expected: "one", Valid: true},
+		{Lang: "fr", Count: 0, Expected: "one", Valid: true},
+		{Lang: "fr", Count: 1.5, Expected: "one", Valid: true},
+		{Lang: "fr", Count: 2, Expected: "other", Valid: true},
+		{Lang: "fr", Count: 1000000, Expected: "many", Valid: true},
+		{Lang: "fr", Count: 2000000, Expected: "many", Valid: true},
+		{Lang: "fr", Count: 1000001, Expected: "other", Valid: true},
+		{Lang: "fr", Count: "1000000.0", Expected: "other", Valid: true},
+		{Lang: "fr", Count: 1e21, Expected: "many", Valid: true},
+		{Lang: "es", Count: "1.0", Expected: "one", Valid: true},
+		{Lang: "es", Count: 0, Expected: "other", Valid: true},
+		{Lang: "es", Count: 1000000, Expected: "many", Valid: true},
+		{Lang: "pt", Count: 0, Expected: "one", Valid: true},
+		{Lang: "pt-BR", Count: 1.5, Expected: "one", Valid: true},
+		{Lang: "pt-PT", Count: 0, Expected: "other", Valid: true},
+		{Lang: "pt_PT", Count: 1, Expected: "one", Valid: true},
+		{Lang: "pt-PT", Count: "1.0", Expected: "other", Valid: true},
+		{Lang: "pt-PT", Count: 1000000, Expected: "many", Valid: true},
+		{Lang: "ja", Count: 1, Expected: "other", Valid: true},
+		{Lang: "ru", Count: 1, Expected: "one", Valid: true},
+		{Lang: "ru", Count: 21, Expected: "one", Valid: true},
+		{Lang: "ru", Count: 11, Expected: "many", Valid: true},
+		{Lang: "ru", Count: 3, Expected: "few", Valid: true},
+		{Lang: "ru", Count: 22, Expected: "few", Valid: true},
+		{Lang: "ru", Count: 13, Expected: "many", Valid: true},
+		{Lang: "ru", Count: 5, Expected: "many", Valid: true},
+		{Lang: "ru", Count: 0, Expected: "many", Valid: true},
+		{Lang: "ru", Count: 1.5, Expected: "other", Valid: true},
+		{Lang: "ru", Count: 1e21, Expected: "many", Valid: true},
+		{Lang: "pl", Count: 1, Expected: "one", Valid: true},
+		{Lang: "pl", Count: 21, Expected: "many", Valid: true},
+		{Lang: "pl", Count: 22, Expected: "few", Valid: true},
+		{Lang: "pl", Count: 12, Expected: "many", Valid: true},
+		{Lang: "pl", Count: "0.5", Expected: "other", Valid: true},
+		{Lang: "xx", Count: 1, Valid: false},
+		{Lang: "en", Count: "dog", Valid: false},
+		{Lang: "en", Count: []int{1}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := pluralCategory(test.Lang, test.Count)
+		if test.Valid && err != nil {
+			t.Errorf("pluralCategory encountered unexpected error: %s.  Lang: %s, Count: %#v", err, test.Lang, test.Count)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("pluralCategory failed to return an error.  Lang: %s, Count: %#v, Received: %s", test.Lang, test.Count, result)
+		}
+		if result != test.Expected {
+			t.Errorf("pluralCategory result incorrect.  Lang: %s, Count: %#v, Expected: %s, Received: %s", test.Lang, test.Count, test.Expected, result)
+		}
+	}
+}
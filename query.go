@@ -0,0 +1,551 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+ * Queries
+ *
+ * Query and QueryOne select values from decoded data, such as the result of json.Unmarshal,
+ * using the following subset of JSONPath:
+ *
+ *	$                   The root.  It may be omitted: "store.book" is "$.store.book".
+ *	.name, ['name']     A map key or exported struct field.  Within quotes, a backslash
+ *	                    escapes the next character, such as ['it\'s'].
+ *	.*, [*]             Every map value, struct field, or slice element.  Map values are
+ *	                    ordered by key.
+ *	[n]                 A slice element.  Negative indexes count from the end.
+ *	[start:end:step]    A range of slice elements, with Python slice semantics.
+ *	[a,b]               A union of names or indexes.
+ *	..name, ..*, ..[]   Recursive descent: the selector applied to a value and its descendants.
+ *	[?(expr)]           The elements or map values for which expr holds, where expr is written
+ *	                    in the language of MapExpr with @ as the candidate value, such as
+ *	                    [?(@.price < 10)].  A non-bool result tests for existence.
+ *
+ * Missing keys and out-of-range indexes select nothing rather than returning an error.  Within a
+ * filter, a field or index that does not exist selects nothing, but other errors, such as
+ * comparing a string with a number in [?(@.price < "x")], are returned.
+ * Evaluation is bounded by DefaultLimits.MaxSteps and DefaultLimits.MaxElements, and recursive
+ * descent by DefaultLimits.MaxDepth.
+ */
+
+// Query returns the values within operand selected by path:
+// {{ range Query "$.store.book[?(@.price < 10)].title" . }}...{{ end }}.
+func Query(path string, operand interface{}) ([]interface{}, error) {
+	return defaultEnv.query(path, operand)
+}
+
+// QueryOne returns the single value within operand selected by path.  Selecting zero or
+// multiple values is an error.
+func QueryOne(path string, operand interface{}) (interface{}, error) {
+	return defaultEnv.queryOne(path, operand)
+}
+
+func (e env) query(path string, operand interface{}) ([]interface{}, error) {
+	return e.selectPath("Query", path, operand)
+}
+
+func (e env) selectPath(fn, path string, operand interface{}) ([]interface{}, error) {
+	segments, err := parseQuery(fn, path, e.funcs)
+	if err != nil {
+		return nil, err
+	}
+	q := &queryEval{path: path, state: &exprState{ctx: e.ctx}}
+	nodes := []interface{}{operand}
+	for _, segment := range segments {
+		if nodes, err = q.apply(segment, nodes); err != nil {
+			return nil, wrapArgumentError(fn, 0, err)
+		}
+	}
+	return nodes, nil
+}
+
+func (e env) queryOne(path string, operand interface{}) (interface{}, error) {
+	nodes, err := e.selectPath("QueryOne", path, operand)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) != 1 {
+		return nil, argumentError("QueryOne", 0, ErrInvalidArgument, "query %q selected %d values, expected exactly 1", path, len(nodes))
+	}
+	return nodes[0], nil
+}
+
+type querySelector int
+
+const (
+	selectNames querySelector = iota
+	selectIndexes
+	selectWildcard
+	selectSlice
+	selectFilter
+)
+
+type querySegment struct {
+	recursive bool
+	selector  querySelector
+	names     []string
+	indexes   []int
+	slice     [3]*int
+	filter    *expression
+}
+
+/*
+ * Query parsing
+ */
+
+func parseQuery(fn, path string, funcs map[string]interface{}) ([]querySegment, error) {
+	p := &queryParser{fn: fn, path: path, funcs: funcs}
+	segments, err := p.parse()
+	if _, ok := err.(*ArgumentError); ok {
+		return nil, err
+	}
+	if err != nil {
+		return nil, argumentError(fn, 0, ErrInvalidArgument, "invalid query %q: %s", path, err)
+	}
+	return segments, nil
+}
+
+type queryParser struct {
+	fn    string
+	path  string
+	pos   int
+	funcs map[string]interface{}
+}
+
+func (p *queryParser) parse() ([]querySegment, error) {
+	var segments []querySegment
+	if strings.HasPrefix(p.path, "$") {
+		p.pos++
+	} else if p.path != "" && p.path[0] != '.' && p.path[0] != '[' {
+		segments = append(segments, querySegment{selector: selectNames, names: []string{p.name()}})
+	}
+	for p.pos < len(p.path) {
+		var segment querySegment
+		switch {
+		case strings.HasPrefix(p.path[p.pos:], ".."):
+			p.pos += 2
+			segment.recursive = true
+			if p.pos < len(p.path) && p.path[p.pos] == '[' {
+				break
+			}
+			fallthrough
+		case p.path[p.pos] == '.':
+			if !segment.recursive {
+				p.pos++
+			}
+			if p.pos < len(p.path) && p.path[p.pos] == '*' {
+				p.pos++
+				segment.selector = selectWildcard
+			} else if name := p.name(); name != "" {
+				segment.selector, segment.names = selectNames, []string{name}
+			} else {
+				return nil, fmt.Errorf("expected name at offset %d", p.pos)
+			}
+			segments = append(segments, segment)
+			continue
+		case p.path[p.pos] != '[':
+			return nil, fmt.Errorf("unexpected %q at offset %d", p.path[p.pos], p.pos)
+		}
+		if err := p.bracket(&segment); err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+func (p *queryParser) name() string {
+	start := p.pos
+	for p.pos < len(p.path) && p.path[p.pos] != '.' && p.path[p.pos] != '[' {
+		p.pos++
+	}
+	return p.path[start:p.pos]
+}
+
+// bracket parses a bracketed selector starting at the opening bracket.
+func (p *queryParser) bracket(segment *querySegment) error {
+	p.pos++
+	if strings.HasPrefix(p.path[p.pos:], "*]") {
+		p.pos += 2
+		segment.selector = selectWildcard
+		return nil
+	}
+	if strings.HasPrefix(p.path[p.pos:], "?(") {
+		end, err := p.filterEnd(p.pos + 2)
+		if err != nil {
+			return err
+		}
+		filter, err := compileExpr(p.fn, p.path[p.pos+2:end], "@", "", p.funcs)
+		if err != nil {
+			return err
+		}
+		filter.strict = true
+		p.pos = end + 2
+		segment.selector, segment.filter = selectFilter, filter
+		return nil
+	}
+
+	start := p.pos
+	items, slice, err := p.bracketItems()
+	if err != nil {
+		return err
+	}
+	body := p.path[start : p.pos-1]
+	if slice {
+		return parseQuerySlice(body, segment)
+	}
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if len(item) >= 2 && (item[0] == '\'' || item[0] == '"') && item[len(item)-1] == item[0] {
+			segment.names = append(segment.names, unescapeQueryName(item[1:len(item)-1]))
+			continue
+		}
+		i, err := strconv.Atoi(item)
+		if err != nil {
+			return fmt.Errorf("invalid selector %q", item)
+		}
+		segment.indexes = append(segment.indexes, i)
+	}
+	switch {
+	case len(segment.names) > 0 && len(segment.indexes) > 0:
+		return fmt.Errorf("cannot mix names and indexes in %q", body)
+	case len(segment.names) > 0:
+		segment.selector = selectNames
+	default:
+		segment.selector = selectIndexes
+	}
+	return nil
+}
+
+// bracketItems scans the body of a bracketed selector, starting after the opening bracket, and
+// returns its comma-separated items and whether it contains a colon.  Commas, colons, and
+// brackets within quoted names are part of the name.  On return, p.pos follows the closing
+// bracket.
+func (p *queryParser) bracketItems() (items []string, slice bool, err error) {
+	start, itemStart := p.pos, p.pos
+	for ; p.pos < len(p.path); p.pos++ {
+		switch c := p.path[p.pos]; c {
+		case '"', '\'':
+			for p.pos++; p.pos < len(p.path) && p.path[p.pos] != c; p.pos++ {
+				if p.path[p.pos] == '\\' {
+					p.pos++
+				}
+			}
+		case ':':
+			slice = true
+		case ',':
+			items = append(items, p.path[itemStart:p.pos])
+			itemStart = p.pos + 1
+		case ']':
+			items = append(items, p.path[itemStart:p.pos])
+			p.pos++
+			return items, slice, nil
+		}
+	}
+	return nil, false, fmt.Errorf("unterminated bracket at offset %d", start-1)
+}
+
+// unescapeQueryName removes the backslashes from a quoted name, so that \' and \\ stand for a
+// quote and a backslash.
+func unescapeQueryName(name string) string {
+	if !strings.Contains(name, "\\") {
+		return name
+	}
+	var buf strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+1 < len(name) {
+			i++
+		}
+		buf.WriteByte(name[i])
+	}
+	return buf.String()
+}
+
+// filterEnd returns the offset of the closing ")]" of a filter whose expression starts at start.
+func (p *queryParser) filterEnd(start int) (int, error) {
+	depth := 0
+	for i := start; i < len(p.path); i++ {
+		switch c := p.path[i]; c {
+		case '"', '\'':
+			for i++; i < len(p.path) && p.path[i] != c; i++ {
+				if p.path[i] == '\\' {
+					i++
+				}
+			}
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				if !strings.HasPrefix(p.path[i:], ")]") {
+					return 0, fmt.Errorf("expected \")]\" at offset %d", i)
+				}
+				return i, nil
+			}
+			depth--
+		}
+	}
+	return 0, fmt.Errorf("unterminated filter at offset %d", start-2)
+}
+
+func parseQuerySlice(body string, segment *querySegment) error {
+	parts := strings.Split(body, ":")
+	if len(parts) > 3 {
+		return fmt.Errorf("invalid slice %q", body)
+	}
+	for i, part := range parts {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return fmt.Errorf("invalid slice %q", body)
+		}
+		segment.slice[i] = &n
+	}
+	if segment.slice[2] != nil && *segment.slice[2] == 0 {
+		return fmt.Errorf("invalid slice %q: step cannot be zero", body)
+	}
+	segment.selector = selectSlice
+	return nil
+}
+
+/*
+ * Query evaluation
+ */
+
+type queryEval struct {
+	path  string
+	state *exprState
+}
+
+func (q *queryEval) step() error {
+	q.state.steps++
+	if q.state.steps > DefaultLimits.MaxSteps {
+		return limitErrorf("query %q exceeded the limit of %d steps", q.path, DefaultLimits.MaxSteps)
+	}
+	return q.state.done()
+}
+
+func (q *queryEval) apply(segment querySegment, nodes []interface{}) ([]interface{}, error) {
+	if segment.recursive {
+		var descendants []interface{}
+		for _, node := range nodes {
+			var err error
+			if descendants, err = q.descend(node, descendants, 0); err != nil {
+				return nil, err
+			}
+		}
+		nodes = descendants
+	}
+	var selected []interface{}
+	for _, node := range nodes {
+		if err := q.step(); err != nil {
+			return nil, err
+		}
+		matches, err := q.selectFrom(segment, node)
+		if err != nil {
+			return nil, err
+		}
+		if selected = append(selected, matches...); len(selected) > DefaultLimits.MaxElements {
+			return nil, limitErrorf("query %q selected more than %d values", q.path, DefaultLimits.MaxElements)
+		}
+	}
+	if selected == nil {
+		selected = []interface{}{}
+	}
+	return selected, nil
+}
+
+// descend appends node and all of its descendants to nodes in document order.  Depth is the
+// number of levels node lies below the value where descent began.
+func (q *queryEval) descend(node interface{}, nodes []interface{}, depth int) ([]interface{}, error) {
+	if depth > DefaultLimits.MaxDepth {
+		return nil, limitErrorf("query %q descended more than %d levels deep", q.path, DefaultLimits.MaxDepth)
+	}
+	if err := q.step(); err != nil {
+		return nil, err
+	}
+	if nodes = append(nodes, node); len(nodes) > DefaultLimits.MaxElements {
+		return nil, limitErrorf("query %q visited more than %d values", q.path, DefaultLimits.MaxElements)
+	}
+	for _, child := range queryChildren(node) {
+		var err error
+		if nodes, err = q.descend(child, nodes, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+func (q *queryEval) selectFrom(segment querySegment, node interface{}) ([]interface{}, error) {
+	switch segment.selector {
+	case selectNames:
+		var selected []interface{}
+		for _, name := range segment.names {
+			if value, ok := queryChild(node, name); ok {
+				selected = append(selected, value)
+			}
+		}
+		return selected, nil
+	case selectWildcard:
+		return queryChildren(node), nil
+	case selectFilter:
+		var selected []interface{}
+		for _, child := range queryChildren(node) {
+			result, err := segment.filter.eval(q.state, child, 0)
+			if errors.Is(err, errMissing) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("query %q: %w", q.path, err)
+			}
+			if b, ok := result.(bool); ok && b || !ok && result != nil {
+				selected = append(selected, child)
+			}
+		}
+		return selected, nil
+	}
+
+	v := derefValue(reflect.ValueOf(node))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, nil
+	}
+	var selected []interface{}
+	if segment.selector == selectIndexes {
+		for _, i := range segment.indexes {
+			if i < 0 {
+				i += v.Len()
+			}
+			if i >= 0 && i < v.Len() {
+				selected = append(selected, v.Index(i).Interface())
+			}
+		}
+		return selected, nil
+	}
+	for _, i := range sliceIndexes(segment.slice, v.Len()) {
+		selected = append(selected, v.Index(i).Interface())
+	}
+	return selected, nil
+}
+
+// sliceIndexes returns the indexes selected by a [start:end:step] slice of a sequence of length n.
+func sliceIndexes(bounds [3]*int, n int) []int {
+	step := 1
+	if bounds[2] != nil {
+		// Steps beyond the length of the slice select at most one index, so clamping them
+		// keeps i += step from overflowing.
+		step = Max(-(n + 1), Min(*bounds[2], n+1))
+	}
+	clamp := func(bound *int, def int) int {
+		if bound == nil {
+			return def
+		}
+		i := *bound
+		if i < 0 {
+			i += n
+		}
+		if step > 0 {
+			return Max(0, Min(i, n))
+		}
+		return Max(-1, Min(i, n-1))
+	}
+	var indexes []int
+	if step > 0 {
+		for i := clamp(bounds[0], 0); i < clamp(bounds[1], n); i += step {
+			indexes = append(indexes, i)
+		}
+	} else {
+		for i := clamp(bounds[0], n-1); i > clamp(bounds[1], -1); i += step {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// queryChild returns the map value or exported struct field of node named name.
+func queryChild(node interface{}, name string) (interface{}, bool) {
+	v := derefValue(reflect.ValueOf(node))
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if !value.IsValid() {
+			return nil, false
+		}
+		return value.Interface(), true
+	case reflect.Struct:
+		field, ok := v.Type().FieldByName(name)
+		if !ok || field.PkgPath != "" {
+			return nil, false
+		}
+		if value, ok := fieldByIndex(v, field.Index); ok {
+			return value.Interface(), true
+		}
+		return nil, false
+	}
+	return nil, false
+}
+
+// queryChildren returns the map values ordered by key, exported struct fields, or slice
+// elements of node.
+func queryChildren(node interface{}) []interface{} {
+	v := derefValue(reflect.ValueOf(node))
+	var children []interface{}
+	switch v.Kind() {
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			children = append(children, v.MapIndex(key).Interface())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				children = append(children, v.Field(i).Interface())
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			children = append(children, v.Index(i).Interface())
+		}
+	}
+	return children
+}
+
+func derefValue(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testStore = `{
+	"store": {
+		"book": [
+			{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+			{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+			{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+			{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+		],
+		"bicycle": {"color": "red", "price": 19.95}
+	},
+	"content-type": "catalog"
+}`
+
+func decodeStore(t *testing.T) interface{} {
+	var store interface{}
+	if err := json.Unmarshal([]byte(testStore), &store); err != nil {
+		t.Fatalf("Failed to decode test store: %s", err)
+	}
+	return store
+}
+
+func TestQuery(t *testing.T) {
+	store := decodeStore(t)
+	var tests = []struct {
+		Path     string
+		Expected []interface{}
+		Valid    bool
+	}{
+		{Path: "$.store.book[0].title", Expected: []interface{}{"Sayings of the Century"}, Valid: true},
+		{Path: "store.book[-1].author", Expected: []interface{}{"J. R. R. Tolkien"}, Valid: true},
+		{Path: "$['content-type']", Expected: []interface{}{"catalog"}, Valid: true},
+		{Path: `$.store.bicycle["color","price"]`, Expected: []interface{}{"red", 19.95}, Valid: true},
+		{Path: "$.store.book[*].price", Expected: []interface{}{8.95, 12.99, 8.99, 22.99}, Valid: true},
+		{Path: "$.store.book[0,2].price", Expected: []interface{}{8.95, 8.99}, Valid: true},
+		{Path: "$.store.book[1:3].price", Expected: []interface{}{12.99, 8.99}, Valid: true},
+		{Path: "$.store.book[:2].price", Expected: []interface{}{8.95, 12.99}, Valid: true},
+		{Path: "$.store.book[::-2].price", Expected: []interface{}{22.99, 12.99}, Valid: true},
+		{Path: "$.store.book[-2:].price", Expected: []interface{}{8.99, 22.99}, Valid: true},
+		{Path: "$.store.book[1::9223372036854775807].price", Expected: []interface{}{12.99}, Valid: true},
+		{Path: "$.store.book[::-9223372036854775808].price", Expected: []interface{}{22.99}, Valid: true},
+		{Path: "$.store.book[-9223372036854775808:9223372036854775807:3].price", Expected: []interface{}{8.95, 22.99}, Valid: true},
+		{Path: "$.store.book[9223372036854775807:-9223372036854775808:-1].price", Expected: []interface{}{22.99, 8.99, 12.99, 8.95}, Valid: true},
+		{Path: "$.store.book[9223372036854775807:].price", Expected: []interface{}{}, Valid: true},
+		{Path: "$.store.*.color", Expected: []interface{}{"red"}, Valid: true},
+		{Path: "$..price", Expected: []interface{}{19.95, 8.95, 12.99, 8.99, 22.99}, Valid: true},
+		{Path: "$..book[2].isbn", Expected: []interface{}{"0-553-21311-3"}, Valid: true},
+		{Path: "$..[?(@.isbn)].title", Expected: []interface{}{"Moby Dick", "The Lord of the Rings"}, Valid: true},
+		{Path: "$.store.book[?(@.price < 10)].title", Expected: []interface{}{"Sayings of the Century", "Moby Dick"}, Valid: true},
+		{Path: `$.store.book[?(@.category == "fiction" && HasPrefix("The", @.title))].price`, Expected: []interface{}{22.99}, Valid: true},
+		{Path: `$.store.book[?(Contains(")]", @.title))]`, Expected: []interface{}{}, Valid: true},
+		{Path: `$.store.book[?(@.isbn != "x")].title`, Expected: []interface{}{"Moby Dick", "The Lord of the Rings"}, Valid: true},
+		{Path: `$.store.book[?(@.price < "x")]`, Valid: false},
+		{Path: `$.store.book[?(!@.price)]`, Valid: false},
+		{Path: "$.store.book[9].title", Expected: []interface{}{}, Valid: true},
+		{Path: "$.missing.deeper", Expected: []interface{}{}, Valid: true},
+		{Path: "$.store.book[", Valid: false},
+		{Path: "$.store.book[1:2:0]", Valid: false},
+		{Path: "$.store.book[a]", Valid: false},
+		{Path: "$.store.book[?(@.price <)]", Valid: false},
+		{Path: "$.store.book[?(@.price < 10]", Valid: false},
+		{Path: "$.store.", Valid: false},
+		{Path: "$store", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Query(test.Path, store)
+		if test.Valid && err != nil {
+			t.Errorf("Query encountered unexpected error: %s.  Path: %s", err, test.Path)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Query failed to return an error.  Path: %s, Received: %#v", test.Path, result)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Query result incorrect.  Path: %s, Expected: %#v, Received: %#v", test.Path, test.Expected, result)
+		}
+	}
+}
+
+func TestQueryStructs(t *testing.T) {
+	result, err := Query("[?(@.Score > 8)].Name", testRecords)
+	if err != nil || !reflect.DeepEqual(result, []interface{}{"web"}) {
+		t.Errorf("Query result incorrect for structs.  Received: %#v, Error: %v", result, err)
+	}
+	result, err = Query("$..Email", testRecords)
+	if err != nil || !reflect.DeepEqual(result, []interface{}{"a@example.com", "c@example.com"}) {
+		t.Errorf("Query result incorrect for struct descent.  Received: %#v, Error: %v", result, err)
+	}
+
+	type embedded struct {
+		*testOwner
+	}
+	result, err = Query("[*].Email", []embedded{{&testOwner{Email: "a@example.com"}}, {}})
+	if err != nil || !reflect.DeepEqual(result, []interface{}{"a@example.com"}) {
+		t.Errorf("Query result incorrect for nil embedded structs.  Received: %#v, Error: %v", result, err)
+	}
+}
+
+func TestQueryQuotedNames(t *testing.T) {
+	operand := map[string]interface{}{"a,b": 1, "x]y": 2, "c:d": 3, "it's": 4, "a": 5, "b": 6}
+	var tests = []struct {
+		Path     string
+		Expected []interface{}
+		Valid    bool
+	}{
+		{Path: "$['a,b']", Expected: []interface{}{1}, Valid: true},
+		{Path: "$['x]y']", Expected: []interface{}{2}, Valid: true},
+		{Path: `$["c:d"]`, Expected: []interface{}{3}, Valid: true},
+		{Path: `$['it\'s']`, Expected: []interface{}{4}, Valid: true},
+		{Path: `$["it's"]`, Expected: []interface{}{4}, Valid: true},
+		{Path: "$['a,b', 'x]y', 'a']", Expected: []interface{}{1, 2, 5}, Valid: true},
+		{Path: "$['a','b']", Expected: []interface{}{5, 6}, Valid: true},
+		{Path: "$['x]y'", Valid: false},
+		{Path: "$['x]", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Query(test.Path, operand)
+		if test.Valid && err != nil {
+			t.Errorf("Query encountered unexpected error: %s.  Path: %s", err, test.Path)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("Query failed to return an error.  Path: %s, Received: %#v", test.Path, result)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Query result incorrect.  Path: %s, Expected: %#v, Received: %#v", test.Path, test.Expected, result)
+		}
+	}
+}
+
+func TestQueryOne(t *testing.T) {
+	store := decodeStore(t)
+	var tests = []struct {
+		Path     string
+		Expected interface{}
+		Valid    bool
+	}{
+		{Path: "$.store.bicycle.color", Expected: "red", Valid: true},
+		{Path: "$.store.book[?(@.author == 'Herman Melville')].price", Expected: 8.99, Valid: true},
+		{Path: "$.store.book[*].price", Valid: false},
+		{Path: "$.store.car", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := QueryOne(test.Path, store)
+		if test.Valid && err != nil {
+			t.Errorf("QueryOne encountered unexpected error: %s.  Path: %s", err, test.Path)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("QueryOne failed to return an error.  Path: %s, Received: %#v", test.Path, result)
+		}
+		if test.Valid && result != test.Expected {
+			t.Errorf("QueryOne result incorrect.  Path: %s, Expected: %#v, Received: %#v", test.Path, test.Expected, result)
+		}
+	}
+}
+
+func TestQueryLimits(t *testing.T) {
+	saved := DefaultLimits
+	defer func() { DefaultLimits = saved }()
+	store := decodeStore(t)
+
+	DefaultLimits.MaxSteps = 10
+	_, err := Query("$..price", store)
+	if err == nil || !strings.Contains(err.Error(), "limit of 10 steps") {
+		t.Errorf("Query failed to enforce step limit.  Received: %v", err)
+	}
+
+	DefaultLimits = saved
+	DefaultLimits.MaxElements = 3
+	if _, err := Query("$.store.book[*]", store); err == nil {
+		t.Errorf("Query failed to enforce element limit")
+	}
+
+	DefaultLimits = saved
+	DefaultLimits.MaxDepth = 5
+	var nested interface{} = "leaf"
+	for i := 0; i < 10; i++ {
+		nested = []interface{}{nested}
+	}
+	_, err = Query("$..*", nested)
+	if !errors.Is(err, ErrLimitExceeded) || !strings.Contains(err.Error(), "more than 5 levels") {
+		t.Errorf("Query failed to enforce depth limit on recursive descent.  Received: %v", err)
+	}
+
+	DefaultLimits = saved
+	path := "$[?(" + strings.Repeat("(", 100000) + "@" + strings.Repeat(")", 100000) + ")]"
+	_, err = QueryOne(path, []int{1})
+	var argErr *ArgumentError
+	if !errors.Is(err, ErrLimitExceeded) || !errors.As(err, &argErr) || argErr.Func != "QueryOne" || argErr.Arg != 0 {
+		t.Errorf("QueryOne failed to enforce depth limit.  Received: %.100v", err)
+	}
+}
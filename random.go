@@ -0,0 +1,225 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	alphaNumChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	lowerChars    = "abcdefghijklmnopqrstuvwxyz"
+	upperChars    = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars    = "0123456789"
+	symbolChars   = "!#$%&*+-=?@^_~"
+)
+
+var passwordClasses = map[string]string{
+	"lower":  lowerChars,
+	"upper":  upperChars,
+	"digit":  digitChars,
+	"symbol": symbolChars,
+}
+
+/*
+ * Secure Randomness
+ */
+
+// RandomAlphaNum returns a random string of length ASCII letters and digits.  The random
+// functions read from crypto/rand, or from Options.Rand for functions returned by NewFuncMap.
+// Lengths greater than DefaultLimits.MaxElements return an error.
+func RandomAlphaNum(length int) (string, error) { return defaultEnv.randomAlphaNum(length) }
+
+// RandomHex returns a random string of length lowercase hexadecimal digits.
+func RandomHex(length int) (string, error) { return defaultEnv.randomHex(length) }
+
+// RandomBytesBase64 returns count random bytes encoded with standard base64 encoding.
+func RandomBytesBase64(count int) (string, error) { return defaultEnv.randomBytesBase64(count) }
+
+// RandomInt returns a uniformly distributed random integer n such that min <= n < max.
+func RandomInt(min, max int) (int, error) { return defaultEnv.randomInt(min, max) }
+
+// RandomChoice returns a random element of the slice or array operand.  An empty operand
+// returns an error.
+func RandomChoice(operand interface{}) (interface{}, error) { return defaultEnv.randomChoice(operand) }
+
+// RandomPassword returns a random password of length characters drawn from the character
+// classes listed in classes.  Classes is a comma-separated list of "lower", "upper", "digit",
+// and "symbol", each optionally followed by a colon and the minimum number of characters
+// required from that class, such as "lower,upper,digit:2,symbol:1".  The minimum defaults
+// to 1.  The symbol class is limited to characters that are safe in most shells and
+// configuration formats: !#$%&*+-=?@^_~
+func RandomPassword(classes string, length int) (string, error) {
+	return defaultEnv.randomPassword(classes, length)
+}
+
+func (e env) randomAlphaNum(length int) (string, error) {
+	if err := checkRandomLength("RandomAlphaNum", 0, length); err != nil {
+		return "", err
+	}
+	return e.randomString(alphaNumChars, length)
+}
+
+func (e env) randomHex(length int) (string, error) {
+	if err := checkRandomLength("RandomHex", 0, length); err != nil {
+		return "", err
+	}
+	buf := make([]byte, (length+1)/2)
+	if _, err := io.ReadFull(e.rand, buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf)[:length], nil
+}
+
+func (e env) randomBytesBase64(count int) (string, error) {
+	if err := checkRandomLength("RandomBytesBase64", 0, count); err != nil {
+		return "", err
+	}
+	buf := make([]byte, count)
+	if _, err := io.ReadFull(e.rand, buf); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+func (e env) randomInt(min, max int) (int, error) {
+	if max <= min {
+		return 0, argumentError("RandomInt", 1, ErrInvalidArgument, "range [%d, %d) is empty: max must be greater than min", min, max)
+	}
+	n, err := e.uniform(uint64(int64(max) - int64(min)))
+	if err != nil {
+		return 0, err
+	}
+	return int(int64(min) + int64(n)), nil
+}
+
+func (e env) randomChoice(operand interface{}) (interface{}, error) {
+	v := reflect.ValueOf(operand)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, argumentError("RandomChoice", 0, ErrInvalidArgument, "cannot choose from %s: expected slice or array", TypeOf(operand))
+	}
+	if v.Len() == 0 {
+		return nil, argumentError("RandomChoice", 0, ErrInvalidArgument, "cannot choose from an empty %s", TypeOf(operand))
+	}
+	i, err := e.uniform(uint64(v.Len()))
+	if err != nil {
+		return nil, err
+	}
+	return v.Index(int(i)).Interface(), nil
+}
+
+func (e env) randomPassword(classes string, length int) (string, error) {
+	if err := checkRandomLength("RandomPassword", 1, length); err != nil {
+		return "", err
+	}
+	var (
+		alphabet string
+		required []byte
+	)
+	for _, class := range strings.Split(classes, ",") {
+		name, min := strings.TrimSpace(class), 1
+		if i := strings.IndexByte(name, ':'); i >= 0 {
+			parsed, err := strconv.Atoi(name[i+1:])
+			if err != nil || parsed < 0 {
+				return "", argumentError("RandomPassword", 0, ErrInvalidArgument, "invalid minimum in password class %q", class)
+			}
+			name, min = name[:i], parsed
+		}
+		chars, ok := passwordClasses[name]
+		if !ok {
+			return "", argumentError("RandomPassword", 0, ErrInvalidArgument, "unknown password class %q: expected lower, upper, digit, or symbol", name)
+		}
+		if strings.Contains(alphabet, chars) {
+			return "", argumentError("RandomPassword", 0, ErrInvalidArgument, "password class %q listed more than once", name)
+		}
+		if len(required)+min > length {
+			return "", argumentError("RandomPassword", 1, ErrOutOfRange, "password classes %q require more than %d characters", classes, length)
+		}
+		alphabet += chars
+		for i := 0; i < min; i++ {
+			c, err := e.randomString(chars, 1)
+			if err != nil {
+				return "", err
+			}
+			required = append(required, c[0])
+		}
+	}
+
+	rest, err := e.randomString(alphabet, length-len(required))
+	if err != nil {
+		return "", err
+	}
+	password := append(required, rest...)
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := e.uniform(uint64(i + 1))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+// randomString returns length characters chosen from alphabet.  Callers check length.
+func (e env) randomString(alphabet string, length int) (string, error) {
+	buf := make([]byte, length)
+	for i := range buf {
+		n, err := e.uniform(uint64(len(alphabet)))
+		if err != nil {
+			return "", err
+		}
+		buf[i] = alphabet[n]
+	}
+	return string(buf), nil
+}
+
+// uniform returns a uniformly distributed random integer in [0, n), using rejection sampling
+// to avoid modulo bias.
+func (e env) uniform(n uint64) (uint64, error) {
+	limit := math.MaxUint64 - math.MaxUint64%n
+	var buf [8]byte
+	for {
+		if _, err := io.ReadFull(e.rand, buf[:]); err != nil {
+			return 0, err
+		}
+		if v := binary.BigEndian.Uint64(buf[:]); v < limit {
+			return v % n, nil
+		}
+	}
+}
+
+// checkRandomLength checks length, which is argument arg of fn.
+func checkRandomLength(fn string, arg, length int) error {
+	if length < 0 {
+		return argumentError(fn, arg, ErrInvalidArgument, "length %d is negative", length)
+	}
+	if length > DefaultLimits.MaxElements {
+		return argumentError(fn, arg, ErrLimitExceeded, "length %d exceeds the limit of %d", length, DefaultLimits.MaxElements)
+	}
+	return nil
+}
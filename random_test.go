@@ -0,0 +1,206 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"encoding/base64"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func seededEnv(seed int64) env {
+	return env{rand: rand.New(rand.NewSource(seed)), clock: time.Now}
+}
+
+func TestRandomStrings(t *testing.T) {
+	var tests = []struct {
+		Name    string
+		Fn      func(int) (string, error)
+		Operand int
+		Pattern string
+		Valid   bool
+	}{
+		{Name: "RandomAlphaNum", Fn: RandomAlphaNum, Operand: 32, Pattern: "^[A-Za-z0-9]{32}$", Valid: true},
+		{Name: "RandomAlphaNum", Fn: RandomAlphaNum, Operand: 0, Pattern: "^$", Valid: true},
+		{Name: "RandomAlphaNum", Fn: RandomAlphaNum, Operand: -1, Valid: false},
+		{Name: "RandomHex", Fn: RandomHex, Operand: 7, Pattern: "^[0-9a-f]{7}$", Valid: true},
+		{Name: "RandomHex", Fn: RandomHex, Operand: 1 << 20, Valid: false},
+		{Name: "RandomBytesBase64", Fn: RandomBytesBase64, Operand: 16, Pattern: "^[A-Za-z0-9+/]{22}==$", Valid: true},
+		{Name: "RandomBytesBase64", Fn: RandomBytesBase64, Operand: -1, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := test.Fn(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("%s encountered unexpected error: %s.  Operand: %d", test.Name, err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("%s failed to return an error.  Operand: %d", test.Name, test.Operand)
+		}
+		if test.Valid && !regexp.MustCompile(test.Pattern).MatchString(result) {
+			t.Errorf("%s result incorrect.  Operand: %d, Expected: %s, Received: %s", test.Name, test.Operand, test.Pattern, result)
+		}
+	}
+
+	decoded, _ := RandomBytesBase64(16)
+	if raw, err := base64.StdEncoding.DecodeString(decoded); err != nil || len(raw) != 16 {
+		t.Errorf("RandomBytesBase64 result incorrect.  Received: %s", decoded)
+	}
+}
+
+func TestRandomDeterministic(t *testing.T) {
+	first, _ := seededEnv(3).randomAlphaNum(24)
+	second, _ := seededEnv(3).randomAlphaNum(24)
+	if first != second {
+		t.Errorf("RandomAlphaNum result not deterministic for a seeded source.  First: %s, Second: %s", first, second)
+	}
+	other, _ := seededEnv(4).randomAlphaNum(24)
+	if first == other {
+		t.Errorf("RandomAlphaNum result identical for different seeds.  Received: %s", first)
+	}
+}
+
+func TestRandomInt(t *testing.T) {
+	var tests = []struct {
+		Min   int
+		Max   int
+		Valid bool
+	}{
+		{Min: 0, Max: 10, Valid: true},
+		{Min: -5, Max: -4, Valid: true},
+		{Min: -1 << 62, Max: 1 << 62, Valid: true},
+		{Min: 3, Max: 3, Valid: false},
+		{Min: 5, Max: 1, Valid: false},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 100; i++ {
+			result, err := RandomInt(test.Min, test.Max)
+			if test.Valid && err != nil {
+				t.Fatalf("RandomInt encountered unexpected error: %s.  Min: %d, Max: %d", err, test.Min, test.Max)
+			}
+			if !test.Valid {
+				if err == nil {
+					t.Errorf("RandomInt failed to return an error.  Min: %d, Max: %d, Received: %d", test.Min, test.Max, result)
+				}
+				break
+			}
+			if result < test.Min || result >= test.Max {
+				t.Fatalf("RandomInt result out of range.  Min: %d, Max: %d, Received: %d", test.Min, test.Max, result)
+			}
+		}
+	}
+
+	e := seededEnv(1)
+	seen := make(map[int]bool)
+	for i := 0; i < 1000; i++ {
+		n, _ := e.randomInt(0, 6)
+		seen[n] = true
+	}
+	if len(seen) != 6 {
+		t.Errorf("RandomInt results not distributed over range.  Received: %v", seen)
+	}
+}
+
+func TestRandomChoice(t *testing.T) {
+	var tests = []struct {
+		Operand interface{}
+		Valid   bool
+	}{
+		{Operand: []string{"a", "b", "c"}, Valid: true},
+		{Operand: []int{7}, Valid: true},
+		{Operand: [2]bool{true, true}, Valid: true},
+		{Operand: []string{}, Valid: false},
+		{Operand: "abc", Valid: false},
+		{Operand: nil, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := RandomChoice(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("RandomChoice encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("RandomChoice failed to return an error.  Operand: %#v, Received: %#v", test.Operand, result)
+		}
+		if test.Valid && !sliceContains(reflect.ValueOf(test.Operand), result) {
+			t.Errorf("RandomChoice result incorrect.  Operand: %#v, Received: %#v", test.Operand, result)
+		}
+	}
+}
+
+func TestRandomPassword(t *testing.T) {
+	var tests = []struct {
+		Classes string
+		Length  int
+		Minimum map[string]int
+		Valid   bool
+	}{
+		{Classes: "lower,upper,digit,symbol", Length: 16, Minimum: map[string]int{"lower": 1, "upper": 1, "digit": 1, "symbol": 1}, Valid: true},
+		{Classes: "lower:2, digit:6", Length: 8, Minimum: map[string]int{"lower": 2, "digit": 6}, Valid: true},
+		{Classes: "upper,digit:0", Length: 4, Minimum: map[string]int{"upper": 1}, Valid: true},
+		{Classes: "lower:5,upper:5", Length: 8, Valid: false},
+		{Classes: "lower,lower", Length: 8, Valid: false},
+		{Classes: "lower:-1", Length: 8, Valid: false},
+		{Classes: "emoji", Length: 8, Valid: false},
+		{Classes: "", Length: 8, Valid: false},
+	}
+
+	for _, test := range tests {
+		for seed := int64(0); seed < 20; seed++ {
+			result, err := seededEnv(seed).randomPassword(test.Classes, test.Length)
+			if test.Valid && err != nil {
+				t.Fatalf("RandomPassword encountered unexpected error: %s.  Classes: %s, Length: %d", err, test.Classes, test.Length)
+			}
+			if !test.Valid {
+				if err == nil {
+					t.Errorf("RandomPassword failed to return an error.  Classes: %s, Length: %d, Received: %s", test.Classes, test.Length, result)
+				}
+				break
+			}
+			if len(result) != test.Length {
+				t.Errorf("RandomPassword result length incorrect.  Classes: %s, Expected: %d, Received: %s", test.Classes, test.Length, result)
+			}
+			allowed := ""
+			for name, chars := range passwordClasses {
+				if strings.Contains(test.Classes, name) {
+					allowed += chars
+				}
+				count := 0
+				for _, r := range result {
+					if strings.ContainsRune(chars, r) {
+						count++
+					}
+				}
+				if count < test.Minimum[name] {
+					t.Errorf("RandomPassword result missing %s characters.  Classes: %s, Received: %s", name, test.Classes, result)
+				}
+			}
+			if strings.Trim(result, allowed) != "" {
+				t.Errorf("RandomPassword result contains unexpected characters.  Classes: %s, Received: %s", test.Classes, result)
+			}
+		}
+	}
+}
@@ -0,0 +1,435 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+ * Records
+ *
+ * These functions operate on slices of maps or structs, such as records decoded from JSON.
+ * Path selects a value from each element: it is a dotted sequence of map keys, exported
+ * struct field names, and slice indexes, such as "owner.name" or "ports.0".  An empty path
+ * selects the element itself.  Missing map keys resolve to nil, while missing struct fields
+ * are an error.  How nil is treated depends on the function: SortBy, SortByDesc, MinBy, and
+ * MaxBy order nil before all other values, and SumBy counts it as zero.  GroupBy, UniqBy,
+ * CountBy, and IndexBy key their results by the value formatted with ToString, so nil, which
+ * has no string form, is an error there.
+ */
+
+// SortBy returns a copy of operand sorted in ascending order of the value at path.  The sort
+// is stable.  Values are compared as numbers, strings, bools, or times; comparing values of
+// different kinds is an error.
+func SortBy(path string, operand interface{}) (interface{}, error) {
+	return sortBy("SortBy", path, false, operand)
+}
+
+// SortByDesc returns a copy of operand sorted in descending order of the value at path.  The
+// sort is stable, so elements with equal values keep their original order.
+func SortByDesc(path string, operand interface{}) (interface{}, error) {
+	return sortBy("SortByDesc", path, true, operand)
+}
+
+// GroupBy groups the elements of operand by the value at path.  The result maps each value,
+// formatted with ToString, to a slice of the elements with that value in their original order.
+func GroupBy(path string, operand interface{}) (map[string]interface{}, error) {
+	v, keys, err := recordKeys("GroupBy", path, operand)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string]reflect.Value)
+	for i, key := range keys {
+		k, err := recordKeyString("GroupBy", path, i, key)
+		if err != nil {
+			return nil, err
+		}
+		group, ok := groups[k]
+		if !ok {
+			group = reflect.MakeSlice(v.Type(), 0, 1)
+		}
+		groups[k] = reflect.Append(group, v.Index(i))
+	}
+	result := make(map[string]interface{}, len(groups))
+	for k, group := range groups {
+		result[k] = group.Interface()
+	}
+	return result, nil
+}
+
+// UniqBy returns a copy of operand containing only the first element for each distinct value
+// at path.
+func UniqBy(path string, operand interface{}) (interface{}, error) {
+	v, keys, err := recordKeys("UniqBy", path, operand)
+	if err != nil {
+		return nil, err
+	}
+	uniq := reflect.MakeSlice(v.Type(), 0, v.Len())
+	seen := make(map[string]bool, len(keys))
+	for i, key := range keys {
+		k, err := recordKeyString("UniqBy", path, i, key)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[k] {
+			uniq = reflect.Append(uniq, v.Index(i))
+			seen[k] = true
+		}
+	}
+	return uniq.Interface(), nil
+}
+
+// CountBy counts the elements of operand by the value at path, formatted with ToString.
+func CountBy(path string, operand interface{}) (map[string]int, error) {
+	_, keys, err := recordKeys("CountBy", path, operand)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for i, key := range keys {
+		k, err := recordKeyString("CountBy", path, i, key)
+		if err != nil {
+			return nil, err
+		}
+		counts[k]++
+	}
+	return counts, nil
+}
+
+// IndexBy maps the value at path, formatted with ToString, to the element of operand holding
+// it.  Duplicate values are an error.
+func IndexBy(path string, operand interface{}) (map[string]interface{}, error) {
+	v, keys, err := recordKeys("IndexBy", path, operand)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]interface{}, len(keys))
+	for i, key := range keys {
+		k, err := recordKeyString("IndexBy", path, i, key)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := index[k]; ok {
+			return nil, argumentError("IndexBy", 1, ErrInvalidArgument, "element %d: duplicate value %q at path %q", i, k, path)
+		}
+		index[k] = v.Index(i).Interface()
+	}
+	return index, nil
+}
+
+// MinBy returns the element of operand with the smallest value at path.  Ties return the first
+// such element.  An empty operand is an error.
+func MinBy(path string, operand interface{}) (interface{}, error) {
+	return extremeBy("MinBy", path, -1, operand)
+}
+
+// MaxBy returns the element of operand with the largest value at path.  Ties return the first
+// such element.  An empty operand is an error.
+func MaxBy(path string, operand interface{}) (interface{}, error) {
+	return extremeBy("MaxBy", path, 1, operand)
+}
+
+// SumBy returns the sum of the values at path, which must be numbers or numeric strings.  The
+// sum is an int64 if every value is an integer and the sum fits in an int64, and a float64
+// otherwise.  Nil values count as zero.
+func SumBy(path string, operand interface{}) (interface{}, error) {
+	_, keys, err := recordKeys("SumBy", path, operand)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		intSum   int64
+		floatSum float64
+		isFloat  bool
+	)
+	for i, key := range keys {
+		if key == nil {
+			continue
+		}
+		if !IsNumber(key) && !IsString(key) {
+			return nil, argumentError("SumBy", 1, ErrInvalidArgument, "element %d: cannot sum %s value at path %q", i, TypeOf(key), path)
+		}
+		if !isFloat {
+			if i, err := exactInt64(key); err == nil {
+				sum := intSum + i
+				if i > 0 && sum < intSum || i < 0 && sum > intSum {
+					// The sum overflows int64, so continue in float64.
+					isFloat, floatSum = true, float64(intSum)+float64(i)
+					continue
+				}
+				intSum = sum
+				continue
+			}
+			isFloat, floatSum = true, float64(intSum)
+		}
+		f, err := toFloat(key)
+		if err != nil {
+			return nil, argumentError("SumBy", 1, ErrInvalidArgument, "element %d: cannot sum value at path %q: %s", i, path, err)
+		}
+		floatSum += f
+	}
+	if isFloat {
+		return floatSum, nil
+	}
+	return intSum, nil
+}
+
+// sortBy implements SortBy and SortByDesc, which are reported as fn in errors.
+func sortBy(fn, path string, desc bool, operand interface{}) (interface{}, error) {
+	v, keys, err := recordKeys(fn, path, operand)
+	if err != nil {
+		return nil, err
+	}
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	var cmpErr error
+	sort.SliceStable(order, func(i, j int) bool {
+		c, err := compareValues(keys[order[i]], keys[order[j]])
+		if err != nil && cmpErr == nil {
+			cmpErr = err
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+	if cmpErr != nil {
+		return nil, argumentError(fn, 1, ErrInvalidArgument, "cannot sort by %q: %s", path, cmpErr)
+	}
+	sorted := reflect.MakeSlice(v.Type(), len(order), len(order))
+	for i, idx := range order {
+		sorted.Index(i).Set(v.Index(idx))
+	}
+	return sorted.Interface(), nil
+}
+
+// extremeBy implements MinBy and MaxBy, which are reported as fn in errors.
+func extremeBy(fn, path string, sign int, operand interface{}) (interface{}, error) {
+	v, keys, err := recordKeys(fn, path, operand)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, argumentError(fn, 1, ErrInvalidArgument, "cannot select from an empty %s", TypeOf(operand))
+	}
+	best := 0
+	for i := 1; i < len(keys); i++ {
+		c, err := compareValues(keys[i], keys[best])
+		if err != nil {
+			return nil, argumentError(fn, 1, ErrInvalidArgument, "cannot compare values at path %q: %s", path, err)
+		}
+		if c*sign > 0 {
+			best = i
+		}
+	}
+	return v.Index(best).Interface(), nil
+}
+
+// recordKeys returns operand as a slice value along with the value at path for each element.
+// Errors are reported for the operand of fn.
+func recordKeys(fn, path string, operand interface{}) (reflect.Value, []interface{}, error) {
+	v, err := sliceValue(operand)
+	if err != nil {
+		return v, nil, wrapArgumentError(fn, 1, err)
+	}
+	keys := make([]interface{}, v.Len())
+	for i := range keys {
+		if keys[i], err = resolvePath(path, v.Index(i).Interface()); err != nil {
+			return v, nil, wrapArgumentError(fn, 1, fmt.Errorf("element %d: %w", i, err))
+		}
+	}
+	return v, keys, nil
+}
+
+// resolvePath returns the value at the dotted path within operand.
+func resolvePath(path string, operand interface{}) (interface{}, error) {
+	if path == "" {
+		return operand, nil
+	}
+	value := operand
+	for _, segment := range strings.Split(path, ".") {
+		var err error
+		if value, err = resolveSegment(segment, value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// resolveSegment returns the value of the map key, struct field, or slice index segment within
+// operand.
+func resolveSegment(segment string, operand interface{}) (interface{}, error) {
+	value, _, err := lookupSegment(segment, operand)
+	return value, err
+}
+
+// lookupSegment is like resolveSegment, but also reports whether segment exists.  A missing
+// map key, or any segment within nil, does not exist and resolves to nil.
+func lookupSegment(segment string, operand interface{}) (interface{}, bool, error) {
+	v := reflect.ValueOf(operand)
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false, nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil, false, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false, fmt.Errorf("cannot resolve %q in %s: keys are not strings", segment, v.Type())
+		}
+		v = v.MapIndex(reflect.ValueOf(segment).Convert(v.Type().Key()))
+	case reflect.Struct:
+		field, ok := v.Type().FieldByName(segment)
+		if !ok || field.PkgPath != "" {
+			return nil, false, fmt.Errorf("no exported field %q in %s", segment, v.Type())
+		}
+		if v, ok = fieldByIndex(v, field.Index); !ok {
+			return nil, false, nil
+		}
+	case reflect.Slice, reflect.Array:
+		i, err := strconv.Atoi(segment)
+		if err != nil || i < 0 || i >= v.Len() {
+			return nil, false, fmt.Errorf("invalid index %q for %s of length %d", segment, v.Type(), v.Len())
+		}
+		v = v.Index(i)
+	default:
+		return nil, false, fmt.Errorf("cannot resolve %q in %s", segment, v.Type())
+	}
+	if !v.IsValid() {
+		return nil, false, nil
+	}
+	return v.Interface(), true, nil
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but reports false rather than panicking when
+// index passes through a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// compareValues returns -1, 0, or 1 as a is less than, equal to, or greater than b.  Numbers
+// compare numerically regardless of type, and nil is less than any other value.
+func compareValues(a, b interface{}) (int, error) {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0, nil
+		case a == nil:
+			return -1, nil
+		}
+		return 1, nil
+	}
+	if IsNumber(a) && IsNumber(b) {
+		ai, aerr := exactInt64(a)
+		bi, berr := exactInt64(b)
+		if aerr == nil && berr == nil {
+			return compareInt64s(ai, bi), nil
+		}
+		af, _ := toFloat(a)
+		bf, _ := toFloat(b)
+		switch {
+		case af < bf:
+			return -1, nil
+		case af > bf:
+			return 1, nil
+		}
+		return 0, nil
+	}
+	if at, ok := a.(time.Time); ok {
+		if bt, ok := b.(time.Time); ok {
+			switch {
+			case at.Before(bt):
+				return -1, nil
+			case at.After(bt):
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case av.Kind() == reflect.String && bv.Kind() == reflect.String:
+		return strings.Compare(av.String(), bv.String()), nil
+	case av.Kind() == reflect.Bool && bv.Kind() == reflect.Bool:
+		if av.Bool() == bv.Bool() {
+			return 0, nil
+		}
+		if bv.Bool() {
+			return -1, nil
+		}
+		return 1, nil
+	}
+	return 0, fmt.Errorf("cannot compare %s with %s", TypeOf(a), TypeOf(b))
+}
+
+func compareInt64s(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// exactInt64 converts integers, and strings holding integers, to int64.  Floats and other values
+// return an error.
+func exactInt64(operand interface{}) (int64, error) {
+	v := reflect.ValueOf(operand)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return toInt64(operand)
+	case reflect.String:
+		return strconv.ParseInt(v.String(), 10, 64)
+	}
+	return 0, conversionError(operand, "int64")
+}
+
+// recordKeyString formats the value at path of element index with ToString for use as a key
+// by fn.  Values without a string form, including nil, are an error for fn's operand.
+func recordKeyString(fn, path string, index int, key interface{}) (string, error) {
+	s, err := toString(key)
+	if err != nil {
+		return "", argumentError(fn, 1, ErrInvalidArgument, "element %d: value at path %q: %s", index, path, err)
+	}
+	return s, nil
+}
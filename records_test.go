@@ -0,0 +1,320 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"text/template"
+	"time"
+)
+
+type testRecord struct {
+	Name  string
+	Team  string
+	Score float64
+	Owner *testOwner
+	notes string
+}
+
+type testOwner struct {
+	Email string
+}
+
+var testRecords = []testRecord{
+	{Name: "api", Team: "core", Score: 7.5, Owner: &testOwner{Email: "a@example.com"}},
+	{Name: "web", Team: "edge", Score: 9},
+	{Name: "db", Team: "core", Score: 7.5, Owner: &testOwner{Email: "c@example.com"}},
+}
+
+func decodeRecords(t *testing.T, data string) []interface{} {
+	var records []interface{}
+	if err := json.Unmarshal([]byte(data), &records); err != nil {
+		t.Fatalf("Failed to decode test records: %s", err)
+	}
+	return records
+}
+
+func recordNames(t *testing.T, records interface{}) []string {
+	var names []string
+	v := reflect.ValueOf(records)
+	for i := 0; i < v.Len(); i++ {
+		name, err := resolvePath("Name", v.Index(i).Interface())
+		if err != nil || name == nil {
+			name, _ = resolvePath("name", v.Index(i).Interface())
+		}
+		names = append(names, name.(string))
+	}
+	return names
+}
+
+func TestSortBy(t *testing.T) {
+	jsonRecords := decodeRecords(t, `[{"name": "b", "size": 10}, {"name": "a", "size": 2.5}, {"name": "c"}, {"name": "d", "size": 2.5}]`)
+	var tests = []struct {
+		Path     string
+		Desc     bool
+		Operand  interface{}
+		Expected []string
+		Valid    bool
+	}{
+		{Path: "Score", Operand: testRecords, Expected: []string{"api", "db", "web"}, Valid: true},
+		{Path: "Score", Desc: true, Operand: testRecords, Expected: []string{"web", "api", "db"}, Valid: true},
+		{Path: "Owner.Email", Desc: true, Operand: testRecords, Expected: []string{"db", "api", "web"}, Valid: true},
+		{Path: "size", Operand: jsonRecords, Expected: []string{"c", "a", "d", "b"}, Valid: true},
+		{Path: "name", Desc: true, Operand: jsonRecords, Expected: []string{"d", "c", "b", "a"}, Valid: true},
+		{Path: "Missing", Operand: testRecords, Valid: false},
+		{Path: "notes", Operand: testRecords, Valid: false},
+		{Path: "", Operand: []interface{}{"a", 1}, Valid: false},
+	}
+
+	for _, test := range tests {
+		fn := SortBy
+		if test.Desc {
+			fn = SortByDesc
+		}
+		result, err := fn(test.Path, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("SortBy encountered unexpected error: %s.  Path: %s, Desc: %t", err, test.Path, test.Desc)
+		}
+		if !test.Valid {
+			if err == nil {
+				t.Errorf("SortBy failed to return an error.  Path: %s, Desc: %t", test.Path, test.Desc)
+			}
+			continue
+		}
+		if names := recordNames(t, result); !reflect.DeepEqual(names, test.Expected) {
+			t.Errorf("SortBy result incorrect.  Path: %s, Desc: %t, Expected: %#v, Received: %#v", test.Path, test.Desc, test.Expected, names)
+		}
+		if reflect.TypeOf(result) != reflect.TypeOf(test.Operand) {
+			t.Errorf("SortBy result type incorrect.  Path: %s, Expected: %T, Received: %T", test.Path, test.Operand, result)
+		}
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	result, err := GroupBy("Team", testRecords)
+	if err != nil {
+		t.Fatalf("GroupBy encountered unexpected error: %s", err)
+	}
+	expected := map[string]interface{}{
+		"core": []testRecord{testRecords[0], testRecords[2]},
+		"edge": []testRecord{testRecords[1]},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("GroupBy result incorrect.  Expected: %#v, Received: %#v", expected, result)
+	}
+
+	jsonResult, err := GroupBy("n", decodeRecords(t, `[{"n": 1}, {"n": 1.5}, {"n": 1}]`))
+	if err != nil {
+		t.Fatalf("GroupBy encountered unexpected error: %s", err)
+	}
+	if len(jsonResult) != 2 || len(jsonResult["1"].([]interface{})) != 2 {
+		t.Errorf("GroupBy result incorrect.  Received: %#v", jsonResult)
+	}
+}
+
+func TestUniqBy(t *testing.T) {
+	result, err := UniqBy("Team", testRecords)
+	if err != nil {
+		t.Fatalf("UniqBy encountered unexpected error: %s", err)
+	}
+	if names := recordNames(t, result); !reflect.DeepEqual(names, []string{"api", "web"}) {
+		t.Errorf("UniqBy result incorrect.  Received: %#v", names)
+	}
+}
+
+func TestCountBy(t *testing.T) {
+	result, err := CountBy("Score", testRecords)
+	if err != nil {
+		t.Fatalf("CountBy encountered unexpected error: %s", err)
+	}
+	expected := map[string]int{"7.5": 2, "9": 1}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("CountBy result incorrect.  Expected: %#v, Received: %#v", expected, result)
+	}
+}
+
+func TestIndexBy(t *testing.T) {
+	result, err := IndexBy("Name", testRecords)
+	if err != nil {
+		t.Fatalf("IndexBy encountered unexpected error: %s", err)
+	}
+	if len(result) != 3 || !reflect.DeepEqual(result["web"], testRecords[1]) {
+		t.Errorf("IndexBy result incorrect.  Received: %#v", result)
+	}
+	if _, err := IndexBy("Team", testRecords); err == nil {
+		t.Errorf("IndexBy failed to return an error for duplicate values")
+	}
+}
+
+func TestRecordKeyErrors(t *testing.T) {
+	cyclic := map[string]interface{}{}
+	cyclic["k"] = cyclic
+	funcs := map[string]func(string, interface{}) (interface{}, error){
+		"GroupBy": func(path string, operand interface{}) (interface{}, error) { return GroupBy(path, operand) },
+		"UniqBy":  func(path string, operand interface{}) (interface{}, error) { return UniqBy(path, operand) },
+		"CountBy": func(path string, operand interface{}) (interface{}, error) { return CountBy(path, operand) },
+		"IndexBy": func(path string, operand interface{}) (interface{}, error) { return IndexBy(path, operand) },
+	}
+	operands := []interface{}{
+		[]interface{}{map[string]interface{}{"k": "<nil>"}, map[string]interface{}{"k": nil}},
+		[]interface{}{map[string]interface{}{"k": []int{1}}},
+		[]interface{}{cyclic},
+	}
+	for name, fn := range funcs {
+		for _, operand := range operands {
+			_, err := fn("k", operand)
+			var argErr *ArgumentError
+			if !errors.As(err, &argErr) || argErr.Func != name || argErr.Arg != 1 || !errors.Is(err, ErrInvalidArgument) {
+				t.Errorf("%s failed to reject a key without a string form.  Received: %v", name, err)
+			}
+		}
+	}
+}
+
+func TestMinByMaxBy(t *testing.T) {
+	var tests = []struct {
+		Path     string
+		Min      bool
+		Operand  interface{}
+		Expected string
+		Valid    bool
+	}{
+		{Path: "Score", Min: true, Operand: testRecords, Expected: "api", Valid: true},
+		{Path: "Score", Operand: testRecords, Expected: "web", Valid: true},
+		{Path: "Name", Operand: testRecords, Expected: "web", Valid: true},
+		{Path: "Name", Min: true, Operand: testRecords, Expected: "api", Valid: true},
+		{Path: "Score", Operand: []testRecord{}, Valid: false},
+		{Path: "Score", Operand: "records", Valid: false},
+	}
+
+	for _, test := range tests {
+		fn := MaxBy
+		if test.Min {
+			fn = MinBy
+		}
+		result, err := fn(test.Path, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("MinBy/MaxBy encountered unexpected error: %s.  Path: %s, Min: %t", err, test.Path, test.Min)
+		}
+		if !test.Valid {
+			if err == nil {
+				t.Errorf("MinBy/MaxBy failed to return an error.  Path: %s, Min: %t", test.Path, test.Min)
+			}
+			continue
+		}
+		if result.(testRecord).Name != test.Expected {
+			t.Errorf("MinBy/MaxBy result incorrect.  Path: %s, Min: %t, Expected: %s, Received: %#v", test.Path, test.Min, test.Expected, result)
+		}
+	}
+}
+
+func TestSumBy(t *testing.T) {
+	var tests = []struct {
+		Path     string
+		Operand  interface{}
+		Expected interface{}
+		Valid    bool
+	}{
+		{Path: "Score", Operand: testRecords, Expected: 24.0, Valid: true},
+		{Path: "n", Operand: []map[string]interface{}{{"n": 1}, {"n": int64(2)}, {"n": "3"}, {}}, Expected: int64(6), Valid: true},
+		{Path: "n", Operand: []map[string]interface{}{{"n": 1}, {"n": 0.5}}, Expected: 1.5, Valid: true},
+		{Path: "n", Operand: []map[string]interface{}{}, Expected: int64(0), Valid: true},
+		{Path: "n", Operand: []map[string]interface{}{{"n": "many"}}, Valid: false},
+		{Path: "n", Operand: []map[string]interface{}{{"n": 1}, {"n": true}}, Valid: false},
+		{Path: "n", Operand: []map[string]interface{}{{"n": 0.5}, {"n": false}}, Valid: false},
+		{Path: "n", Operand: []map[string]interface{}{{"n": []int{1}}}, Valid: false},
+		{Path: "n", Operand: []map[string]interface{}{{"n": int64(math.MaxInt64)}, {"n": 1}}, Expected: float64(math.MaxInt64) + 1, Valid: true},
+		{Path: "n", Operand: []map[string]interface{}{{"n": int64(math.MinInt64)}, {"n": -1}, {"n": 2}}, Expected: float64(math.MinInt64) + 1, Valid: true},
+	}
+
+	for _, test := range tests {
+		result, err := SumBy(test.Path, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("SumBy encountered unexpected error: %s.  Path: %s, Operand: %#v", err, test.Path, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("SumBy failed to return an error.  Path: %s, Operand: %#v", test.Path, test.Operand)
+		}
+		if test.Valid && result != test.Expected {
+			t.Errorf("SumBy result incorrect.  Path: %s, Operand: %#v, Expected: %#v, Received: %#v", test.Path, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	type embedded struct {
+		*testOwner
+		Name string
+	}
+	when := time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)
+	data := map[string]interface{}{
+		"ports":    []interface{}{80, 443},
+		"owner":    testOwner{Email: "a@example.com"},
+		"created":  when,
+		"embedded": []embedded{{testOwner: &testOwner{Email: "b@example.com"}}, {Name: "none"}},
+	}
+	var tests = []struct {
+		Path     string
+		Expected interface{}
+		Valid    bool
+	}{
+		{Path: "ports.1", Expected: 443, Valid: true},
+		{Path: "owner.Email", Expected: "a@example.com", Valid: true},
+		{Path: "created", Expected: when, Valid: true},
+		{Path: "missing.deeper", Expected: nil, Valid: true},
+		{Path: "embedded.0.Email", Expected: "b@example.com", Valid: true},
+		{Path: "embedded.1.Email", Expected: nil, Valid: true},
+		{Path: "ports.2", Valid: false},
+		{Path: "owner.Phone", Valid: false},
+		{Path: "created.Year", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := resolvePath(test.Path, data)
+		if test.Valid && err != nil {
+			t.Errorf("resolvePath encountered unexpected error: %s.  Path: %s", err, test.Path)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("resolvePath failed to return an error.  Path: %s, Received: %#v", test.Path, result)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("resolvePath result incorrect.  Path: %s, Expected: %#v, Received: %#v", test.Path, test.Expected, result)
+		}
+	}
+}
+
+func TestRecordsTemplate(t *testing.T) {
+	records := decodeRecords(t, `[{"team": "edge", "name": "web"}, {"team": "core", "name": "db"}, {"team": "core", "name": "api"}]`)
+	tmpl := template.Must(template.New("test").Funcs(FuncMap).Parse(
+		`{{ range $team, $members := GroupBy "team" . }}{{ $team }}:{{ range SortBy "name" $members }} {{ .name }}{{ end }};{{ end }}`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, records); err != nil {
+		t.Fatalf("Template execution encountered unexpected error: %s", err)
+	}
+	if buf.String() != "core: api db;edge: web;" {
+		t.Errorf("Records template output incorrect.  Received: %s", buf.String())
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// DefaultMaxOutput and DefaultTimeout are the limits used by a Renderer whose MaxOutput or
+// Timeout field is zero.
+const (
+	DefaultMaxOutput = 1 << 20
+	DefaultTimeout   = 10 * time.Second
+)
+
+// Renderer parses and executes text templates with haven's functions installed and safe
+// defaults applied.  Templates parsed by a Renderer treat a missing map key as an error rather
+// than rendering "<no value>".  The zero Renderer is ready to use.
+type Renderer struct {
+	// Funcs are the functions available to templates, such as the result of NewFuncMap.  If
+	// nil, FuncMap is used.
+	Funcs map[string]interface{}
+
+	// MaxOutput is the maximum size of rendered output in bytes.  If zero, DefaultMaxOutput
+	// is used.  If negative, output size is not limited.
+	MaxOutput int
+
+	// Timeout is the maximum duration of a single execution.  If zero, DefaultTimeout is
+	// used.  If negative, execution time is not limited.
+	Timeout time.Duration
+}
+
+// RenderError is returned by Renderer when a template fails to parse or execute.  Callers may
+// use errors.As on Err, or on the RenderError itself, to inspect the underlying error, such as
+// an *AssertionError returned by a template function or context.DeadlineExceeded.
+type RenderError struct {
+	// Name is the name of the template that failed.
+	Name string
+
+	// Line and Column give the 1-based position of the failure within the template, or zero
+	// if unknown.  Column counts bytes.  Parse errors report only a line.
+	Line   int
+	Column int
+
+	// Err is the underlying error.
+	Err error
+
+	msg string
+}
+
+func (e *RenderError) Error() string {
+	switch {
+	case e.Column > 0:
+		return fmt.Sprintf("template %s:%d:%d: %s", e.Name, e.Line, e.Column, e.msg)
+	case e.Line > 0:
+		return fmt.Sprintf("template %s:%d: %s", e.Name, e.Line, e.msg)
+	}
+	return fmt.Sprintf("template %s: %s", e.Name, e.msg)
+}
+
+func (e *RenderError) Unwrap() error { return e.Err }
+
+// Parse parses text as a template named name.  Errors are returned as a *RenderError.
+func (r *Renderer) Parse(name, text string) (*template.Template, error) {
+	funcs := r.Funcs
+	if funcs == nil {
+		funcs = FuncMap
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, newRenderError(name, err)
+	}
+	return tmpl, nil
+}
+
+// Execute executes tmpl with data and returns its output.  Execution that exceeds the output
+// or time limits of r is stopped, and errors are returned as a *RenderError.
+func (r *Renderer) Execute(tmpl *template.Template, data interface{}) (string, error) {
+	return r.ExecuteContext(context.Background(), tmpl, data)
+}
+
+// ExecuteContext is like Execute, but also stops execution once ctx is done.  The functions
+// that the template calls are bound to ctx as by Options.Context, so each checks ctx before it
+// runs, and writes to the output fail once ctx is done.  ExecuteContext returns as soon as ctx
+// is done, but a function call already running when it returns runs to completion in the
+// background, within DefaultLimits; the bound functions stop only the calls after it.
+func (r *Renderer) ExecuteContext(ctx context.Context, tmpl *template.Template, data interface{}) (string, error) {
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	max := r.MaxOutput
+	if max == 0 {
+		max = DefaultMaxOutput
+	}
+
+	funcs := r.Funcs
+	if funcs == nil {
+		funcs = FuncMap
+	}
+	bound, err := tmpl.Clone()
+	if err != nil {
+		return "", newRenderError(tmpl.Name(), err)
+	}
+	bound.Funcs(bindContext(ctx, calledFuncs(tmpl, funcs)))
+
+	// The template runs in its own goroutine so that a deadline can interrupt it.  The
+	// buffer is abandoned, and never read, if execution does not finish in time.
+	w := &limitedBuffer{ctx: ctx, max: max}
+	done := make(chan error, 1)
+	go func() { done <- bound.Execute(w, data) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return "", newRenderError(tmpl.Name(), err)
+		}
+		return w.buf.String(), nil
+	case <-ctx.Done():
+		return "", &RenderError{Name: tmpl.Name(), Err: ctx.Err(), msg: "execution stopped: " + ctx.Err().Error()}
+	}
+}
+
+// calledFuncs returns the functions in funcs that tmpl, or a template associated with it,
+// calls by name.  Binding only those to a context avoids wrapping every function in funcs on
+// every execution.
+func calledFuncs(tmpl *template.Template, funcs map[string]interface{}) map[string]interface{} {
+	called := make(map[string]interface{})
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, child := range n.Nodes {
+					walk(child)
+				}
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, cmd := range n.Cmds {
+					walk(cmd)
+				}
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.ChainNode:
+			walk(n.Node)
+		case *parse.IdentifierNode:
+			if fn, ok := funcs[n.Ident]; ok {
+				called[n.Ident] = fn
+			}
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		}
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			walk(t.Tree.Root)
+		}
+	}
+	return called
+}
+
+// templateErrorPattern matches the position prefix of text/template parse and execution
+// errors, such as "template: name:3:12: executing ...".
+var templateErrorPattern = regexp.MustCompile(`(?s)^template: (.*?):(\d+):(?:(\d+):)? (.*)$`)
+
+// Render parses text as a template named name and executes it with data.
+func (r *Renderer) Render(name, text string, data interface{}) (string, error) {
+	return r.RenderContext(context.Background(), name, text, data)
+}
+
+// RenderContext is like Render, but also stops execution once ctx is done.  It allows an HTTP
+// handler to bound rendering by the request's context: r.RenderContext(req.Context(), ...).
+func (r *Renderer) RenderContext(ctx context.Context, name, text string, data interface{}) (string, error) {
+	tmpl, err := r.Parse(name, text)
+	if err != nil {
+		return "", err
+	}
+	return r.ExecuteContext(ctx, tmpl, data)
+}
+
+func newRenderError(name string, err error) *RenderError {
+	rerr := &RenderError{Name: name, Err: err, msg: err.Error()}
+	var execErr template.ExecError
+	if errors.As(err, &execErr) {
+		rerr.Name = execErr.Name
+	}
+	if m := templateErrorPattern.FindStringSubmatch(err.Error()); m != nil {
+		rerr.Name = m[1]
+		rerr.Line, _ = strconv.Atoi(m[2])
+		if m[3] != "" {
+			// text/template reports the byte offset within the line, counting from zero.
+			col, _ := strconv.Atoi(m[3])
+			rerr.Column = col + 1
+		}
+		rerr.msg = m[4]
+	}
+	return rerr
+}
+
+// limitedBuffer is a bytes.Buffer that fails writes that would grow it beyond max bytes, or
+// that occur once ctx is done.  A negative max disables the size limit.
+type limitedBuffer struct {
+	ctx context.Context
+	buf bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if err := b.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if b.max >= 0 && b.buf.Len()+len(p) > b.max {
+		return 0, limitErrorf("output exceeds the limit of %d bytes", b.max)
+	}
+	return b.buf.Write(p)
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestRenderer(t *testing.T) {
+	var tests = []struct {
+		Name     string
+		Text     string
+		Data     interface{}
+		Expected string
+		Line     int
+		Column   int
+		Valid    bool
+	}{
+		{Name: "ok", Text: `{{ .names | Join ", " | ToUpper }}`, Data: map[string]interface{}{"names": []string{"a", "b"}}, Expected: "A, B", Valid: true},
+		{Name: "missing", Text: "line one\n{{ .name }} {{ .absent }}", Data: map[string]interface{}{"name": "x"}, Line: 2, Column: 16, Valid: false},
+		{Name: "parse", Text: "ok\n{{ .name", Line: 2, Valid: false},
+		{Name: "func", Text: `{{ Split "," .csv | Head -1 }}`, Data: map[string]interface{}{"csv": "a"}, Valid: false},
+		{Name: "unknown", Text: `{{ NoSuchFunc }}`, Line: 1, Valid: false},
+		{Name: "nested", Text: `{{ define "inner" }}{{ .absent }}{{ end }}{{ template "inner" .}}`, Data: map[string]interface{}{}, Line: 1, Column: 24, Valid: false},
+	}
+
+	var r Renderer
+	for _, test := range tests {
+		result, err := r.Render(test.Name, test.Text, test.Data)
+		if test.Valid {
+			if err != nil {
+				t.Errorf("Render encountered unexpected error: %s.  Name: %s", err, test.Name)
+			}
+			if result != test.Expected {
+				t.Errorf("Render result incorrect.  Name: %s, Expected: %q, Received: %q", test.Name, test.Expected, result)
+			}
+			continue
+		}
+		var rerr *RenderError
+		if !errors.As(err, &rerr) {
+			t.Errorf("Render failed to return a *RenderError.  Name: %s, Received: %#v", test.Name, err)
+			continue
+		}
+		if test.Line != 0 && (rerr.Line != test.Line || rerr.Column != test.Column) {
+			t.Errorf("Render error position incorrect.  Name: %s, Expected: %d:%d, Received: %d:%d (%s)", test.Name, test.Line, test.Column, rerr.Line, rerr.Column, err)
+		}
+		if rerr.Name == "" || !strings.HasPrefix(err.Error(), "template "+rerr.Name) {
+			t.Errorf("Render error message incorrect.  Name: %s, Received: %s", test.Name, err)
+		}
+	}
+}
+
+func TestRendererErrors(t *testing.T) {
+	r := Renderer{MaxOutput: 10}
+	if _, err := r.Render("big", `{{ Repeat 11 "x" }}`, nil); !errors.Is(err, ErrLimitExceeded) || !strings.Contains(err.Error(), "limit of 10 bytes") {
+		t.Errorf("Render failed to enforce MaxOutput.  Received: %v", err)
+	}
+	if result, err := r.Render("small", `{{ Repeat 10 "x" }}`, nil); err != nil || result != "xxxxxxxxxx" {
+		t.Errorf("Render result incorrect at MaxOutput.  Received: %q, Error: %v", result, err)
+	}
+
+	r = Renderer{MaxOutput: -1}
+	if result, err := r.Render("unlimited", `{{ range Seq 1 40 }}{{ Repeat 50000 "x" }}{{ end }}`, nil); err != nil || len(result) != 2000000 {
+		t.Errorf("Render result incorrect with unlimited output.  Received length: %d, Error: %v", len(result), err)
+	}
+
+	r = Renderer{}
+	_, err := r.Render("assert", `{{ Fail "bad data" }}`, nil)
+	var assertErr *AssertionError
+	if !errors.As(err, &assertErr) || assertErr.Message != "bad data" {
+		t.Errorf("Render error does not unwrap to the function error.  Received: %#v", err)
+	}
+
+	funcs := NewFuncMap(Options{})
+	funcs["Sleep"] = func() string { time.Sleep(time.Second); return "" }
+	r = Renderer{Funcs: funcs, Timeout: 10 * time.Millisecond}
+	start := time.Now()
+	_, err = r.Render("slow", `{{ Sleep }}`, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Render failed to enforce Timeout.  Received: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Render returned too late after Timeout.  Elapsed: %s", elapsed)
+	}
+
+	tmpl, err := r.Parse("reuse", `{{ .n }}`)
+	if err != nil {
+		t.Fatalf("Parse encountered unexpected error: %s", err)
+	}
+	for _, n := range []string{"1", "2"} {
+		if result, err := r.Execute(tmpl, map[string]string{"n": n}); err != nil || result != n {
+			t.Errorf("Execute result incorrect.  Expected: %s, Received: %q, Error: %v", n, result, err)
+		}
+	}
+}
+
+func TestCalledFuncs(t *testing.T) {
+	text := `{{ define "row" }}{{ ToUpper . }}{{ end }}` +
+		`{{ if Contains "a" .A }}{{ range Split "," .B }}{{ template "row" . }}{{ end }}{{ else }}{{ len .C }}{{ end }}` +
+		`{{ with .D }}{{ . | Join (Quote ",") }}{{ end }}`
+	tmpl := template.Must(template.New("test").Funcs(FuncMap).Parse(text))
+	var called []string
+	for name := range calledFuncs(tmpl, FuncMap) {
+		called = append(called, name)
+	}
+	sort.Strings(called)
+	expected := []string{"Contains", "Join", "Quote", "Split", "ToUpper"}
+	if !reflect.DeepEqual(called, expected) {
+		t.Errorf("calledFuncs result incorrect.  Expected: %v, Received: %v", expected, called)
+	}
+}
+
+func TestRenderContext(t *testing.T) {
+	var r Renderer
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.RenderContext(ctx, "canceled", `{{ ToUpper "x" }}`, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("RenderContext failed to stop on a canceled context.  Received: %v", err)
+	}
+
+	var calls int32
+	funcs := NewFuncMap(Options{})
+	funcs["Slow"] = func() string {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(time.Millisecond)
+		return "x"
+	}
+	r = Renderer{Funcs: funcs, MaxOutput: -1, Timeout: -1}
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := r.RenderContext(ctx, "runaway", `{{ range Seq 1 60000 }}{{ Slow }}{{ end }}`, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RenderContext failed to enforce the deadline.  Received: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	stopped := atomic.LoadInt32(&calls)
+	time.Sleep(20 * time.Millisecond)
+	if after := atomic.LoadInt32(&calls); after != stopped {
+		t.Errorf("RenderContext left execution running after the deadline.  Calls: %d, Later: %d", stopped, after)
+	}
+
+	if result, err := r.RenderContext(context.Background(), "ok", `{{ range Seq 1 3 }}{{ Slow }}{{ end }}`, nil); err != nil || result != "xxx" {
+		t.Errorf("RenderContext result incorrect.  Received: %q, Error: %v", result, err)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"sort"
+)
+
+/*
+ * Sampling
+ */
+
+// SeededShuffle returns a copy of operand shuffled in an order determined entirely by seed.
+// Seed may be any scalar and is converted with ToString.  The same seed and operand produce the
+// same order on every run, platform, and version of haven, making SeededShuffle suitable for
+// stable assignments such as canary selection.  The shuffle is not suitable for secrets.
+func SeededShuffle(seed interface{}, operand []string) ([]string, error) {
+	s, err := toString(seed)
+	if err != nil {
+		return nil, wrapArgumentError("SeededShuffle", 0, err)
+	}
+	e := env{rand: &hashStream{seed: []byte(s)}}
+	shuffled := make([]string, len(operand))
+	copy(shuffled, operand)
+	if err := e.shuffle(shuffled); err != nil {
+		return nil, err
+	}
+	return shuffled, nil
+}
+
+// Sample returns n elements of operand chosen at random without replacement, in random order.
+// The random bytes are read from crypto/rand, or from Options.Rand for functions returned by
+// NewFuncMap.  N must be between 0 and len(operand).
+func Sample(n int, operand []string) ([]string, error) { return defaultEnv.sample(n, operand) }
+
+// WeightedChoice returns a key of the map operand chosen at random with probability
+// proportional to its value.  Values must be non-negative numbers, or strings convertible with
+// ToFloat, and at least one must be positive.  Example: {{ WeightedChoice .Weights }} with
+// weights {"blue": 9, "green": 1} returns "blue" 90% of the time.  The random bytes are read
+// from crypto/rand, or from Options.Rand for functions returned by NewFuncMap.
+func WeightedChoice(operand interface{}) (string, error) {
+	return defaultEnv.weightedChoice(operand)
+}
+
+// HashBucket assigns key to one of n buckets, numbered 0 through n-1, using a jump consistent
+// hash of the key's 64-bit FNV-1a hash.  The assignment is stable across runs, platforms, and
+// versions of haven, and increasing n moves only about 1/n of the keys to new buckets.
+func HashBucket(n int, key string) (int, error) {
+	if n <= 0 {
+		return 0, argumentError("HashBucket", 0, ErrInvalidArgument, "bucket count %d is not positive", n)
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	k := h.Sum64()
+
+	var b, j int64 = -1, 0
+	for j < int64(n) {
+		b = j
+		k = k*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((k>>33)+1)))
+	}
+	return int(b), nil
+}
+
+func (e env) shuffle(values []string) error {
+	for i := len(values) - 1; i > 0; i-- {
+		j, err := e.uniform(uint64(i + 1))
+		if err != nil {
+			return err
+		}
+		values[i], values[j] = values[j], values[i]
+	}
+	return nil
+}
+
+func (e env) sample(n int, operand []string) ([]string, error) {
+	if n < 0 || n > len(operand) {
+		return nil, argumentError("Sample", 0, ErrOutOfRange, "sample size %d is not between 0 and %d", n, len(operand))
+	}
+	pool := make([]string, len(operand))
+	copy(pool, operand)
+	for i := 0; i < n; i++ {
+		j, err := e.uniform(uint64(len(pool) - i))
+		if err != nil {
+			return nil, err
+		}
+		pool[i], pool[i+int(j)] = pool[i+int(j)], pool[i]
+	}
+	return pool[:n], nil
+}
+
+func (e env) weightedChoice(operand interface{}) (string, error) {
+	v := reflect.ValueOf(operand)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return "", argumentError("WeightedChoice", 0, ErrInvalidArgument, "cannot choose from %s: expected map with string keys", TypeOf(operand))
+	}
+
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+
+	weights := make([]float64, len(keys))
+	var total float64
+	for i, k := range keys {
+		w, err := toFloat(v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key())).Interface())
+		if err != nil {
+			return "", argumentError("WeightedChoice", 0, ErrInvalidArgument, "invalid weight for %q: %s", k, err)
+		}
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return "", argumentError("WeightedChoice", 0, ErrInvalidArgument, "invalid weight for %q: %v", k, w)
+		}
+		weights[i] = w
+		total += w
+	}
+	if total <= 0 || math.IsInf(total, 0) {
+		return "", argumentError("WeightedChoice", 0, ErrInvalidArgument, "weights must have a positive, finite sum")
+	}
+
+	n, err := e.uniform(1 << 53)
+	if err != nil {
+		return "", err
+	}
+	target := float64(n) / (1 << 53) * total
+	for i, w := range weights {
+		if target < w {
+			return keys[i], nil
+		}
+		target -= w
+	}
+	// Rounding may leave target just beyond the final weight; fall back to the last
+	// positively weighted key.
+	for i := len(keys) - 1; ; i-- {
+		if weights[i] > 0 {
+			return keys[i], nil
+		}
+	}
+}
+
+// hashStream is a deterministic byte stream formed by concatenating SHA-256 hashes of the seed
+// and an incrementing counter.  Unlike math/rand, its output is fixed independent of Go version.
+type hashStream struct {
+	seed    []byte
+	counter uint64
+	buf     []byte
+}
+
+func (s *hashStream) Read(p []byte) (int, error) {
+	for n := 0; n < len(p); {
+		if len(s.buf) == 0 {
+			var block [8]byte
+			binary.BigEndian.PutUint64(block[:], s.counter)
+			s.counter++
+			sum := sha256.Sum256(append(append([]byte{}, s.seed...), block[:]...))
+			s.buf = sum[:]
+		}
+		c := copy(p[n:], s.buf)
+		s.buf = s.buf[c:]
+		n += c
+	}
+	return len(p), nil
+}
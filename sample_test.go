@@ -0,0 +1,180 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeededShuffle(t *testing.T) {
+	var tests = []struct {
+		Seed     interface{}
+		Operand  []string
+		Expected []string
+		Valid    bool
+	}{
+		{Seed: "canary", Operand: []string{"a", "b", "c", "d", "e", "f"}, Expected: []string{"e", "f", "c", "b", "a", "d"}, Valid: true},
+		{Seed: 42, Operand: []string{"a", "b", "c", "d", "e", "f"}, Expected: []string{"d", "c", "a", "e", "b", "f"}, Valid: true},
+		{Seed: "42", Operand: []string{"a", "b", "c", "d", "e", "f"}, Expected: []string{"d", "c", "a", "e", "b", "f"}, Valid: true},
+		{Seed: "empty", Operand: []string{}, Expected: []string{}, Valid: true},
+		{Seed: []int{1}, Operand: []string{"a"}, Valid: false},
+	}
+
+	for _, test := range tests {
+		original := append([]string{}, test.Operand...)
+		result, err := SeededShuffle(test.Seed, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("SeededShuffle encountered unexpected error: %s.  Seed: %v, Operand: %#v", err, test.Seed, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("SeededShuffle failed to return an error.  Seed: %v, Operand: %#v", test.Seed, test.Operand)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("SeededShuffle result incorrect.  Seed: %v, Operand: %#v, Expected: %#v, Received: %#v", test.Seed, test.Operand, test.Expected, result)
+		}
+		if !reflect.DeepEqual(original, test.Operand) {
+			t.Errorf("SeededShuffle modified its operand.  Seed: %v, Operand: %#v", test.Seed, test.Operand)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	var tests = []struct {
+		N       int
+		Operand []string
+		Valid   bool
+	}{
+		{N: 3, Operand: []string{"a", "b", "c", "d", "e"}, Valid: true},
+		{N: 5, Operand: []string{"a", "b", "c", "d", "e"}, Valid: true},
+		{N: 0, Operand: []string{"a"}, Valid: true},
+		{N: 2, Operand: []string{"a"}, Valid: false},
+		{N: -1, Operand: []string{"a"}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := Sample(test.N, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("Sample encountered unexpected error: %s.  N: %d, Operand: %#v", err, test.N, test.Operand)
+		}
+		if !test.Valid {
+			if err == nil {
+				t.Errorf("Sample failed to return an error.  N: %d, Operand: %#v, Received: %#v", test.N, test.Operand, result)
+			}
+			continue
+		}
+		if len(result) != test.N || len(Union(result, nil)) != test.N || len(Intersect(result, test.Operand)) != test.N {
+			t.Errorf("Sample result incorrect.  N: %d, Operand: %#v, Received: %#v", test.N, test.Operand, result)
+		}
+	}
+
+	first, _ := seededEnv(5).sample(3, []string{"a", "b", "c", "d", "e"})
+	second, _ := seededEnv(5).sample(3, []string{"a", "b", "c", "d", "e"})
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Sample result not deterministic for a seeded source.  First: %#v, Second: %#v", first, second)
+	}
+}
+
+func TestWeightedChoice(t *testing.T) {
+	var tests = []struct {
+		Operand interface{}
+		Allowed []string
+		Valid   bool
+	}{
+		{Operand: map[string]interface{}{"blue": 9, "green": 1.5}, Allowed: []string{"blue", "green"}, Valid: true},
+		{Operand: map[string]int{"only": 1, "never": 0}, Allowed: []string{"only"}, Valid: true},
+		{Operand: map[string]string{"a": "0.5", "b": "2"}, Allowed: []string{"a", "b"}, Valid: true},
+		{Operand: map[string]int{"a": 0}, Valid: false},
+		{Operand: map[string]int{"a": -1, "b": 2}, Valid: false},
+		{Operand: map[string]interface{}{"a": "heavy"}, Valid: false},
+		{Operand: map[int]int{1: 1}, Valid: false},
+		{Operand: []string{"a"}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := WeightedChoice(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("WeightedChoice encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("WeightedChoice failed to return an error.  Operand: %#v, Received: %s", test.Operand, result)
+		}
+		if test.Valid && len(Intersect([]string{result}, test.Allowed)) != 1 {
+			t.Errorf("WeightedChoice result incorrect.  Operand: %#v, Received: %s", test.Operand, result)
+		}
+	}
+
+	e := seededEnv(9)
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		choice, _ := e.weightedChoice(map[string]int{"blue": 9, "green": 1})
+		counts[choice]++
+	}
+	if counts["blue"] < 8700 || counts["blue"] > 9300 {
+		t.Errorf("WeightedChoice results not proportional to weights.  Received: %v", counts)
+	}
+}
+
+func TestHashBucket(t *testing.T) {
+	var tests = []struct {
+		N        int
+		Key      string
+		Expected int
+		Valid    bool
+	}{
+		{N: 1, Key: "web-01", Expected: 0, Valid: true},
+		{N: 10, Key: "web-01", Expected: 2, Valid: true},
+		{N: 10, Key: "web-02", Expected: 3, Valid: true},
+		{N: 10, Key: "user@example.com", Expected: 5, Valid: true},
+		{N: 1000, Key: "user@example.com", Expected: 193, Valid: true},
+		{N: 1000, Key: "", Expected: 266, Valid: true},
+		{N: 0, Key: "web-01", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := HashBucket(test.N, test.Key)
+		if test.Valid && err != nil {
+			t.Errorf("HashBucket encountered unexpected error: %s.  N: %d, Key: %s", err, test.N, test.Key)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("HashBucket failed to return an error.  N: %d, Key: %s", test.N, test.Key)
+		}
+		if result != test.Expected {
+			t.Errorf("HashBucket result incorrect.  N: %d, Key: %s, Expected: %d, Received: %d", test.N, test.Key, test.Expected, result)
+		}
+	}
+
+	moved := 0
+	keys, _ := Seq(1, 1000)
+	for _, key := range keys {
+		before, _ := HashBucket(10, string(rune(key)))
+		after, _ := HashBucket(11, string(rune(key)))
+		if before != after {
+			if after != 10 {
+				t.Fatalf("HashBucket moved a key between existing buckets.  Key: %d, Before: %d, After: %d", key, before, after)
+			}
+			moved++
+		}
+	}
+	if moved > 150 {
+		t.Errorf("HashBucket moved too many keys when adding a bucket.  Moved: %d", moved)
+	}
+}
@@ -0,0 +1,466 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	semverRegex  = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+	partialRegex = regexp.MustCompile(`^v?(0|[1-9]\d*|[xX*])(?:\.(0|[1-9]\d*|[xX*])(?:\.(0|[1-9]\d*|[xX*])(?:-([0-9a-zA-Z.-]+))?(?:\+[0-9a-zA-Z.-]+)?)?)?$`)
+	hyphenRegex  = regexp.MustCompile(`^(\S+)\s+-\s+(\S+)$`)
+	opRegex      = regexp.MustCompile(`^(<=|>=|<|>|=|\^|~>|~)?\s*`)
+)
+
+// Semver is a semantic version as defined by https://semver.org.
+type Semver struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Build      string
+}
+
+// String returns v in semantic version form, such as "1.2.3-beta.1+build.5".
+func (v Semver) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// compare returns -1, 0, or 1 if v has lower, equal, or higher precedence than other.
+// Build metadata does not affect precedence.
+func (v Semver) compare(other Semver) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] != pair[1] {
+			return compareInts(pair[0], pair[1])
+		}
+	}
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	ids, otherIDs := strings.Split(v.Prerelease, "."), strings.Split(other.Prerelease, ".")
+	for i := 0; i < len(ids) && i < len(otherIDs); i++ {
+		if ids[i] == otherIDs[i] {
+			continue
+		}
+		num, numErr := strconv.ParseUint(ids[i], 10, 64)
+		otherNum, otherErr := strconv.ParseUint(otherIDs[i], 10, 64)
+		switch {
+		case numErr == nil && otherErr == nil:
+			if num < otherNum {
+				return -1
+			}
+			return 1
+		case numErr == nil:
+			return -1
+		case otherErr == nil:
+			return 1
+		case ids[i] < otherIDs[i]:
+			return -1
+		default:
+			return 1
+		}
+	}
+	return compareInts(len(ids), len(otherIDs))
+}
+
+/*
+ * Semantic Versions
+ */
+
+// SemverParse parses operand as a semantic version.  A leading "v" is permitted.
+func SemverParse(operand string) (Semver, error) {
+	v, err := parseSemver(operand)
+	return v, wrapArgumentError("SemverParse", 0, err)
+}
+
+func parseSemver(operand string) (Semver, error) {
+	matches := semverRegex.FindStringSubmatch(strings.TrimSpace(operand))
+	if matches == nil {
+		return Semver{}, fmt.Errorf("invalid semantic version %q", operand)
+	}
+	var v Semver
+	for i, field := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		n, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return Semver{}, rangeErrorf("invalid semantic version %q: %s", operand, err)
+		}
+		*field = n
+	}
+	v.Prerelease, v.Build = matches[4], matches[5]
+	return v, nil
+}
+
+// SemverCompare compares the semantic versions a and operand, returning -1 if operand has
+// lower precedence than a, 0 if they have equal precedence, and 1 if operand has higher
+// precedence than a.
+func SemverCompare(a, operand string) (int, error) {
+	va, err := parseSemver(a)
+	if err != nil {
+		return 0, wrapArgumentError("SemverCompare", 0, err)
+	}
+	vop, err := parseSemver(operand)
+	if err != nil {
+		return 0, wrapArgumentError("SemverCompare", 1, err)
+	}
+	return vop.compare(va), nil
+}
+
+// SemverSatisfies checks if the semantic version operand satisfies constraint.  Constraints
+// follow npm's syntax:
+//
+//	=1.2.3 >1.2.3 >=1.2.3 <1.2.3 <=1.2.3   comparisons (a bare version means =)
+//	1.2.x 1.* *                            wildcards, where "1.2" is equivalent to "1.2.x"
+//	~1.2.3                                 patch updates: >=1.2.3 <1.3.0
+//	^1.2.3                                 updates that don't change the leftmost non-zero part
+//	1.2.3 - 2.3.4                          inclusive range: >=1.2.3 <=2.3.4
+//	>=1.2.3 <2.0.0                         comparators separated by spaces or commas must all match
+//	^1.2.3 || ^2.0.0                       either constraint must match
+//
+// As with npm, a prerelease version only satisfies a constraint if one of the comparators it
+// must match is for the same major, minor, and patch version and has a prerelease.
+func SemverSatisfies(constraint string, operand string) (bool, error) {
+	sets, err := parseSemverConstraint(constraint)
+	if err != nil {
+		return false, wrapArgumentError("SemverSatisfies", 0, err)
+	}
+	v, err := parseSemver(operand)
+	if err != nil {
+		return false, wrapArgumentError("SemverSatisfies", 1, err)
+	}
+	for _, set := range sets {
+		if set.matches(v) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SemverBump increments part of the semantic version operand.  Part is one of "major",
+// "minor", "patch", or "pre".  Bumping a prerelease version's major, minor, or patch
+// releases it when the lower parts are already zero, so "2.0.0-rc.1" bumps to "2.0.0" for
+// "major".  Bumping "pre" increments the last numeric prerelease identifier, appending ".0"
+// if there is none, or increments the patch version and sets the prerelease to "0" for
+// release versions.  Build metadata is removed.
+func SemverBump(part string, operand string) (string, error) {
+	v, err := parseSemver(operand)
+	if err != nil {
+		return "", wrapArgumentError("SemverBump", 1, err)
+	}
+	prerelease := v.Prerelease
+	v.Prerelease, v.Build = "", ""
+	switch part {
+	case "major":
+		if prerelease == "" || v.Minor != 0 || v.Patch != 0 {
+			v.Major++
+		}
+		v.Minor, v.Patch = 0, 0
+	case "minor":
+		if prerelease == "" || v.Patch != 0 {
+			v.Minor++
+		}
+		v.Patch = 0
+	case "patch":
+		if prerelease == "" {
+			v.Patch++
+		}
+	case "pre":
+		if prerelease == "" {
+			v.Patch++
+			v.Prerelease = "0"
+			break
+		}
+		ids := strings.Split(prerelease, ".")
+		bumped := false
+		for i := len(ids) - 1; i >= 0; i-- {
+			if n, err := strconv.ParseUint(ids[i], 10, 64); err == nil {
+				ids[i] = strconv.FormatUint(n+1, 10)
+				bumped = true
+				break
+			}
+		}
+		if !bumped {
+			ids = append(ids, "0")
+		}
+		v.Prerelease = strings.Join(ids, ".")
+	default:
+		return "", argumentError("SemverBump", 0, ErrInvalidArgument, "version part %q is not major, minor, patch, or pre", part)
+	}
+	return v.String(), nil
+}
+
+// SortSemver returns a copy of operand sorted by ascending semantic version precedence.
+// Versions with equal precedence retain their original order.
+func SortSemver(operand []string) ([]string, error) {
+	versions := make([]Semver, len(operand))
+	for i, s := range operand {
+		v, err := parseSemver(s)
+		if err != nil {
+			return nil, wrapArgumentError("SortSemver", 0, fmt.Errorf("element %d: %w", i, err))
+		}
+		versions[i] = v
+	}
+	sorted := make([]string, len(operand))
+	copy(sorted, operand)
+	sort.Stable(semverSorter{strings: sorted, versions: versions})
+	return sorted, nil
+}
+
+type semverSorter struct {
+	strings  []string
+	versions []Semver
+}
+
+func (s semverSorter) Len() int           { return len(s.strings) }
+func (s semverSorter) Less(i, j int) bool { return s.versions[i].compare(s.versions[j]) < 0 }
+func (s semverSorter) Swap(i, j int) {
+	s.strings[i], s.strings[j] = s.strings[j], s.strings[i]
+	s.versions[i], s.versions[j] = s.versions[j], s.versions[i]
+}
+
+type semverComparator struct {
+	op      string
+	version Semver
+
+	// synthetic is set when version was derived while desugaring a partial version or range
+	// rather than written by the user, so its "-0" prerelease must not opt prereleases in.
+	synthetic bool
+}
+
+func syntheticBound(op string, version Semver) semverComparator {
+	return semverComparator{op: op, version: version, synthetic: true}
+}
+
+func (c semverComparator) matches(v Semver) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return cmp == 0
+}
+
+type semverComparatorSet []semverComparator
+
+func (set semverComparatorSet) matches(v Semver) bool {
+	for _, c := range set {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	if v.Prerelease == "" {
+		return true
+	}
+	for _, c := range set {
+		cv := c.version
+		if !c.synthetic && cv.Prerelease != "" && cv.Major == v.Major && cv.Minor == v.Minor && cv.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// partialVersion is a version from a constraint, where trailing parts may be omitted or
+// wildcards.  Parts is the number of leading numeric parts that were specified.
+type partialVersion struct {
+	Semver
+	parts int
+}
+
+func parsePartialVersion(s string) (partialVersion, error) {
+	matches := partialRegex.FindStringSubmatch(s)
+	if matches == nil {
+		return partialVersion{}, fmt.Errorf("invalid version %q in constraint", s)
+	}
+	var p partialVersion
+	for i, field := range []*int{&p.Major, &p.Minor, &p.Patch} {
+		part := matches[i+1]
+		if part == "" || part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return partialVersion{}, rangeErrorf("invalid version %q in constraint: %s", s, err)
+		}
+		*field = n
+		p.parts++
+	}
+	if p.parts == 3 {
+		p.Prerelease = matches[4]
+	}
+	return p, nil
+}
+
+// next returns the lowest version above every version matching p.  P must have at
+// least one part.
+func (p partialVersion) next() Semver {
+	if p.parts == 1 {
+		return Semver{Major: p.Major + 1, Prerelease: "0"}
+	}
+	if p.parts == 2 {
+		return Semver{Major: p.Major, Minor: p.Minor + 1, Prerelease: "0"}
+	}
+	return Semver{Major: p.Major, Minor: p.Minor, Patch: p.Patch + 1, Prerelease: "0"}
+}
+
+func parseSemverConstraint(constraint string) ([]semverComparatorSet, error) {
+	var sets []semverComparatorSet
+	for _, alternative := range strings.Split(constraint, "||") {
+		alternative = strings.TrimSpace(alternative)
+		if matches := hyphenRegex.FindStringSubmatch(alternative); matches != nil {
+			set, err := hyphenRange(matches[1], matches[2])
+			if err != nil {
+				return nil, err
+			}
+			sets = append(sets, set)
+			continue
+		}
+
+		var set semverComparatorSet
+		rest := strings.TrimSpace(strings.Replace(alternative, ",", " ", -1))
+		if rest == "" {
+			rest = "*"
+		}
+		for rest != "" {
+			op := opRegex.FindStringSubmatch(rest)
+			rest = rest[len(op[0]):]
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			comparators, err := desugarComparator(op[1], rest[:end])
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, comparators...)
+			rest = strings.TrimSpace(rest[end:])
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+func hyphenRange(lower, upper string) (semverComparatorSet, error) {
+	from, err := parsePartialVersion(lower)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parsePartialVersion(upper)
+	if err != nil {
+		return nil, err
+	}
+	set := semverComparatorSet{{op: ">=", version: from.Semver}}
+	switch to.parts {
+	case 0:
+	case 3:
+		set = append(set, semverComparator{op: "<=", version: to.Semver})
+	default:
+		set = append(set, syntheticBound("<", to.next()))
+	}
+	return set, nil
+}
+
+func desugarComparator(op, version string) (semverComparatorSet, error) {
+	p, err := parsePartialVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	lower := semverComparator{op: ">=", version: p.Semver}
+	if p.parts == 0 {
+		switch op {
+		case "<", ">":
+			return semverComparatorSet{syntheticBound("<", Semver{Prerelease: "0"})}, nil
+		}
+		return semverComparatorSet{lower}, nil
+	}
+
+	switch op {
+	case "^":
+		upper := Semver{Major: p.Major + 1, Prerelease: "0"}
+		switch {
+		case p.Major == 0 && p.parts == 1:
+		case p.Major == 0 && (p.Minor != 0 || p.parts == 2):
+			upper = Semver{Minor: p.Minor + 1, Prerelease: "0"}
+		case p.Major == 0:
+			upper = Semver{Patch: p.Patch + 1, Prerelease: "0"}
+		}
+		return semverComparatorSet{lower, syntheticBound("<", upper)}, nil
+	case "~", "~>":
+		if p.parts == 1 {
+			return semverComparatorSet{lower, syntheticBound("<", p.next())}, nil
+		}
+		return semverComparatorSet{lower, syntheticBound("<", Semver{Major: p.Major, Minor: p.Minor + 1, Prerelease: "0"})}, nil
+	case ">":
+		if p.parts == 3 {
+			return semverComparatorSet{{op: ">", version: p.Semver}}, nil
+		}
+		return semverComparatorSet{syntheticBound(">=", p.next())}, nil
+	case "<=":
+		if p.parts == 3 {
+			return semverComparatorSet{{op: "<=", version: p.Semver}}, nil
+		}
+		return semverComparatorSet{syntheticBound("<", p.next())}, nil
+	case "<":
+		if p.parts == 3 {
+			return semverComparatorSet{{op: "<", version: p.Semver}}, nil
+		}
+		return semverComparatorSet{syntheticBound("<", Semver{Major: p.Major, Minor: p.Minor, Prerelease: "0"})}, nil
+	case ">=":
+		return semverComparatorSet{lower}, nil
+	}
+	if p.parts == 3 {
+		return semverComparatorSet{{op: "=", version: p.Semver}}, nil
+	}
+	return semverComparatorSet{lower, syntheticBound("<", p.next())}, nil
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
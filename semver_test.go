@@ -0,0 +1,255 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSemverParse(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Expected Semver
+		Valid    bool
+	}{
+		{Operand: "1.2.3", Expected: Semver{Major: 1, Minor: 2, Patch: 3}, Valid: true},
+		{Operand: "v10.20.30", Expected: Semver{Major: 10, Minor: 20, Patch: 30}, Valid: true},
+		{Operand: "1.0.0-alpha.1+build.5", Expected: Semver{Major: 1, Prerelease: "alpha.1", Build: "build.5"}, Valid: true},
+		{Operand: "1.0.0+20130313144700", Expected: Semver{Major: 1, Build: "20130313144700"}, Valid: true},
+		{Operand: "1.0.0-x-y-z.--", Expected: Semver{Major: 1, Prerelease: "x-y-z.--"}, Valid: true},
+		{Operand: "1.2", Valid: false},
+		{Operand: "01.2.3", Valid: false},
+		{Operand: "1.2.3-01", Valid: false},
+		{Operand: "1.2.3-", Valid: false},
+		{Operand: "1.2.3+", Valid: false},
+		{Operand: "1.2.3.4", Valid: false},
+		{Operand: "99999999999999999999.0.0", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := SemverParse(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("SemverParse encountered unexpected error: %s.  Operand: %s", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("SemverParse failed to return an error.  Operand: %s, Received: %#v", test.Operand, result)
+		}
+		if result != test.Expected {
+			t.Errorf("SemverParse result incorrect.  Operand: %s, Expected: %#v, Received: %#v", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestSemverString(t *testing.T) {
+	var tests = []struct {
+		Operand  Semver
+		Expected string
+	}{
+		{Operand: Semver{Major: 1, Minor: 2, Patch: 3}, Expected: "1.2.3"},
+		{Operand: Semver{Major: 1, Prerelease: "rc.1"}, Expected: "1.0.0-rc.1"},
+		{Operand: Semver{Major: 1, Prerelease: "rc.1", Build: "abc"}, Expected: "1.0.0-rc.1+abc"},
+	}
+
+	for _, test := range tests {
+		result := test.Operand.String()
+		if result != test.Expected {
+			t.Errorf("Semver.String result incorrect.  Operand: %#v, Expected: %s, Received: %s", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestVersionString(t *testing.T) {
+	var original struct {
+		Major int
+		Minor int
+		Patch int
+	} = Version
+	if Version.String() != fmt.Sprintf("%d.%d.%d", original.Major, original.Minor, original.Patch) {
+		t.Errorf("Version.String result incorrect.  Received: %s", Version)
+	}
+	if _, err := SemverParse(Version.String()); err != nil {
+		t.Errorf("Version.String result is not a semantic version: %s", err)
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "1.10.0", "2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			result, err := SemverCompare(ordered[j], ordered[i])
+			if err != nil {
+				t.Errorf("SemverCompare encountered unexpected error: %s.  Operand: %s, A: %s", err, ordered[i], ordered[j])
+			}
+			expected := compareInts(i, j)
+			if result != expected {
+				t.Errorf("SemverCompare result incorrect.  Operand: %s, A: %s, Expected: %d, Received: %d", ordered[i], ordered[j], expected, result)
+			}
+		}
+	}
+
+	if result, _ := SemverCompare("1.0.0+a", "1.0.0+b"); result != 0 {
+		t.Errorf("SemverCompare result incorrect.  Build metadata affected precedence: %d", result)
+	}
+	if _, err := SemverCompare("1.0", "1.0.0"); err == nil {
+		t.Errorf("SemverCompare failed to return an error.  A: 1.0")
+	}
+	if _, err := SemverCompare("1.0.0", "1.0"); err == nil {
+		t.Errorf("SemverCompare failed to return an error.  Operand: 1.0")
+	}
+}
+
+func TestSemverSatisfies(t *testing.T) {
+	var tests = []struct {
+		Constraint string
+		Operand    string
+		Expected   bool
+		Valid      bool
+	}{
+		{Constraint: "1.2.3", Operand: "1.2.3", Expected: true, Valid: true},
+		{Constraint: "=1.2.3", Operand: "1.2.4", Expected: false, Valid: true},
+		{Constraint: ">1.2.3", Operand: "1.2.4", Expected: true, Valid: true},
+		{Constraint: ">= 1.2.3", Operand: "1.2.3", Expected: true, Valid: true},
+		{Constraint: "<1.2.3", Operand: "1.2.3", Expected: false, Valid: true},
+		{Constraint: "<=1.2.3", Operand: "1.2.3", Expected: true, Valid: true},
+		{Constraint: ">1.2", Operand: "1.2.9", Expected: false, Valid: true},
+		{Constraint: ">1.2", Operand: "1.3.0", Expected: true, Valid: true},
+		{Constraint: "<=1.2", Operand: "1.2.9", Expected: true, Valid: true},
+		{Constraint: "<1.2", Operand: "1.1.9", Expected: true, Valid: true},
+		{Constraint: "<1.2", Operand: "1.2.0", Expected: false, Valid: true},
+		{Constraint: "1.2.x", Operand: "1.2.9", Expected: true, Valid: true},
+		{Constraint: "1.2", Operand: "1.3.0", Expected: false, Valid: true},
+		{Constraint: "1.*", Operand: "1.9.0", Expected: true, Valid: true},
+		{Constraint: "*", Operand: "9.9.9", Expected: true, Valid: true},
+		{Constraint: "", Operand: "9.9.9", Expected: true, Valid: true},
+		{Constraint: "~1.2.3", Operand: "1.2.9", Expected: true, Valid: true},
+		{Constraint: "~1.2.3", Operand: "1.3.0", Expected: false, Valid: true},
+		{Constraint: "~1", Operand: "1.9.0", Expected: true, Valid: true},
+		{Constraint: "^1.2.3", Operand: "1.9.0", Expected: true, Valid: true},
+		{Constraint: "^1.2.3", Operand: "2.0.0", Expected: false, Valid: true},
+		{Constraint: "^1.2.3", Operand: "1.2.2", Expected: false, Valid: true},
+		{Constraint: "^0.2.3", Operand: "0.2.9", Expected: true, Valid: true},
+		{Constraint: "^0.2.3", Operand: "0.3.0", Expected: false, Valid: true},
+		{Constraint: "^0.0.3", Operand: "0.0.4", Expected: false, Valid: true},
+		{Constraint: "^0.0", Operand: "0.0.9", Expected: true, Valid: true},
+		{Constraint: "^0", Operand: "0.9.0", Expected: true, Valid: true},
+		{Constraint: "1.2.3 - 2.3.4", Operand: "2.3.4", Expected: true, Valid: true},
+		{Constraint: "1.2.3 - 2.3.4", Operand: "2.3.5", Expected: false, Valid: true},
+		{Constraint: "1.2.3 - 2.3", Operand: "2.3.9", Expected: true, Valid: true},
+		{Constraint: "1.2 - 2", Operand: "1.2.0", Expected: true, Valid: true},
+		{Constraint: ">=1.2.3 <2.0.0", Operand: "1.5.0", Expected: true, Valid: true},
+		{Constraint: ">=1.2.3, <2.0.0", Operand: "2.0.0", Expected: false, Valid: true},
+		{Constraint: "^1.2.3 || ^3.0.0", Operand: "3.1.0", Expected: true, Valid: true},
+		{Constraint: "^1.2.3 || ^3.0.0", Operand: "2.1.0", Expected: false, Valid: true},
+		{Constraint: "^1.2.3", Operand: "1.5.0-beta", Expected: false, Valid: true},
+		{Constraint: "^1.2.3-beta.2", Operand: "1.2.3-beta.4", Expected: true, Valid: true},
+		{Constraint: "^1.2.3-beta.2", Operand: "1.2.3-alpha", Expected: false, Valid: true},
+		{Constraint: "^1.2.3-beta.2", Operand: "1.2.4-beta.4", Expected: false, Valid: true},
+		{Constraint: ">1.2", Operand: "1.3.0-alpha", Expected: false, Valid: true},
+		{Constraint: ">1.2", Operand: "1.3.0", Expected: true, Valid: true},
+		{Constraint: "1.2.3 - 2.3", Operand: "2.4.0-alpha", Expected: false, Valid: true},
+		{Constraint: ">=1.0.0", Operand: "v1.0.0+build", Expected: true, Valid: true},
+		{Constraint: ">=1.0.0", Operand: "1.0", Valid: false},
+		{Constraint: ">=dog", Operand: "1.0.0", Valid: false},
+		{Constraint: "1.2.3 - ", Operand: "1.0.0", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := SemverSatisfies(test.Constraint, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("SemverSatisfies encountered unexpected error: %s.  Operand: %s, Constraint: %s", err, test.Operand, test.Constraint)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("SemverSatisfies failed to return an error.  Operand: %s, Constraint: %s", test.Operand, test.Constraint)
+		}
+		if result != test.Expected {
+			t.Errorf("SemverSatisfies result incorrect.  Operand: %s, Constraint: %s, Expected: %t, Received: %t", test.Operand, test.Constraint, test.Expected, result)
+		}
+	}
+}
+
+func TestSemverBump(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Part     string
+		Expected string
+		Valid    bool
+	}{
+		{Operand: "1.2.3", Part: "major", Expected: "2.0.0", Valid: true},
+		{Operand: "1.2.3", Part: "minor", Expected: "1.3.0", Valid: true},
+		{Operand: "1.2.3", Part: "patch", Expected: "1.2.4", Valid: true},
+		{Operand: "1.2.3+build", Part: "patch", Expected: "1.2.4", Valid: true},
+		{Operand: "2.0.0-rc.1", Part: "major", Expected: "2.0.0", Valid: true},
+		{Operand: "2.1.0-rc.1", Part: "major", Expected: "3.0.0", Valid: true},
+		{Operand: "1.3.0-rc.1", Part: "minor", Expected: "1.3.0", Valid: true},
+		{Operand: "1.2.4-rc.1", Part: "patch", Expected: "1.2.4", Valid: true},
+		{Operand: "1.2.3", Part: "pre", Expected: "1.2.4-0", Valid: true},
+		{Operand: "1.2.4-0", Part: "pre", Expected: "1.2.4-1", Valid: true},
+		{Operand: "1.2.4-beta", Part: "pre", Expected: "1.2.4-beta.0", Valid: true},
+		{Operand: "1.2.4-beta.9", Part: "pre", Expected: "1.2.4-beta.10", Valid: true},
+		{Operand: "1.2.4-1.beta", Part: "pre", Expected: "1.2.4-2.beta", Valid: true},
+		{Operand: "1.2.3", Part: "build", Valid: false},
+		{Operand: "1.2", Part: "patch", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := SemverBump(test.Part, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("SemverBump encountered unexpected error: %s.  Operand: %s, Part: %s", err, test.Operand, test.Part)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("SemverBump failed to return an error.  Operand: %s, Part: %s, Received: %s", test.Operand, test.Part, result)
+		}
+		if result != test.Expected {
+			t.Errorf("SemverBump result incorrect.  Operand: %s, Part: %s, Expected: %s, Received: %s", test.Operand, test.Part, test.Expected, result)
+		}
+	}
+}
+
+func TestSortSemver(t *testing.T) {
+	var tests = []struct {
+		Operand  []string
+		Expected []string
+		Valid    bool
+	}{
+		{Operand: []string{"1.10.0", "1.2.0", "v1.9.0", "1.2.0-rc.1"}, Expected: []string{"1.2.0-rc.1", "1.2.0", "v1.9.0", "1.10.0"}, Valid: true},
+		{Operand: []string{"1.0.0+b", "1.0.0+a"}, Expected: []string{"1.0.0+b", "1.0.0+a"}, Valid: true},
+		{Operand: []string{}, Expected: []string{}, Valid: true},
+		{Operand: []string{"1.0.0", "latest"}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := SortSemver(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("SortSemver encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("SortSemver failed to return an error.  Operand: %#v", test.Operand)
+		}
+		if !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("SortSemver result incorrect.  Operand: %#v, Expected: %#v, Received: %#v", test.Operand, test.Expected, result)
+		}
+	}
+}
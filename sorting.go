@@ -0,0 +1,289 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// accentBases maps each base letter to the accented Latin letters that sort as variants of it
+// in the default collation.
+var accentBases = map[string]string{
+	"a": "àáâãäåāăą", "c": "çćĉċč", "d": "ďđ", "e": "èéêëēĕėęě", "g": "ĝğġģ", "h": "ĥħ",
+	"i": "ìíîïĩīĭįı", "j": "ĵ", "k": "ķ", "l": "ĺļľŀł", "n": "ñńņňŉ", "o": "òóôõöøōŏő",
+	"r": "ŕŗř", "s": "śŝşš", "t": "ţťŧ", "u": "ùúûüũūŭůűų", "w": "ŵ", "y": "ýÿŷ", "z": "źżž",
+	"ae": "æ", "oe": "œ", "ss": "ß",
+}
+
+var accentFolds = func() map[rune]string {
+	folds := make(map[rune]string)
+	for base, accented := range accentBases {
+		for _, r := range accented {
+			folds[r] = base
+		}
+	}
+	return folds
+}()
+
+/*
+ * Sorting
+ */
+
+// SortNatural returns a copy of operand sorted so that runs of digits compare by numeric value:
+// "file2" sorts before "file10", and "1.9" before "1.10".  Other characters compare by
+// codepoint.
+func SortNatural(operand []string) []string { return sortNatural(false, operand) }
+
+// SortNaturalDesc is like SortNatural, but sorts operand in descending order.
+func SortNaturalDesc(operand []string) []string { return sortNatural(true, operand) }
+
+// SortNumeric returns a copy of operand sorted by the numeric value of each element, which is
+// parsed with strconv.ParseFloat.  Elements that are not numbers return an error.
+func SortNumeric(operand []string) ([]string, error) {
+	return sortNumeric("SortNumeric", false, operand)
+}
+
+// SortNumericDesc is like SortNumeric, but sorts operand in descending order.
+func SortNumericDesc(operand []string) ([]string, error) {
+	return sortNumeric("SortNumericDesc", true, operand)
+}
+
+// SortFold returns a copy of operand sorted without regard to case, so that "alice" sorts
+// before "Bob".  Elements that differ only in case keep a consistent order, with lowercase first.
+func SortFold(operand []string) []string { return sortFold(false, operand) }
+
+// SortFoldDesc is like SortFold, but sorts operand in descending order, with uppercase first
+// among elements that differ only in case.
+func SortFoldDesc(operand []string) []string { return sortFold(true, operand) }
+
+// SortStable returns a copy of operand sorted according to the collation rules of a language,
+// reversed if reverse is true.  Collation is "" for rules suitable to most languages, or a tag
+// supported by LocaleFuncMap, such as "sv" or "tr".  Letters are compared without regard to
+// accents and case, so "Émile" sorts between "eclair" and "zebra", with accents and then case
+// breaking ties.  Languages that treat accented letters as distinct, such as Swedish placing
+// "å", "ä", and "ö" after "z", are honored.  Punctuation sorts before digits, which sort before
+// letters.  The sort is stable, so elements that collate equally keep their order in either
+// direction.
+func SortStable(collation string, reverse bool, operand []string) ([]string, error) {
+	c := newCollator(nil, nil)
+	if collation != "" {
+		l, err := lookupLocale(collation)
+		if err != nil {
+			return nil, wrapArgumentError("SortStable", 0, err)
+		}
+		c = newCollator(l.collation, l.caseMapping)
+	}
+	keys := make(map[string]collationKey, len(operand))
+	for _, elem := range operand {
+		keys[elem] = c.key(elem)
+	}
+	return sortedStrings(operand, reverse, func(a, b string) bool { return keys[a].compare(keys[b]) < 0 }), nil
+}
+
+func sortNatural(desc bool, operand []string) []string {
+	return sortedStrings(operand, desc, func(a, b string) bool { return compareNatural(a, b) < 0 })
+}
+
+func sortNumeric(fn string, desc bool, operand []string) ([]string, error) {
+	values := make(map[string]float64, len(operand))
+	for i, elem := range operand {
+		f, err := strconv.ParseFloat(strings.TrimSpace(elem), 64)
+		if err != nil {
+			return nil, wrapArgumentError(fn, 0, fmt.Errorf("element %d: %w", i, err))
+		}
+		values[elem] = f
+	}
+	return sortedStrings(operand, desc, func(a, b string) bool {
+		fa, fb := values[a], values[b]
+		return fa < fb || math.IsNaN(fa) && !math.IsNaN(fb)
+	}), nil
+}
+
+func sortFold(desc bool, operand []string) []string {
+	return sortedStrings(operand, desc, func(a, b string) bool {
+		if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+			return c < 0
+		}
+		return a > b
+	})
+}
+
+// sortedStrings returns a copy of operand sorted by less, or in reverse if desc is true.  The
+// sort is stable, so elements that are equal keep their order in either direction.
+func sortedStrings(operand []string, desc bool, less func(a, b string) bool) []string {
+	sorted := make([]string, len(operand))
+	copy(sorted, operand)
+	if desc {
+		sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[j], sorted[i]) })
+	} else {
+		sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	}
+	return sorted
+}
+
+// compareNatural compares a and b, treating runs of ASCII digits as numbers.  Numbers that are
+// equal in value compare by their count of leading zeros if the strings are otherwise equal.
+func compareNatural(a, b string) int {
+	zeros := 0
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isASCIIDigit(a[i]) && isASCIIDigit(b[j]) {
+			ei, ej := i, j
+			for ei < len(a) && isASCIIDigit(a[ei]) {
+				ei++
+			}
+			for ej < len(b) && isASCIIDigit(b[ej]) {
+				ej++
+			}
+			na, nb := strings.TrimLeft(a[i:ei], "0"), strings.TrimLeft(b[j:ej], "0")
+			if len(na) != len(nb) {
+				return compareInts(len(na), len(nb))
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			if zeros == 0 {
+				zeros = compareInts(ei-i, ej-j)
+			}
+			i, j = ei, ej
+			continue
+		}
+		if a[i] != b[j] {
+			return compareInts(int(a[i]), int(b[j]))
+		}
+		i++
+		j++
+	}
+	if c := compareInts(len(a)-i, len(b)-j); c != 0 {
+		return c
+	}
+	return zeros
+}
+
+func isASCIIDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+// collator computes collation keys with three levels of comparison: base letters, accents, and
+// case.
+type collator struct {
+	tailored    map[rune]uint32
+	caseMapping unicode.SpecialCase
+}
+
+type collationKey struct {
+	primary   []uint32
+	secondary []rune
+	tertiary  []bool
+	original  string
+}
+
+const (
+	collateSymbol uint32 = iota + 1
+	collateDigit
+	collateLetter
+)
+
+func newCollator(tailoring []string, caseMapping unicode.SpecialCase) *collator {
+	c := &collator{tailored: make(map[rune]uint32), caseMapping: caseMapping}
+	for _, rule := range tailoring {
+		base, size := utf8.DecodeRuneInString(rule)
+		for i, r := range []rune(rule[size:]) {
+			c.tailored[r] = letterWeight(base) + uint32(i) + 1
+		}
+	}
+	return c
+}
+
+// letterWeight leaves room for tailored letters after each base letter.
+func letterWeight(r rune) uint32 { return collateLetter<<28 | uint32(r)<<5 }
+
+func (c *collator) key(s string) collationKey {
+	k := collationKey{original: s}
+	for _, r := range s {
+		lower := unicode.ToLower(r)
+		if c.caseMapping != nil {
+			lower = c.caseMapping.ToLower(r)
+		}
+		upper := lower != r
+		if w, ok := c.tailored[lower]; ok {
+			k.append(w, 0, upper)
+			continue
+		}
+		if base, ok := accentFolds[lower]; ok {
+			for i, b := range base {
+				accent := lower
+				if i > 0 {
+					accent = 0
+				}
+				k.append(letterWeight(b), accent, upper)
+			}
+			continue
+		}
+		switch {
+		case unicode.IsLetter(lower):
+			k.append(letterWeight(lower), 0, upper)
+		case unicode.IsDigit(lower):
+			k.append(collateDigit<<28|uint32(lower), 0, false)
+		default:
+			k.append(collateSymbol<<28|uint32(lower), 0, false)
+		}
+	}
+	return k
+}
+
+func (k *collationKey) append(primary uint32, secondary rune, upper bool) {
+	k.primary = append(k.primary, primary)
+	k.secondary = append(k.secondary, secondary)
+	k.tertiary = append(k.tertiary, upper)
+}
+
+func (k collationKey) compare(other collationKey) int {
+	for i := 0; i < len(k.primary) && i < len(other.primary); i++ {
+		if k.primary[i] != other.primary[i] {
+			if k.primary[i] < other.primary[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	if c := compareInts(len(k.primary), len(other.primary)); c != 0 {
+		return c
+	}
+	for i := range k.secondary {
+		if c := compareInts(int(k.secondary[i]), int(other.secondary[i])); c != 0 {
+			return c
+		}
+	}
+	for i := range k.tertiary {
+		if k.tertiary[i] != other.tertiary[i] {
+			if other.tertiary[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(k.original, other.original)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortNatural(t *testing.T) {
+	var tests = []struct {
+		Operand  []string
+		Expected []string
+	}{
+		{Operand: []string{"file10", "file2", "file1"}, Expected: []string{"file1", "file2", "file10"}},
+		{Operand: []string{"1.10", "1.9", "1.9.1", "1.0"}, Expected: []string{"1.0", "1.9", "1.9.1", "1.10"}},
+		{Operand: []string{"a01", "a1", "a001", "a0"}, Expected: []string{"a0", "a1", "a01", "a001"}},
+		{Operand: []string{"b", "a10b", "a10a", "10", "9"}, Expected: []string{"9", "10", "a10a", "a10b", "b"}},
+		{Operand: []string{}, Expected: []string{}},
+	}
+
+	for _, test := range tests {
+		result := SortNatural(test.Operand)
+		if !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("SortNatural result incorrect.  Operand: %#v, Expected: %#v, Received: %#v", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestSortNumeric(t *testing.T) {
+	var tests = []struct {
+		Operand  []string
+		Expected []string
+		Valid    bool
+	}{
+		{Operand: []string{"10", "9", "-1.5", "1e2", " 3 "}, Expected: []string{"-1.5", " 3 ", "9", "10", "1e2"}, Valid: true},
+		{Operand: []string{"2", "NaN", "1"}, Expected: []string{"NaN", "1", "2"}, Valid: true},
+		{Operand: []string{"2", "two"}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := SortNumeric(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("SortNumeric encountered unexpected error: %s.  Operand: %#v", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("SortNumeric failed to return an error.  Operand: %#v, Received: %#v", test.Operand, result)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("SortNumeric result incorrect.  Operand: %#v, Expected: %#v, Received: %#v", test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestSortFold(t *testing.T) {
+	operand := []string{"Bob", "alice", "Alice", "carol"}
+	expected := []string{"alice", "Alice", "Bob", "carol"}
+	result := SortFold(operand)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("SortFold result incorrect.  Operand: %#v, Expected: %#v, Received: %#v", operand, expected, result)
+	}
+	if !reflect.DeepEqual(SortFold([]string{"Alice", "alice"}), expected[:2]) {
+		t.Errorf("SortFold result depends on input order")
+	}
+}
+
+func TestSortStable(t *testing.T) {
+	var tests = []struct {
+		Collation string
+		Reverse   bool
+		Operand   []string
+		Expected  []string
+		Valid     bool
+	}{
+		{Collation: "", Operand: []string{"zebra", "Émile", "eclair", "apple"}, Expected: []string{"apple", "eclair", "Émile", "zebra"}, Valid: true},
+		{Collation: "", Operand: []string{"Resume", "résumé", "resume", "rèsume"}, Expected: []string{"resume", "Resume", "rèsume", "résumé"}, Valid: true},
+		{Collation: "", Operand: []string{"b", "2", "_x", "a"}, Expected: []string{"_x", "2", "a", "b"}, Valid: true},
+		{Collation: "", Operand: []string{"Straße", "strasse", "strasze"}, Expected: []string{"strasse", "Straße", "strasze"}, Valid: true},
+		{Collation: "de", Operand: []string{"zoo", "Äpfel", "Apfel", "Bär"}, Expected: []string{"Apfel", "Äpfel", "Bär", "zoo"}, Valid: true},
+		{Collation: "sv", Operand: []string{"ödla", "zebra", "älg", "åsna", "apa"}, Expected: []string{"apa", "zebra", "åsna", "älg", "ödla"}, Valid: true},
+		{Collation: "sv", Reverse: true, Operand: []string{"ödla", "zebra", "apa"}, Expected: []string{"ödla", "zebra", "apa"}, Valid: true},
+		{Collation: "es", Operand: []string{"nube", "ñandú", "oso", "nzz"}, Expected: []string{"nube", "nzz", "ñandú", "oso"}, Valid: true},
+		{Collation: "tr", Operand: []string{"ilk", "ırmak", "Istanbul", "hane"}, Expected: []string{"hane", "ırmak", "Istanbul", "ilk"}, Valid: true},
+		{Collation: "", Reverse: true, Operand: []string{"a", "c", "b"}, Expected: []string{"c", "b", "a"}, Valid: true},
+		{Collation: "", Reverse: true, Operand: []string{"b", "a", "B"}, Expected: []string{"B", "b", "a"}, Valid: true},
+		{Collation: "xx", Operand: []string{"a"}, Valid: false},
+		{Collation: "xx", Reverse: true, Operand: []string{"a"}, Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := SortStable(test.Collation, test.Reverse, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("SortStable encountered unexpected error: %s.  Collation: %s, Operand: %#v", err, test.Collation, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("SortStable failed to return an error.  Collation: %s, Operand: %#v", test.Collation, test.Operand)
+		}
+		if test.Valid && !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("SortStable result incorrect.  Collation: %s, Reverse: %t, Operand: %#v, Expected: %#v, Received: %#v", test.Collation, test.Reverse, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestSortDesc(t *testing.T) {
+	if result := SortNaturalDesc([]string{"file2", "file10", "file1"}); !reflect.DeepEqual(result, []string{"file10", "file2", "file1"}) {
+		t.Errorf("SortNaturalDesc result incorrect.  Received: %#v", result)
+	}
+	if result, err := SortNumericDesc([]string{"9", "10", "-1"}); err != nil || !reflect.DeepEqual(result, []string{"10", "9", "-1"}) {
+		t.Errorf("SortNumericDesc result incorrect.  Received: %#v, Error: %v", result, err)
+	}
+	if _, err := SortNumericDesc([]string{"two"}); err == nil {
+		t.Errorf("SortNumericDesc failed to return an error")
+	}
+	if result := SortFoldDesc([]string{"alice", "Bob", "Alice", "carol"}); !reflect.DeepEqual(result, []string{"carol", "Bob", "Alice", "alice"}) {
+		t.Errorf("SortFoldDesc result incorrect.  Received: %#v", result)
+	}
+
+	// Descending sorts are stable: equal elements keep their order.
+	operand := []string{"1.0", "2", "1"}
+	if result, err := SortNumericDesc(operand); err != nil || !reflect.DeepEqual(result, []string{"2", "1.0", "1"}) {
+		t.Errorf("SortNumericDesc result incorrect.  Operand: %#v, Received: %#v, Error: %v", operand, result, err)
+	}
+}
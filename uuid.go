@@ -0,0 +1,140 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var uuidNamespaces = map[string]string{
+	"dns":  "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+	"url":  "6ba7b811-9dad-11d1-80b4-00c04fd430c8",
+	"oid":  "6ba7b812-9dad-11d1-80b4-00c04fd430c8",
+	"x500": "6ba7b814-9dad-11d1-80b4-00c04fd430c8",
+}
+
+/*
+ * UUIDs
+ */
+
+// UUIDv4 returns a random (version 4) UUID.  The random bytes are read from crypto/rand, or
+// from Options.Rand for functions returned by NewFuncMap.
+func UUIDv4() (string, error) { return defaultEnv.uuidV4() }
+
+// UUIDv5 returns the name-based (version 5) UUID for operand within namespace.  The same
+// namespace and operand always produce the same UUID.  Namespace is either a UUID or one of
+// the predefined namespaces "dns", "url", "oid", or "x500".
+func UUIDv5(namespace, operand string) (string, error) {
+	if predefined, ok := uuidNamespaces[namespace]; ok {
+		namespace = predefined
+	}
+	ns, err := parseUUID(namespace)
+	if err != nil {
+		return "", wrapArgumentError("UUIDv5", 0, err)
+	}
+	hash := sha1.New()
+	hash.Write(ns[:])
+	hash.Write([]byte(operand))
+	var uuid [16]byte
+	copy(uuid[:], hash.Sum(nil))
+	return formatUUID(setUUIDVersion(uuid, 5)), nil
+}
+
+// UUIDv7 returns a time-ordered (version 7) UUID, which begins with the current Unix time in
+// milliseconds followed by random bits.  The time is read from time.Now and the random bytes
+// from crypto/rand, or from Options.Clock and Options.Rand for functions returned by
+// NewFuncMap.
+func UUIDv7() (string, error) { return defaultEnv.uuidV7() }
+
+// ParseUUID parses operand as a UUID, returning it in canonical lowercase form, such as
+// "f47ac10b-58cc-4372-a567-0e02b2c3d479".  Operand may be upper or lower case, may omit
+// hyphens, and may be wrapped in braces or prefixed with "urn:uuid:".
+func ParseUUID(operand string) (string, error) {
+	uuid, err := parseUUID(operand)
+	if err != nil {
+		return "", wrapArgumentError("ParseUUID", 0, err)
+	}
+	return formatUUID(uuid), nil
+}
+
+// IsUUID checks if operand is a UUID in any of the forms accepted by ParseUUID.
+func IsUUID(operand string) bool {
+	_, err := parseUUID(operand)
+	return err == nil
+}
+
+func (e env) uuidV4() (string, error) {
+	var uuid [16]byte
+	if _, err := io.ReadFull(e.rand, uuid[:]); err != nil {
+		return "", err
+	}
+	return formatUUID(setUUIDVersion(uuid, 4)), nil
+}
+
+func (e env) uuidV7() (string, error) {
+	var uuid [16]byte
+	if _, err := io.ReadFull(e.rand, uuid[6:]); err != nil {
+		return "", err
+	}
+	ms := uint64(e.clock().UnixNano() / 1e6)
+	for i := 0; i < 6; i++ {
+		uuid[i] = byte(ms >> uint(40-8*i))
+	}
+	return formatUUID(setUUIDVersion(uuid, 7)), nil
+}
+
+func setUUIDVersion(uuid [16]byte, version byte) [16]byte {
+	uuid[6] = uuid[6]&0x0f | version<<4
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return uuid
+}
+
+func formatUUID(uuid [16]byte) string {
+	h := hex.EncodeToString(uuid[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+func parseUUID(s string) ([16]byte, error) {
+	var uuid [16]byte
+	trimmed := s
+	if strings.HasPrefix(strings.ToLower(trimmed), "urn:uuid:") {
+		trimmed = trimmed[len("urn:uuid:"):]
+	} else if strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}") {
+		trimmed = trimmed[1 : len(trimmed)-1]
+	}
+	if len(trimmed) == 36 {
+		if trimmed[8] != '-' || trimmed[13] != '-' || trimmed[18] != '-' || trimmed[23] != '-' {
+			return uuid, fmt.Errorf("invalid UUID %q", s)
+		}
+		trimmed = trimmed[0:8] + trimmed[9:13] + trimmed[14:18] + trimmed[19:23] + trimmed[24:]
+	}
+	if len(trimmed) != 32 {
+		return uuid, fmt.Errorf("invalid UUID %q", s)
+	}
+	if _, err := hex.Decode(uuid[:], []byte(trimmed)); err != nil {
+		return uuid, fmt.Errorf("invalid UUID %q", s)
+	}
+	return uuid, nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2016 Bob Ziuchkovski
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package haven
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUUIDv4(t *testing.T) {
+	result, err := UUIDv4()
+	if err != nil {
+		t.Fatalf("UUIDv4 encountered unexpected error: %s", err)
+	}
+	if !IsUUID(result) || result[14] != '4' || !strings.ContainsRune("89ab", rune(result[19])) {
+		t.Errorf("UUIDv4 result incorrect.  Received: %s", result)
+	}
+
+	e := env{rand: rand.New(rand.NewSource(42)), clock: time.Now}
+	first, _ := e.uuidV4()
+	e = env{rand: rand.New(rand.NewSource(42)), clock: time.Now}
+	second, _ := e.uuidV4()
+	if first != second {
+		t.Errorf("UUIDv4 result not deterministic for a seeded source.  First: %s, Second: %s", first, second)
+	}
+}
+
+func TestUUIDv5(t *testing.T) {
+	var tests = []struct {
+		Namespace string
+		Operand   string
+		Expected  string
+		Valid     bool
+	}{
+		{Namespace: "dns", Operand: "www.example.com", Expected: "2ed6657d-e927-568b-95e1-2665a8aea6a2", Valid: true},
+		{Namespace: "6ba7b810-9dad-11d1-80b4-00c04fd430c8", Operand: "www.example.com", Expected: "2ed6657d-e927-568b-95e1-2665a8aea6a2", Valid: true},
+		{Namespace: "url", Operand: "http://www.example.com/", Expected: "fcde3c85-2270-590f-9e7c-ee003d65e0e2", Valid: true},
+		{Namespace: "bogus", Operand: "www.example.com", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := UUIDv5(test.Namespace, test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("UUIDv5 encountered unexpected error: %s.  Namespace: %s, Operand: %s", err, test.Namespace, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("UUIDv5 failed to return an error.  Namespace: %s, Operand: %s, Received: %s", test.Namespace, test.Operand, result)
+		}
+		if result != test.Expected {
+			t.Errorf("UUIDv5 result incorrect.  Namespace: %s, Operand: %s, Expected: %s, Received: %s", test.Namespace, test.Operand, test.Expected, result)
+		}
+	}
+}
+
+func TestUUIDv7(t *testing.T) {
+	clock := time.Date(2022, 2, 22, 19, 22, 22, 0, time.UTC)
+	e := env{rand: rand.New(rand.NewSource(1)), clock: func() time.Time { return clock }}
+	result, err := e.uuidV7()
+	if err != nil {
+		t.Fatalf("UUIDv7 encountered unexpected error: %s", err)
+	}
+	if !strings.HasPrefix(result, "017f22e2-79b0-7") || !strings.ContainsRune("89ab", rune(result[19])) {
+		t.Errorf("UUIDv7 result incorrect.  Clock: %s, Received: %s", clock, result)
+	}
+
+	clock = clock.Add(time.Millisecond)
+	later, _ := e.uuidV7()
+	if later <= result {
+		t.Errorf("UUIDv7 results not time-ordered.  First: %s, Second: %s", result, later)
+	}
+
+	if result, err = UUIDv7(); err != nil || !IsUUID(result) {
+		t.Errorf("UUIDv7 result incorrect.  Received: %s, Error: %v", result, err)
+	}
+}
+
+func TestParseUUID(t *testing.T) {
+	var tests = []struct {
+		Operand  string
+		Expected string
+		Valid    bool
+	}{
+		{Operand: "f47ac10b-58cc-4372-a567-0e02b2c3d479", Expected: "f47ac10b-58cc-4372-a567-0e02b2c3d479", Valid: true},
+		{Operand: "F47AC10B-58CC-4372-A567-0E02B2C3D479", Expected: "f47ac10b-58cc-4372-a567-0e02b2c3d479", Valid: true},
+		{Operand: "f47ac10b58cc4372a5670e02b2c3d479", Expected: "f47ac10b-58cc-4372-a567-0e02b2c3d479", Valid: true},
+		{Operand: "{f47ac10b-58cc-4372-a567-0e02b2c3d479}", Expected: "f47ac10b-58cc-4372-a567-0e02b2c3d479", Valid: true},
+		{Operand: "urn:uuid:f47ac10b-58cc-4372-a567-0e02b2c3d479", Expected: "f47ac10b-58cc-4372-a567-0e02b2c3d479", Valid: true},
+		{Operand: "f47ac10b-58cc-4372-a567-0e02b2c3d47", Valid: false},
+		{Operand: "f47ac10b_58cc_4372_a567_0e02b2c3d479", Valid: false},
+		{Operand: "g47ac10b-58cc-4372-a567-0e02b2c3d479", Valid: false},
+		{Operand: "", Valid: false},
+	}
+
+	for _, test := range tests {
+		result, err := ParseUUID(test.Operand)
+		if test.Valid && err != nil {
+			t.Errorf("ParseUUID encountered unexpected error: %s.  Operand: %s", err, test.Operand)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("ParseUUID failed to return an error.  Operand: %s, Received: %s", test.Operand, result)
+		}
+		if result != test.Expected {
+			t.Errorf("ParseUUID result incorrect.  Operand: %s, Expected: %s, Received: %s", test.Operand, test.Expected, result)
+		}
+		if IsUUID(test.Operand) != test.Valid {
+			t.Errorf("IsUUID result incorrect.  Operand: %s, Expected: %t", test.Operand, test.Valid)
+		}
+	}
+}
@@ -20,9 +20,18 @@
 
 package haven
 
+import "fmt"
+
 // Version records the version of the haven package
-var Version = struct {
+var Version = PackageVersion{0, 5, 1}
+
+// PackageVersion is the type of Version.  Its fields are those of the anonymous struct that
+// Version was declared with originally, so Version remains assignable to that struct type.
+type PackageVersion struct {
 	Major int
 	Minor int
 	Patch int
-}{0, 5, 1}
+}
+
+// String returns v in semantic version form, such as "0.5.1".
+func (v PackageVersion) String() string { return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch) }